@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"blogging-platform-api/configs"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = "usage: config print | flags"
+
+// Config runs "config print|flags", print writes the effective config as yaml with secrets redacted and the source of every value as comment,
+// the output can be used as config file, problems found while loading are listed after it, it returns the process exit code
+func Config(config configs.Config, errLoad error, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "print":
+		output, err := configYaml(config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error when writing config: "+err.Error())
+			return 1
+		}
+		fmt.Print(output)
+	case "flags":
+		configs.Usage(os.Stdout)
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+	if errLoad != nil {
+		fmt.Fprintln(os.Stderr, "invalid config:")
+		for _, problem := range strings.Split(errLoad.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  "+problem)
+		}
+		return 1
+	}
+	return 0
+}
+
+func configYaml(config configs.Config) (string, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+	for _, setting := range config.Settings() {
+		section, ok := sections[setting.Section]
+		if !ok {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			sections[setting.Section] = section
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: setting.Section}, section)
+		}
+		value := &yaml.Node{}
+		err := value.Encode(setting.Value)
+		if err != nil {
+			return "", err
+		}
+		if value.Kind == yaml.SequenceNode {
+			value.Style = yaml.FlowStyle
+		}
+		value.LineComment = setting.Env + " from " + setting.Source
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: setting.Key}
+		section.Content = append(section.Content, key, value)
+	}
+	if root.Content == nil {
+		return "", errors.New("config has no settings")
+	}
+	output := &strings.Builder{}
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	err := encoder.Encode(root)
+	if err != nil {
+		return "", err
+	}
+	err = encoder.Close()
+	return output.String(), err
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"blogging-platform-api/services"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Import runs "import [-format jsonl|zip|tar|tar.gz] <file>" and prints the per record report as json, it returns the process exit code
+func Import(importService services.ImportService, args []string) int {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flagSet.String("format", "", "jsonl, zip, tar or tar.gz, detected from the file name when empty")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if flagSet.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import [-format jsonl|zip|tar|tar.gz] <file>")
+		return 2
+	}
+	fileName := flagSet.Arg(0)
+	if *format == "" {
+		*format = services.ImportFormatFromName(fileName)
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when opening file: "+err.Error())
+		return 1
+	}
+	defer file.Close()
+
+	response, err := importService.Import(context.Background(), *format, file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when importing: "+err.Error())
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(response)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when writing report: "+err.Error())
+		return 1
+	}
+	if response.Failed > 0 {
+		return 1
+	}
+	return 0
+}
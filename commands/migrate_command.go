@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status | to <version>"
+
+// Migrate runs "migrate up|down [steps]|status|to <version>", it returns the process exit code
+func Migrate(migrationUtil utils.MigrationUtil, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	ctx := context.Background()
+	var changed []utils.Migration
+	var err error
+	switch args[0] {
+	case "up":
+		changed, err = migrationUtil.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, "steps must be a positive number")
+				return 2
+			}
+		}
+		changed, err = migrationUtil.Down(ctx, steps)
+	case "to":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, errParse := strconv.ParseInt(args[1], 10, 64)
+		if errParse != nil {
+			fmt.Fprintln(os.Stderr, "version must be a number")
+			return 2
+		}
+		changed, err = migrationUtil.To(ctx, version)
+	case "status":
+		return migrationStatus(migrationUtil)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	for _, migration := range changed {
+		fmt.Println("migrated", strconv.FormatInt(migration.Version, 10)+"_"+migration.Name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when migrating: "+err.Error())
+		return 1
+	}
+	if len(changed) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+	return 0
+}
+
+func migrationStatus(migrationUtil utils.MigrationUtil) int {
+	statuses, err := migrationUtil.Status(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when reading migration status: "+err.Error())
+		return 1
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = "applied " + status.AppliedAt.Format("2006-01-02T15:04:05Z")
+		}
+		fmt.Printf("%-6d %-30s %s\n", status.Version, status.Name, appliedAt)
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// GenerateSite runs "site [-out dir] [-theme dir] [-base-url url] [-title title] [-per-page n]" and renders the static site, it returns the process exit code,
+// baseUrl is the default of -base-url
+func GenerateSite(postgresUtil utils.PostgresUtil, blogRepository repositories.BlogRepository, baseUrl string, args []string) int {
+	if baseUrl == "" {
+		baseUrl = "/"
+	}
+	flagSet := flag.NewFlagSet("site", flag.ContinueOnError)
+	outputDir := flagSet.String("out", "public", "directory the site is written to")
+	themeDir := flagSet.String("theme", "themes/default", "directory containing layout.html, index.html, post.html, taxonomy.html and static/")
+	flagSet.StringVar(&baseUrl, "base-url", baseUrl, "url the site is served from")
+	title := flagSet.String("title", "Blog", "site title")
+	postsPerPage := flagSet.Int("per-page", 10, "posts per index page")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	staticSiteService := services.NewStaticSiteService(postgresUtil, blogRepository, *themeDir, baseUrl, *title, *postsPerPage)
+	err = staticSiteService.Generate(context.Background(), *outputDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when generating site: "+err.Error())
+		return 1
+	}
+	fmt.Println("site generated in " + *outputDir)
+	return 0
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"blogging-platform-api/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ImportWordpress runs "import-wordpress <file.xml>" and prints the per post report as json, it returns the process exit code
+func ImportWordpress(wordpressImportService services.WordpressImportService, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-wordpress <file.xml>")
+		return 2
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when opening file: "+err.Error())
+		return 1
+	}
+	defer file.Close()
+
+	response, err := wordpressImportService.Import(context.Background(), file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when importing: "+err.Error())
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(response)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error when writing report: "+err.Error())
+		return 1
+	}
+	if response.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"blogging-platform-api/exceptions"
 	modelrequests "blogging-platform-api/models/requests"
 	"blogging-platform-api/services"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -31,59 +33,70 @@ func (controller *BlogControllerImplementation) Create(c echo.Context) error {
 	var createRequest modelrequests.CreateRequest
 	err := c.Bind(&createRequest)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"message": err.Error(),
-		})
+		return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", err)
 	}
-	httpCode, response := controller.BlogService.Create(c.Request().Context(), createRequest)
-	return c.JSON(httpCode, response)
+	response, err := controller.BlogService.Create(c.Request().Context(), createRequest)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, response)
 }
 
 func (controller *BlogControllerImplementation) Update(c echo.Context) error {
 	var updateRequest modelrequests.UpdateRequest
 	err := c.Bind(&updateRequest)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"message": err.Error(),
-		})
+		return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", err)
+	}
+	id, err := pathId(c)
+	if err != nil {
+		return err
 	}
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	response, err := controller.BlogService.Update(c.Request().Context(), id, updateRequest)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": err.Error(),
-		})
+		return err
 	}
-	httpCode, response := controller.BlogService.Update(c.Request().Context(), id, updateRequest)
-	return c.JSON(httpCode, response)
+	return c.JSON(http.StatusOK, response)
 }
 
 func (controller *BlogControllerImplementation) Delete(c echo.Context) error {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := pathId(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": err.Error(),
-		})
+		return err
 	}
-	httpCode, _ := controller.BlogService.Delete(c.Request().Context(), id)
-	return c.NoContent(httpCode)
+	err = controller.BlogService.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
 }
 
 func (controller *BlogControllerImplementation) FindById(c echo.Context) error {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := pathId(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"message": err.Error(),
-		})
+		return err
 	}
-	httpCode, response := controller.BlogService.FindById(c.Request().Context(), id)
-	return c.JSON(httpCode, response)
+	response, err := controller.BlogService.FindById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 func (controller *BlogControllerImplementation) FindAll(c echo.Context) error {
 	term := c.QueryParam("term")
-	httpCode, response := controller.BlogService.FindAllPosts(c.Request().Context(), term)
-	return c.JSON(httpCode, response)
+	response, err := controller.BlogService.FindAllPosts(c.Request().Context(), term)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// pathId parses the id path parameter, ids are int4 in the database so an id outside 1..math.MaxInt32 is invalid and never reaches a query
+func pathId(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 || id > math.MaxInt32 {
+		return 0, exceptions.NewBadRequestError(exceptions.CodeInvalidId, "id must be a positive 32 bit integer", err)
+	}
+	return id, nil
 }
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/protos/blogpb"
+	"blogging-platform-api/services"
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listPageSize is the number of posts ListPosts reads from the database at once
+const listPageSize = 100
+
+// BlogGrpcControllerImplementation serves the grpc BlogService with the same BlogService as the blog routes,
+// errors are returned as grpc statuses carrying the code of the problem response in an ErrorInfo detail
+type BlogGrpcControllerImplementation struct {
+	blogpb.UnimplementedBlogServiceServer
+	BlogService services.BlogService
+}
+
+func NewBlogGrpcController(blogService services.BlogService) blogpb.BlogServiceServer {
+	return &BlogGrpcControllerImplementation{
+		BlogService: blogService,
+	}
+}
+
+func (controller *BlogGrpcControllerImplementation) CreatePost(ctx context.Context, request *blogpb.CreatePostRequest) (*blogpb.Post, error) {
+	response, err := controller.BlogService.Create(ctx, modelrequests.CreateRequest(postInput(request.GetPost())))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return grpcPost(response.Id, response.Title, response.Content, response.Category, response.Tags, response.CreatedAt, response.UpdatedAt), nil
+}
+
+func (controller *BlogGrpcControllerImplementation) UpdatePost(ctx context.Context, request *blogpb.UpdatePostRequest) (*blogpb.Post, error) {
+	id, err := grpcId(request.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	response, err := controller.BlogService.Update(ctx, id, postInput(request.GetPost()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return grpcPost(response.Id, response.Title, response.Content, response.Category, response.Tags, response.CreatedAt, response.UpdatedAt), nil
+}
+
+func (controller *BlogGrpcControllerImplementation) DeletePost(ctx context.Context, request *blogpb.DeletePostRequest) (*emptypb.Empty, error) {
+	id, err := grpcId(request.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	err = controller.BlogService.Delete(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (controller *BlogGrpcControllerImplementation) GetPost(ctx context.Context, request *blogpb.GetPostRequest) (*blogpb.Post, error) {
+	id, err := grpcId(request.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	response, err := controller.BlogService.FindById(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return grpcPost(response.Id, response.Title, response.Content, response.Category, response.Tags, response.CreatedAt, response.UpdatedAt), nil
+}
+
+// ListPosts reads the posts page by page so a large blog is never held in memory, a post created while streaming is sent when its id comes after the last sent one
+func (controller *BlogGrpcControllerImplementation) ListPosts(request *blogpb.ListPostsRequest, stream blogpb.BlogService_ListPostsServer) error {
+	ctx := stream.Context()
+	findPageRequest := modelrequests.FindPageRequest{Term: request.GetTerm(), Category: request.GetCategory(), First: listPageSize}
+	for {
+		page, err := controller.BlogService.FindPostsPage(ctx, findPageRequest)
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		for _, edge := range page.Edges {
+			err = stream.Send(grpcPostOf(edge.Post))
+			if err != nil {
+				return err
+			}
+		}
+		if !page.HasNextPage {
+			return nil
+		}
+		findPageRequest.After = page.EndCursor
+	}
+}
+
+func postInput(input *blogpb.PostInput) modelrequests.UpdateRequest {
+	return modelrequests.UpdateRequest{
+		Title:    input.GetTitle(),
+		Content:  input.GetContent(),
+		Category: input.GetCategory(),
+		Tags:     input.GetTags(),
+	}
+}
+
+// grpcId checks the id like the id path parameter, ids are int4 in the database
+func grpcId(id int64) (int, error) {
+	if id < 1 || id > math.MaxInt32 {
+		return 0, exceptions.NewBadRequestError(exceptions.CodeInvalidId, "id must be a positive 32 bit integer", nil)
+	}
+	return int(id), nil
+}
+
+func grpcPostOf(post modelresponses.PostResponse) *blogpb.Post {
+	return grpcPost(post.Id, post.Title, post.Content, post.Category, post.Tags, post.CreatedAt, post.UpdatedAt)
+}
+
+// grpcPost trims the tags so every rpc returns the tags of a post like ListPosts does
+func grpcPost(id int, title string, content string, category string, tags []string, createdAt string, updatedAt string) *blogpb.Post {
+	return &blogpb.Post{
+		Id:         int64(id),
+		Title:      title,
+		Content:    content,
+		Category:   category,
+		Tags:       services.TrimTags(tags),
+		CreateTime: grpcTimestamp(createdAt),
+		UpdateTime: grpcTimestamp(updatedAt),
+	}
+}
+
+// grpcTimestamp parses the dates of the responses of BlogService, they are always formatted as 2006-01-02T15:04:05Z
+func grpcTimestamp(value string) *timestamppb.Timestamp {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(parsed)
+}
+
+// grpcError is the grpc counterpart of HTTPErrorHandler, the status has the safe message of the AppError, an ErrorInfo with its code
+// and a BadRequest with its field errors, the cause of server errors is logged and never sent to the client
+func grpcError(ctx context.Context, err error) error {
+	appError := exceptions.AsAppError(err)
+	code := GrpcCode(appError.Kind)
+	if code == codes.Internal || code == codes.Unavailable {
+		slog.ErrorContext(ctx, "grpc call failed", "error", err)
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appError.Code, Domain: "blogging-platform-api"}}
+	if len(appError.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range appError.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message})
+		}
+		details = append(details, badRequest)
+	}
+	grpcStatus, errDetails := status.New(code, appError.Message).WithDetails(details...)
+	if errDetails != nil {
+		return status.Error(code, appError.Message)
+	}
+	return grpcStatus.Err()
+}
+
+// GrpcCode maps error kinds to grpc status codes like HttpCode maps them to http status codes
+func GrpcCode(kind exceptions.Kind) codes.Code {
+	switch kind {
+	case exceptions.KindBadRequest, exceptions.KindValidation:
+		return codes.InvalidArgument
+	case exceptions.KindNotFound:
+		return codes.NotFound
+	case exceptions.KindConflict:
+		return codes.AlreadyExists
+	case exceptions.KindAborted:
+		return codes.Aborted
+	case exceptions.KindUnavailable:
+		return codes.Unavailable
+	case exceptions.KindTooLarge:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	modelresponses "blogging-platform-api/models/responses"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// HTTPErrorHandler renders every error returned by a handler as application/problem+json, the cause of server errors is logged and never sent to the client
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		slog.ErrorContext(c.Request().Context(), "error after response was committed", "error", err)
+		return
+	}
+
+	var httpCode int
+	var problem modelresponses.ProblemResponse
+	var appError *exceptions.AppError
+	var httpError *echo.HTTPError
+	// a body read through http.MaxBytesReader fails wherever it is read, the error may come back wrapped as a bad request
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		err = exceptions.NewTooLargeError("request body is larger than "+strconv.FormatInt(maxBytesError.Limit, 10)+" bytes", err)
+	}
+	if !errors.As(err, &appError) && errors.As(err, &httpError) {
+		httpCode = httpError.Code
+		problem.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(httpCode)), " ", "_")
+		if httpCode < http.StatusInternalServerError {
+			problem.Detail = fmt.Sprint(httpError.Message)
+		}
+	} else {
+		appError = exceptions.AsAppError(err)
+		httpCode = HttpCode(appError.Kind)
+		problem.Code = appError.Code
+		problem.Detail = appError.Message
+		for _, field := range appError.Fields {
+			problem.Errors = append(problem.Errors, modelresponses.FieldErrorResponse(field))
+		}
+	}
+	if httpCode >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request().Context(), "request failed", "method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+	}
+
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(httpCode)
+	problem.Status = httpCode
+	problem.Instance = c.Request().URL.Path
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	c.Response().Header().Del(echo.HeaderContentDisposition)
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(httpCode)
+	} else {
+		err = c.JSON(httpCode, problem)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "error when writing problem response", "error", err)
+	}
+}
+
+// HttpCode is the single place where error kinds are mapped to http status codes
+func HttpCode(kind exceptions.Kind) int {
+	switch kind {
+	case exceptions.KindBadRequest, exceptions.KindValidation:
+		return http.StatusBadRequest
+	case exceptions.KindNotFound:
+		return http.StatusNotFound
+	case exceptions.KindConflict, exceptions.KindAborted:
+		return http.StatusConflict
+	case exceptions.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case exceptions.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
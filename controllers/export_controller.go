@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExportController interface {
+	Export(c echo.Context) error
+}
+
+type ExportControllerImplementation struct {
+	ExportService services.ExportService
+}
+
+func NewExportController(exportService services.ExportService) ExportController {
+	return &ExportControllerImplementation{
+		ExportService: exportService,
+	}
+}
+
+func (controller *ExportControllerImplementation) Export(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = services.ExportFormatJsonLines
+	}
+	if !services.IsExportFormat(format) {
+		return exceptions.NewBadRequestError(exceptions.CodeUnsupportedFormat, "unsupported export format, use jsonl, csv or markdown-zip", nil)
+	}
+
+	contentType, fileName := "application/jsonl", "posts.jsonl"
+	switch format {
+	case services.ExportFormatCsv:
+		contentType, fileName = "text/csv; charset=utf-8", "posts.csv"
+	case services.ExportFormatMarkdownZip:
+		contentType, fileName = "application/zip", "posts.zip"
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+fileName+`"`)
+
+	term := c.QueryParam("term")
+	return controller.ExportService.Export(c.Request().Context(), format, term, c.Response())
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/middlewares"
+	modelrequests "blogging-platform-api/models/requests"
+	"blogging-platform-api/services"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GraphqlController interface {
+	Graphql(c echo.Context) error
+}
+
+type GraphqlControllerImplementation struct {
+	GraphqlService services.GraphqlService
+}
+
+func NewGraphqlController(graphqlService services.GraphqlService) GraphqlController {
+	return &GraphqlControllerImplementation{
+		GraphqlService: graphqlService,
+	}
+}
+
+// Graphql answers 200 for every operation that could be read, errors of the operation are in the errors member of the body,
+// a body without a query is a bad request like on the other routes, only mutations count as writes for read your writes
+func (controller *GraphqlControllerImplementation) Graphql(c echo.Context) error {
+	var graphqlRequest modelrequests.GraphqlRequest
+	err := c.Bind(&graphqlRequest)
+	if err != nil {
+		return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", err)
+	}
+	if graphqlRequest.Query == "" {
+		return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "query is required", nil)
+	}
+	response := controller.GraphqlService.Execute(c.Request().Context(), graphqlRequest)
+	middlewares.MarkWrite(c, response.Mutation)
+	return c.JSON(http.StatusOK, response)
+}
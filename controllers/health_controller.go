@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/services"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthController interface {
+	Healthz(c echo.Context) error
+	Readyz(c echo.Context) error
+	Health(c echo.Context) error
+}
+
+type HealthControllerImplementation struct {
+	HealthService services.HealthService
+}
+
+func NewHealthController(healthService services.HealthService) HealthController {
+	return &HealthControllerImplementation{
+		HealthService: healthService,
+	}
+}
+
+func (controller *HealthControllerImplementation) Healthz(c echo.Context) error {
+	return writeHealth(c, controller.HealthService.Live(), true)
+}
+
+func (controller *HealthControllerImplementation) Readyz(c echo.Context) error {
+	response, ready := controller.HealthService.Ready(c.Request().Context())
+	return writeHealth(c, response, ready)
+}
+
+func (controller *HealthControllerImplementation) Health(c echo.Context) error {
+	response, healthy := controller.HealthService.Health(c.Request().Context())
+	return writeHealth(c, response, healthy)
+}
+
+// writeHealth answers 503 when not up so probes that only look at the status code work, probes must never be cached
+func writeHealth(c echo.Context, response modelresponses.HealthResponse, up bool) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	if !up {
+		return c.JSON(http.StatusServiceUnavailable, response)
+	}
+	return c.JSON(http.StatusOK, response)
+}
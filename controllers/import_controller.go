@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/services"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ImportController interface {
+	Import(c echo.Context) error
+}
+
+type ImportControllerImplementation struct {
+	ImportService services.ImportService
+}
+
+func NewImportController(importService services.ImportService) ImportController {
+	return &ImportControllerImplementation{
+		ImportService: importService,
+	}
+}
+
+// Import accepts either a multipart upload in the "file" field or the raw file as request body, the format query param overrides the detected format
+func (controller *ImportControllerImplementation) Import(c echo.Context) error {
+	var reader io.Reader = c.Request().Body
+	format := services.ImportFormatFromContentType(c.Request().Header.Get(echo.HeaderContentType))
+	fileHeader, err := c.FormFile("file")
+	// an upload over the body limit must not fall back to reading the body that was already consumed
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return err
+	}
+	if err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "uploaded file can not be opened", err)
+		}
+		defer file.Close()
+		reader = file
+		format = services.ImportFormatFromName(fileHeader.Filename)
+	}
+	if c.QueryParam("format") != "" {
+		format = c.QueryParam("format")
+	}
+	if format == "" {
+		return exceptions.NewBadRequestError(exceptions.CodeUnsupportedFormat, "unknown import format, use jsonl, zip, tar or tar.gz", nil)
+	}
+	response, err := controller.ImportService.Import(c.Request().Context(), format, reader)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
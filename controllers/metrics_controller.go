@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"blogging-platform-api/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MetricsController interface {
+	Metrics(c echo.Context) error
+}
+
+type MetricsControllerImplementation struct {
+	MetricsUtil utils.MetricsUtil
+}
+
+func NewMetricsController(metricsUtil utils.MetricsUtil) MetricsController {
+	return &MetricsControllerImplementation{
+		MetricsUtil: metricsUtil,
+	}
+}
+
+func (controller *MetricsControllerImplementation) Metrics(c echo.Context) error {
+	controller.MetricsUtil.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"blogging-platform-api/docs"
+	"blogging-platform-api/services"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OpenApiController interface {
+	OpenApi(c echo.Context) error
+	Docs(c echo.Context) error
+	Redoc(c echo.Context) error
+}
+
+type OpenApiControllerImplementation struct {
+	OpenApiService services.OpenApiService
+}
+
+func NewOpenApiController(openApiService services.OpenApiService) OpenApiController {
+	return &OpenApiControllerImplementation{
+		OpenApiService: openApiService,
+	}
+}
+
+func (controller *OpenApiControllerImplementation) OpenApi(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, controller.OpenApiService.Json())
+}
+
+func (controller *OpenApiControllerImplementation) Docs(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docs.Page)
+}
+
+// Redoc serves the embedded redoc bundle, a build without it redirects to the same pinned release
+func (controller *OpenApiControllerImplementation) Redoc(c echo.Context) error {
+	if docs.Redoc == nil {
+		return c.Redirect(http.StatusFound, docs.RedocUrl)
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=86400")
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJavaScriptCharsetUTF8, docs.Redoc)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/services"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const MIMETextEventStream = "text/event-stream"
+
+type PostEventController interface {
+	Events(c echo.Context) error
+}
+
+type PostEventControllerImplementation struct {
+	PostEventService services.PostEventService
+	Heartbeat        time.Duration
+}
+
+func NewPostEventController(postEventService services.PostEventService, heartbeat time.Duration) PostEventController {
+	return &PostEventControllerImplementation{
+		PostEventService: postEventService,
+		Heartbeat:        heartbeat,
+	}
+}
+
+// Events streams the post events as server sent events, a reconnecting EventSource sends the id of the last event it got in Last-Event-ID
+// and gets the events it missed from the log first, a comment is sent every Heartbeat so proxies do not close an idle stream,
+// the stream ends when the client goes away, falls behind or the server shuts down and the client reconnects
+func (controller *PostEventControllerImplementation) Events(c echo.Context) error {
+	ctx := c.Request().Context()
+	subscription, err := controller.PostEventService.Subscribe(ctx, c.Request().Header.Get("Last-Event-ID"))
+	if err != nil {
+		return err
+	}
+	defer controller.PostEventService.Unsubscribe(subscription)
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, MIMETextEventStream)
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// nginx buffers proxied responses unless it is told not to
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	for _, event := range subscription.Backlog {
+		if writeEvent(response, event) != nil {
+			return nil
+		}
+	}
+	response.Flush()
+
+	heartbeat := time.NewTicker(controller.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			_, err = io.WriteString(response, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return nil
+			}
+			err = writeEvent(response, event)
+		}
+		if err != nil {
+			return nil
+		}
+		response.Flush()
+	}
+}
+
+func writeEvent(writer io.Writer, event modelresponses.PostEventResponse) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
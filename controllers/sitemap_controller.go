@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/services"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SitemapController interface {
+	Sitemap(c echo.Context) error
+	PostsSitemap(c echo.Context) error
+	TaxonomiesSitemap(c echo.Context) error
+}
+
+type SitemapControllerImplementation struct {
+	SitemapService services.SitemapService
+}
+
+func NewSitemapController(sitemapService services.SitemapService) SitemapController {
+	return &SitemapControllerImplementation{
+		SitemapService: sitemapService,
+	}
+}
+
+func (controller *SitemapControllerImplementation) Sitemap(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+	return controller.SitemapService.WriteSitemap(c.Request().Context(), c.Response())
+}
+
+func (controller *SitemapControllerImplementation) PostsSitemap(c echo.Context) error {
+	page, err := sitemapPage(c)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+	return controller.SitemapService.WritePostsSitemap(c.Request().Context(), c.Response(), page)
+}
+
+func (controller *SitemapControllerImplementation) TaxonomiesSitemap(c echo.Context) error {
+	page, err := sitemapPage(c)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+	return controller.SitemapService.WriteTaxonomiesSitemap(c.Request().Context(), c.Response(), page)
+}
+
+// sitemapPage reads the page of a paged sitemap file name like 2.xml
+func sitemapPage(c echo.Context) (int, error) {
+	pageParam, ok := strings.CutSuffix(c.Param("page"), ".xml")
+	page, err := strconv.Atoi(pageParam)
+	if !ok || err != nil {
+		return 0, exceptions.NewNotFoundError(exceptions.CodeNotFound, "sitemap page not found")
+	}
+	return page, nil
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/services"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WordpressImportController interface {
+	Import(c echo.Context) error
+}
+
+type WordpressImportControllerImplementation struct {
+	WordpressImportService services.WordpressImportService
+}
+
+func NewWordpressImportController(wordpressImportService services.WordpressImportService) WordpressImportController {
+	return &WordpressImportControllerImplementation{
+		WordpressImportService: wordpressImportService,
+	}
+}
+
+// Import accepts the wxr file either as multipart upload in the "file" field or as raw request body
+func (controller *WordpressImportControllerImplementation) Import(c echo.Context) error {
+	var reader io.Reader = c.Request().Body
+	fileHeader, err := c.FormFile("file")
+	// an upload over the body limit must not fall back to reading the body that was already consumed
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return err
+	}
+	if err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "uploaded file can not be opened", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	response, err := controller.WordpressImportService.Import(c.Request().Context(), reader)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,17 @@
+package databases
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Migrations holds the versioned schema migrations, every version has a {version}_{name}.up.sql and a {version}_{name}.down.sql file
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+//go:embed sqlite/migrations/*.sql
+var sqliteFiles embed.FS
+
+// SqliteMigrations holds the sqlite version of every migration of Migrations with the same versions and names, a schema change must be added to both
+var SqliteMigrations, _ = fs.Sub(sqliteFiles, "sqlite")
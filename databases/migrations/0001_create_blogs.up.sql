@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS blogs (
+	id SERIAL PRIMARY KEY,
+	title varchar(50) NOT NULL,
+	content text NOT NULL,
+	category varchar(50) NOT NULL,
+	tags text NOT NULL,
+	created_at bigint NOT NULL,
+	updated_at bigint
+);
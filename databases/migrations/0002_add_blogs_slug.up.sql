@@ -0,0 +1 @@
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS slug varchar(255) UNIQUE;
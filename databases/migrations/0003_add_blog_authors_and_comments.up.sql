@@ -0,0 +1,10 @@
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS author varchar(100);
+CREATE TABLE IF NOT EXISTS blog_comments (
+	id SERIAL PRIMARY KEY,
+	post_id integer NOT NULL REFERENCES blogs (id) ON DELETE CASCADE,
+	source_id varchar(64) NOT NULL,
+	author varchar(100) NOT NULL,
+	content text NOT NULL,
+	created_at bigint NOT NULL,
+	UNIQUE (post_id, source_id)
+);
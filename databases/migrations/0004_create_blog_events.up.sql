@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS blog_events (
+	id BIGSERIAL PRIMARY KEY,
+	type varchar(20) NOT NULL,
+	post_id integer NOT NULL,
+	payload text NOT NULL,
+	created_at bigint NOT NULL
+);
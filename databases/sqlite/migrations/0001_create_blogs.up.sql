@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS blogs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title varchar(50) NOT NULL CHECK (length(title) <= 50),
+	content text NOT NULL,
+	category varchar(50) NOT NULL CHECK (length(category) <= 50),
+	tags text NOT NULL,
+	created_at bigint NOT NULL,
+	updated_at bigint
+);
@@ -0,0 +1,2 @@
+ALTER TABLE blogs ADD COLUMN slug varchar(255) CHECK (length(slug) <= 255);
+CREATE UNIQUE INDEX IF NOT EXISTS blogs_slug_key ON blogs (slug);
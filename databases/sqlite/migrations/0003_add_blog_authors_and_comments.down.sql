@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS blog_comments;
+ALTER TABLE blogs DROP COLUMN author;
@@ -0,0 +1,10 @@
+ALTER TABLE blogs ADD COLUMN author varchar(100) CHECK (length(author) <= 100);
+CREATE TABLE IF NOT EXISTS blog_comments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id integer NOT NULL REFERENCES blogs (id) ON DELETE CASCADE,
+	source_id varchar(64) NOT NULL CHECK (length(source_id) <= 64),
+	author varchar(100) NOT NULL CHECK (length(author) <= 100),
+	content text NOT NULL,
+	created_at bigint NOT NULL,
+	UNIQUE (post_id, source_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS blog_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type varchar(20) NOT NULL CHECK (length(type) <= 20),
+	post_id integer NOT NULL,
+	payload text NOT NULL,
+	created_at bigint NOT NULL
+);
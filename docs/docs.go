@@ -0,0 +1,22 @@
+package docs
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// RedocUrl is the pinned redoc release the bundle is downloaded from, the api serves the embedded bundle instead when it was downloaded
+const RedocUrl = "https://cdn.jsdelivr.net/npm/redoc@2.2.0/bundles/redoc.standalone.js"
+
+//go:generate curl -sSfL -o redoc/redoc.standalone.js https://cdn.jsdelivr.net/npm/redoc@2.2.0/bundles/redoc.standalone.js
+
+// Page renders /openapi.json with redoc, the script is loaded from the api so the page does not depend on a third party host
+//
+//go:embed index.html
+var Page []byte
+
+//go:embed all:redoc
+var redoc embed.FS
+
+// Redoc is the redoc bundle written by go generate, it is nil until the bundle was downloaded into redoc/
+var Redoc, _ = fs.ReadFile(redoc, "redoc/redoc.standalone.js")
@@ -0,0 +1,185 @@
+package exceptions
+
+import (
+	"blogging-platform-api/utils"
+	"errors"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnavailable
+	KindTooLarge
+	// KindAborted is a write that lost a race with another transaction and can be tried again, unlike KindConflict
+	KindAborted
+)
+
+// String is the snake case name of kind, it is used as a metric label
+func (kind Kind) String() string {
+	switch kind {
+	case KindBadRequest:
+		return "bad_request"
+	case KindValidation:
+		return "validation"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnavailable:
+		return "unavailable"
+	case KindTooLarge:
+		return "too_large"
+	case KindAborted:
+		return "aborted"
+	}
+	return "internal"
+}
+
+// stable error codes returned to clients in the code member of problem responses
+const (
+	CodeInternal          = "internal_error"
+	CodeBadRequest        = "bad_request"
+	CodeInvalidId         = "invalid_id"
+	CodeInvalidBody       = "invalid_body"
+	CodeInvalidCursor     = "invalid_cursor"
+	CodeInvalidEventId    = "invalid_last_event_id"
+	CodeQueryTooComplex   = "query_too_complex"
+	CodeUnsupportedFormat = "unsupported_format"
+	CodeValidation        = "validation_failed"
+	CodeNotFound          = "not_found"
+	CodePostNotFound      = "post_not_found"
+	CodeConflict          = "conflict"
+	CodeUnavailable       = "service_unavailable"
+	CodeTooLarge          = "payload_too_large"
+)
+
+// AppError is returned by services, Message and Fields are safe to show to clients while Err keeps the internal cause for logging only
+type AppError struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError describes one failed validation rule, Field uses the json name of the field
+type FieldError struct {
+	Field   string
+	Rule    string
+	Param   string
+	Message string
+}
+
+func (appError *AppError) Error() string {
+	if appError.Err != nil {
+		return appError.Code + ": " + appError.Message + ": " + appError.Err.Error()
+	}
+	return appError.Code + ": " + appError.Message
+}
+
+func (appError *AppError) Unwrap() error {
+	return appError.Err
+}
+
+func NewInternalError(err error) error {
+	return &AppError{Kind: KindInternal, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+func NewBadRequestError(code string, message string, err error) error {
+	return &AppError{Kind: KindBadRequest, Code: code, Message: message, Err: err}
+}
+
+// NewValidationError turns the errors of validator.Struct into field errors translated with translator, the english translator is used when translator is nil
+func NewValidationError(err error, translator ut.Translator) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return &AppError{Kind: KindValidation, Code: CodeValidation, Message: err.Error(), Err: err}
+	}
+	if translator == nil {
+		translator = utils.DefaultTranslator()
+	}
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, validationError := range validationErrors {
+		message := validationError.Translate(translator)
+		fields = append(fields, FieldError{
+			Field:   validationError.Field(),
+			Rule:    validationError.Tag(),
+			Param:   validationError.Param(),
+			Message: message,
+		})
+	}
+	return &AppError{Kind: KindValidation, Code: CodeValidation, Message: "validation failed", Fields: fields, Err: err}
+}
+
+// NewFieldsValidationError is a validation error of fields checked outside of the validator, like the openapi schema of a request
+func NewFieldsValidationError(fields []FieldError) error {
+	return &AppError{Kind: KindValidation, Code: CodeValidation, Message: "validation failed", Fields: fields}
+}
+
+func NewNotFoundError(code string, message string) error {
+	return &AppError{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(message string, err error) error {
+	return &AppError{Kind: KindConflict, Code: CodeConflict, Message: message, Err: err}
+}
+
+// NewAbortedError keeps the conflict code for http clients, only the grpc status tells it apart from a conflict
+func NewAbortedError(message string, err error) error {
+	return &AppError{Kind: KindAborted, Code: CodeConflict, Message: message, Err: err}
+}
+
+func NewUnavailableError(err error) error {
+	return &AppError{Kind: KindUnavailable, Code: CodeUnavailable, Message: "service temporarily unavailable", Err: err}
+}
+
+func NewTooLargeError(message string, err error) error {
+	return &AppError{Kind: KindTooLarge, Code: CodeTooLarge, Message: message, Err: err}
+}
+
+// NewDatabaseError maps errors returned by the repositories, constraint violations become client errors, application errors are kept and everything else is internal
+func NewDatabaseError(err error) error {
+	var appError *AppError
+	if errors.As(err, &appError) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NewNotFoundError(CodePostNotFound, "post not found")
+	}
+	if errors.Is(err, utils.ErrCircuitOpen) || utils.IsConnectionError(err) {
+		return NewUnavailableError(err)
+	}
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		switch pgError.Code {
+		case "23505":
+			return NewConflictError("post already exists", err)
+		case "40001", "40P01":
+			return NewAbortedError("post was changed by another request, try again", err)
+		case "22001":
+			return &AppError{Kind: KindValidation, Code: CodeValidation, Message: "value too long", Err: err}
+		case "23502", "23514":
+			return &AppError{Kind: KindValidation, Code: CodeValidation, Message: "invalid value", Err: err}
+		}
+	}
+	return NewInternalError(err)
+}
+
+// AsAppError returns err as *AppError, errors that are not application errors are wrapped as internal errors
+func AsAppError(err error) *AppError {
+	var appError *AppError
+	if errors.As(err, &appError) {
+		return appError
+	}
+	return NewInternalError(err).(*AppError)
+}
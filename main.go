@@ -1,43 +1,229 @@
 package main
 
 import (
+	"blogging-platform-api/commands"
+	"blogging-platform-api/configs"
 	"blogging-platform-api/controllers"
+	"blogging-platform-api/databases"
+	"blogging-platform-api/middlewares"
 	"blogging-platform-api/repositories"
 	"blogging-platform-api/routes"
 	"blogging-platform-api/services"
 	"blogging-platform-api/utils"
 	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"time"
+	"strings"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	postgresUtil := utils.NewPostgresConnection()
-	validate := validator.New()
+	config, args, errConfig := configs.Load(os.Args[1:])
+	utils.SetupLogger(config.Log.Level)
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(commands.Config(config, errConfig, args[1:]))
+	}
+	if errors.Is(errConfig, flag.ErrHelp) {
+		configs.Usage(os.Stderr)
+		os.Exit(2)
+	}
+	if errConfig != nil {
+		utils.Fatal("invalid config", "problems", strings.Split(errConfig.Error(), "\n"))
+	}
+	shutdownTracing := utils.SetupTracing(context.Background(), config.Tracing)
+
+	// services only know PostgresUtil, with DATABASE_DRIVER=sqlite it is backed by a sqlite database and the sqlite repository
+	var postgresUtil utils.PostgresUtil
+	var migrationUtil utils.MigrationUtil
+	var blogRepository repositories.BlogRepository
+	var err error
+	switch config.Database.Driver {
+	case "postgres":
+		postgresUtil = utils.NewPostgresConnection(config.Postgres)
+		migrationUtil, err = utils.NewMigrationUtil(postgresUtil, databases.Migrations)
+		blogRepository = repositories.NewBlogRepository()
+	case "sqlite":
+		sqliteUtil := utils.NewSqliteConnection(config.Sqlite)
+		postgresUtil = sqliteUtil
+		migrationUtil, err = utils.NewSqliteMigrationUtil(sqliteUtil, databases.SqliteMigrations)
+		blogRepository = repositories.NewBlogSqliteRepository(sqliteUtil.GetDB())
+	}
+	if err != nil {
+		utils.Fatal("error when reading migrations", "error", err)
+	}
+	retryPolicy := utils.NewDatabaseRetryPolicy(config.Postgres.RetryAttempts)
+	metricsUtil := utils.NewMetricsUtil()
+	metricsUtil.RegisterPostgresPools(postgresUtil)
+	blogRepository = repositories.NewInstrumentedBlogRepository(blogRepository, metricsUtil)
+	blogRepository = repositories.NewResilientBlogRepository(blogRepository, retryPolicy, utils.NewCircuitBreaker(config.Postgres.BreakerThreshold, config.Postgres.BreakerCooldown))
+	validate, universalTranslator := utils.NewValidator()
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.RequestId())
+	e.Use(middlewares.Metrics(metricsUtil))
+	e.Use(middlewares.Tracing())
+	e.Use(middlewares.AccessLog())
+	e.Use(middlewares.Locale(universalTranslator))
+	if len(config.Postgres.ReplicaHosts) > 0 {
+		e.Use(middlewares.ReadYourWrites(config.Postgres.ReadYourWritesWindow, config.Server.CookieSecure))
+	}
 
-	blogRepository := repositories.NewBlogRepository()
-	blogService := services.NewBlogService(postgresUtil, validate, blogRepository)
-	blogController := controllers.NewBlogController(blogService)
-	routes.BlogRoute(e, blogController)
+	blogService := services.NewInstrumentedBlogService(services.NewTracedBlogService(services.NewBlogService(postgresUtil, validate, blogRepository, retryPolicy, config.Events.LogSize)), metricsUtil)
+	importMaxSize, importMaxEntrySize := config.Import.Limits()
+	importService := services.NewImportService(postgresUtil, validate, blogRepository, importMaxSize, importMaxEntrySize)
+	wordpressImportService := services.NewWordpressImportService(postgresUtil, validate, blogRepository)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			exitCode := commands.Migrate(migrationUtil, args[1:])
+			postgresUtil.Close()
+			os.Exit(exitCode)
+		case "import":
+			exitCode := commands.Import(importService, args[1:])
+			postgresUtil.Close()
+			os.Exit(exitCode)
+		case "import-wordpress":
+			exitCode := commands.ImportWordpress(wordpressImportService, args[1:])
+			postgresUtil.Close()
+			os.Exit(exitCode)
+		case "site":
+			exitCode := commands.GenerateSite(postgresUtil, blogRepository, config.Site.BaseUrl, args[1:])
+			postgresUtil.Close()
+			os.Exit(exitCode)
+		default:
+			utils.Fatal("unknown command " + args[0])
+		}
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
-	go func() {
-		if err := e.Start(os.Getenv("ECHO_HOST")); err != nil && err != http.ErrServerClosed {
-			e.Logger.Fatal("shutting down the server")
+	if config.Database.AutoMigrate {
+		applied, err := migrationUtil.Up(context.Background())
+		if err != nil {
+			utils.Fatal("error when migrating", "error", err)
 		}
-	}()
+		slog.Info("database: applied migrations", "count", len(applied))
+	}
+
+	openApiService := services.NewOpenApiService(config.Site.BaseUrl)
+	blogController := controllers.NewBlogController(blogService)
+	importController := controllers.NewImportController(importService)
+	wordpressImportController := controllers.NewWordpressImportController(wordpressImportService)
+	exportService := services.NewExportService(postgresUtil, blogRepository)
+	exportController := controllers.NewExportController(exportService)
+	// a post is one json line of an import so a body gets the entry limit of the imports
+	openApiValidation := middlewares.OpenApiValidation(openApiService, config.OpenApi.ValidateResponses, importMaxEntrySize)
+	importBodyLimit := middlewares.BodyLimit(importMaxSize)
+	postEventService := services.NewPostEventService(postgresUtil, blogRepository)
+	postEventController := controllers.NewPostEventController(postEventService, config.Events.Heartbeat)
 
-	<-ctx.Done()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := e.Shutdown(ctx); err != nil {
-		e.Logger.Fatal(err)
+	// v1 is mounted under /v1 and, until the sunset date, at the root for clients that predate versioning, a v2 gets its own group and controllers
+	v1Route := func(router routes.Router, m ...echo.MiddlewareFunc) {
+		routes.BlogRoute(router, blogController, append(m, openApiValidation)...)
+		// the event stream is not validated, buffering the response to check it would hold the events back
+		routes.PostEventRoute(router, postEventController, m...)
+		routes.ImportRoute(router, importController, append(m, importBodyLimit)...)
+		routes.WordpressImportRoute(router, wordpressImportController, append(m, importBodyLimit)...)
+		routes.ExportRoute(router, exportController, m...)
 	}
+	v1Route(e.Group("/v1"))
+	aliasDeprecation, aliasSunset := config.Api.AliasDates()
+	v1Route(e, middlewares.Deprecation(aliasDeprecation, aliasSunset, "/v1"))
+
+	sitemapService := services.NewSitemapService(postgresUtil, blogRepository, config.Site.BaseUrl)
+	sitemapController := controllers.NewSitemapController(sitemapService)
+	routes.SitemapRoute(e, sitemapController)
+
+	healthService := services.NewHealthService(postgresUtil, migrationUtil, config.Health.CheckTimeout)
+	healthController := controllers.NewHealthController(healthService)
+	routes.HealthRoute(e, healthController)
+
+	graphqlService, err := services.NewGraphqlService(blogService, config.Graphql.MaxComplexity)
+	if err != nil {
+		utils.Fatal("error when building the graphql schema", "error", err)
+	}
+	graphqlController := controllers.NewGraphqlController(graphqlService)
+	routes.GraphqlRoute(e, graphqlController, middlewares.BodyLimit(config.Graphql.BodyLimit()))
+
+	openApiController := controllers.NewOpenApiController(openApiService)
+	routes.OpenApiRoute(e, openApiController)
+
+	metricsController := controllers.NewMetricsController(metricsUtil)
+	routes.MetricsRoute(e, metricsController)
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middlewares.GrpcUnaryContext(universalTranslator), middlewares.GrpcUnaryAccessLog()),
+		grpc.ChainStreamInterceptor(middlewares.GrpcStreamContext(universalTranslator), middlewares.GrpcStreamAccessLog()),
+	)
+	blogGrpcController := controllers.NewBlogGrpcController(blogService)
+	routes.BlogGrpcRoute(grpcServer, blogGrpcController)
+	if config.Grpc.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	// components are stopped in the reverse order they are added, readiness fails first and the server keeps serving for the drain delay
+	// so the load balancer stops routing here before connections are refused, then open grpc calls and requests finish before spans are flushed and the database is closed
+	lifecycleUtil := utils.NewLifecycleUtil(utils.NewOsSignalSource(), config.Server.ShutdownTimeout)
+	lifecycleUtil.Add("database", nil, func(ctx context.Context) error {
+		postgresUtil.Close()
+		return nil
+	})
+	lifecycleUtil.Add("tracing", nil, shutdownTracing)
+	lifecycleUtil.Add("http server", func() error {
+		slog.Info("server: listening", "address", config.Server.Host)
+		if err := e.Start(config.Server.Host); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}, e.Shutdown)
+	// the event streams end before the http server shuts down, a shutdown would otherwise wait for them until the timeout
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	lifecycleUtil.Add("post events", func() error {
+		return postEventService.Run(eventsCtx)
+	}, func(ctx context.Context) error {
+		stopEvents()
+		return nil
+	})
+	lifecycleUtil.Add("grpc server", func() error {
+		listener, err := net.Listen("tcp", config.Grpc.Host)
+		if err != nil {
+			return err
+		}
+		slog.Info("grpc server: listening", "address", config.Grpc.Host)
+		if err := grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			return err
+		}
+		return nil
+	}, func(ctx context.Context) error {
+		// GracefulStop waits for open streams, Stop cuts them when the shutdown timeout is reached
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+	lifecycleUtil.Add("readiness", nil, func(ctx context.Context) error {
+		if e.ListenerAddr() == nil {
+			return nil
+		}
+		healthService.Drain()
+		slog.Info("server: draining", "delay", config.Server.ShutdownDrainDelay.String())
+		return utils.Sleep(ctx, config.Server.ShutdownDrainDelay)
+	})
+	os.Exit(lifecycleUtil.Run(context.Background()))
 }
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccessLog writes one log line per request after the response was sent, server errors are logged at error level,
+// it runs inside Tracing so the line carries the trace id of the request
+func AccessLog() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			request, response := c.Request(), c.Response()
+			level := slog.LevelInfo
+			if response.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			slog.Log(request.Context(), level, "request",
+				"method", request.Method,
+				"route", c.Path(),
+				"path", request.URL.Path,
+				"status", response.Status,
+				"bytes", response.Size,
+				"latency_ms", float64(time.Since(start).Microseconds())/1000,
+				"remote_ip", c.RealIP(),
+				"user_agent", request.UserAgent(),
+			)
+			return nil
+		}
+	}
+}
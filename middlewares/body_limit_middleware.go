@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"blogging-platform-api/exceptions"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BodyLimit answers 413 when the request body is larger than limit bytes, a declared Content-Length is rejected before anything is read
+// and a chunked body fails with http.MaxBytesError wherever it is read, HTTPErrorHandler turns that error into a 413 too
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			if request.ContentLength > limit {
+				return exceptions.NewTooLargeError("request body is larger than "+strconv.FormatInt(limit, 10)+" bytes", nil)
+			}
+			request.Body = http.MaxBytesReader(c.Response(), request.Body, limit)
+			return next(c)
+		}
+	}
+}
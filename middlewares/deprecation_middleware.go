@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Deprecation marks the responses of a deprecated route with the Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+// and links the same path under successorPrefix as successor version, the headers are set before the handler runs so error responses carry them too
+func Deprecation(deprecation time.Time, sunset time.Time, successorPrefix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+			header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			header.Add("Link", "<"+successorPrefix+c.Request().URL.Path+`>; rel="successor-version"`)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"log/slog"
+	"time"
+
+	ut "github.com/go-playground/universal-translator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcContext puts the request id and the translator into the context of a grpc call like RequestId and Locale do for http requests,
+// the request id is read from and sent back in the x-request-id metadata, the locale is read from the accept-language metadata
+func grpcContext(ctx context.Context, universalTranslator *ut.UniversalTranslator) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestId := firstMetadata(md, "x-request-id")
+	if !isValidRequestId(requestId) {
+		requestId = newRequestId()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestId))
+	ctx = utils.ContextWithRequestId(ctx, requestId)
+	translator := utils.FindTranslator(universalTranslator, firstMetadata(md, "accept-language"))
+	return utils.ContextWithTranslator(ctx, translator)
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// GrpcUnaryContext is the grpc counterpart of RequestId and Locale for unary calls
+func GrpcUnaryContext(universalTranslator *ut.UniversalTranslator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(grpcContext(ctx, universalTranslator), request)
+	}
+}
+
+// GrpcStreamContext is the grpc counterpart of RequestId and Locale for streaming calls
+func GrpcStreamContext(universalTranslator *ut.UniversalTranslator) grpc.StreamServerInterceptor {
+	return func(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(server, &contextServerStream{ServerStream: stream, ctx: grpcContext(stream.Context(), universalTranslator)})
+	}
+}
+
+// contextServerStream replaces the context of a stream, grpc has no other way to pass values to a stream handler
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *contextServerStream) Context() context.Context {
+	return stream.ctx
+}
+
+// GrpcUnaryAccessLog writes one log line per unary call like AccessLog, it runs inside GrpcUnaryContext so the line carries the request id
+func GrpcUnaryAccessLog() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		response, err := handler(ctx, request)
+		logGrpcCall(ctx, info.FullMethod, err, start)
+		return response, err
+	}
+}
+
+// GrpcStreamAccessLog writes one log line per streaming call after the stream ended
+func GrpcStreamAccessLog() grpc.StreamServerInterceptor {
+	return func(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(server, stream)
+		logGrpcCall(stream.Context(), info.FullMethod, err, start)
+		return err
+	}
+}
+
+// logGrpcCall logs codes that mean the server failed at error level, the codes a client causes at info level
+func logGrpcCall(ctx context.Context, method string, err error, start time.Time) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented, codes.DeadlineExceeded:
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "grpc call",
+		"method", method,
+		"code", code.String(),
+		"latency_ms", float64(time.Since(start).Microseconds())/1000,
+	)
+}
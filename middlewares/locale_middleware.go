@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"blogging-platform-api/utils"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/labstack/echo/v4"
+)
+
+// Locale stores the translator matching the Accept-Language header in the request context so services can translate validation messages
+func Locale(universalTranslator *ut.UniversalTranslator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			translator := utils.FindTranslator(universalTranslator, c.Request().Header.Get("Accept-Language"))
+			request := c.Request()
+			c.SetRequest(request.WithContext(utils.ContextWithTranslator(request.Context(), translator)))
+			c.Response().Header().Set("Content-Language", translator.Locale())
+			c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"blogging-platform-api/utils"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics observes the duration of every request by route template so /posts/1 and /posts/2 share one series,
+// errors are handed to the error handler here so the observed status is the one the client gets
+func Metrics(metricsUtil utils.MetricsUtil) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			metricsUtil.ObserveHttpRequest(c.Request().Method, route, c.Response().Status, time.Since(start))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package middlewares
+
+import (
+	"blogging-platform-api/exceptions"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/services"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OpenApiValidation rejects requests whose path parameters, query parameters or json body do not match the openapi operation of the route
+// before they reach the controller, routes without an operation pass through, a body larger than maxBodySize bytes answers 413 before it is buffered,
+// with validateResponses every response is buffered and checked against the documented status codes and schemas and replaced by an internal error
+// when it does not match, it is meant for tests and staging
+func OpenApiValidation(openApiService services.OpenApiService, validateResponses bool, maxBodySize int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			operation, ok := openApiService.Operation(c.Request().Method, c.Path())
+			if !ok {
+				return next(c)
+			}
+			err := validateRequest(c, openApiService, operation, maxBodySize)
+			if err != nil {
+				return err
+			}
+			if !validateResponses {
+				return next(c)
+			}
+
+			// the error is handled here so the problem response is checked too
+			writer := c.Response().Writer
+			buffer := &responseBuffer{writer: writer}
+			c.Response().Writer = buffer
+			err = next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			c.Response().Writer = writer
+			if !c.Response().Committed {
+				return nil
+			}
+			problems := validateResponse(openApiService, operation, c.Response().Status, writer.Header().Get(echo.HeaderContentType), buffer.body.Bytes())
+			if len(problems) > 0 {
+				c.Response().Committed = false
+				c.Response().Size = 0
+				c.Error(exceptions.NewInternalError(errors.New("response does not match the openapi schema: " + strings.Join(problems, ", "))))
+				return nil
+			}
+			writer.WriteHeader(buffer.status)
+			_, err = writer.Write(buffer.body.Bytes())
+			return err
+		}
+	}
+}
+
+func validateRequest(c echo.Context, openApiService services.OpenApiService, operation modelresponses.OpenApiOperationResponse, maxBodySize int64) error {
+	ctx := c.Request().Context()
+	var fields []exceptions.FieldError
+	for _, parameter := range operation.Parameters {
+		switch parameter.In {
+		case "path":
+			// a path parameter of the wrong type keeps the invalid_{name} code the controllers return
+			value := c.Param(parameter.Name)
+			if len(openApiService.Validate(ctx, parameter.Schema, parameterValue(parameter.Schema, value), parameter.Name)) > 0 {
+				return exceptions.NewBadRequestError("invalid_"+parameter.Name, parameter.Name+" must be "+typeDescription(parameter.Schema), nil)
+			}
+		case "query":
+			values, ok := c.QueryParams()[parameter.Name]
+			if !ok {
+				if parameter.Required {
+					fields = append(fields, openApiService.Validate(ctx, &modelresponses.OpenApiSchema{Type: "object", Required: []string{parameter.Name}}, map[string]any{}, "")...)
+				}
+				continue
+			}
+			fields = append(fields, openApiService.Validate(ctx, parameter.Schema, parameterValue(parameter.Schema, values[0]), parameter.Name)...)
+		}
+	}
+
+	if operation.RequestBody != nil {
+		request := c.Request()
+		if request.ContentLength > maxBodySize {
+			return exceptions.NewTooLargeError("request body is larger than "+strconv.FormatInt(maxBodySize, 10)+" bytes", nil)
+		}
+		// HTTPErrorHandler answers 413 for the http.MaxBytesError of a chunked body
+		body, err := io.ReadAll(http.MaxBytesReader(c.Response(), request.Body, maxBodySize))
+		if err != nil {
+			return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", err)
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		// an empty body is checked as an empty object so every required field is reported like the service does,
+		// another content type keeps the 415 that Bind answers
+		if len(bytes.TrimSpace(body)) == 0 {
+			body = []byte("{}")
+		} else if mediaType, _, _ := mime.ParseMediaType(request.Header.Get(echo.HeaderContentType)); mediaType != echo.MIMEApplicationJSON {
+			return echo.ErrUnsupportedMediaType
+		}
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		err = decoder.Decode(&value)
+		if err != nil {
+			return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", err)
+		}
+		for _, field := range openApiService.Validate(ctx, operation.RequestBody.Content[echo.MIMEApplicationJSON].Schema, withoutNulls(value), "") {
+			if field.Field == "" {
+				return exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "invalid request body", errors.New(field.Message))
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fields) > 0 {
+		return exceptions.NewFieldsValidationError(fields)
+	}
+	return nil
+}
+
+// withoutNulls drops the null members of the objects in value, Bind leaves a null field at its zero value so a required null field is reported as missing
+func withoutNulls(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, member := range value {
+			if member == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = withoutNulls(member)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = withoutNulls(item)
+		}
+	}
+	return value
+}
+
+// parameterValue converts a path or query parameter to the json value the schema expects, a value that does not convert is kept as string so it fails the type rule
+func parameterValue(schema *modelresponses.OpenApiSchema, value string) any {
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+func typeDescription(schema *modelresponses.OpenApiSchema) string {
+	switch schema.Type {
+	case "integer":
+		if schema.Format == "int32" && schema.Minimum != nil && *schema.Minimum >= 1 {
+			return "a positive 32 bit integer"
+		}
+		return "an integer"
+	case "boolean":
+		return "true or false"
+	}
+	return "a " + schema.Type
+}
+
+func validateResponse(openApiService services.OpenApiService, operation modelresponses.OpenApiOperationResponse, status int, contentType string, body []byte) (problems []string) {
+	result, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []string{"status " + strconv.Itoa(status) + " is not documented"}
+	}
+	if len(result.Content) == 0 {
+		if len(body) > 0 {
+			problems = append(problems, "status "+strconv.Itoa(status)+" must not have a body")
+		}
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := result.Content[mediaType]
+	if !ok {
+		return []string{"content type " + strconv.Quote(mediaType) + " is not documented for status " + strconv.Itoa(status)}
+	}
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"body is not json: " + err.Error()}
+	}
+	for _, field := range openApiService.Validate(context.Background(), media.Schema, value, "") {
+		problems = append(problems, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return
+}
+
+// responseBuffer holds the response back until it has been validated, the headers are written to the real writer
+type responseBuffer struct {
+	writer http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (buffer *responseBuffer) Header() http.Header {
+	return buffer.writer.Header()
+}
+
+func (buffer *responseBuffer) WriteHeader(status int) {
+	buffer.status = status
+}
+
+func (buffer *responseBuffer) Write(body []byte) (int, error) {
+	return buffer.body.Write(body)
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"blogging-platform-api/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const LastWriteCookie = "last_write"
+
+const writeKey = "read_your_writes_write"
+
+// ReadYourWrites sends the reads of a client to the primary for window after the client wrote successfully so replica lag never hides its own writes,
+// the time of the last write is kept in a cookie that expires after window
+func ReadYourWrites(window time.Duration, secureCookie bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(LastWriteCookie)
+			if err == nil {
+				lastWrite, err := strconv.ParseInt(cookie.Value, 10, 64)
+				if err == nil && time.Since(time.UnixMilli(lastWrite)) < window {
+					request := c.Request()
+					c.SetRequest(request.WithContext(utils.ContextWithPrimaryReads(request.Context())))
+				}
+			}
+
+			c.Response().Before(func() {
+				if !isWrite(c) || c.Response().Status >= http.StatusBadRequest {
+					return
+				}
+				c.SetCookie(&http.Cookie{
+					Name:     LastWriteCookie,
+					Value:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+					Path:     "/",
+					MaxAge:   int(math.Ceil(window.Seconds())),
+					HttpOnly: true,
+					Secure:   secureCookie,
+					SameSite: http.SameSiteLaxMode,
+				})
+			})
+			return next(c)
+		}
+	}
+}
+
+// MarkWrite tells ReadYourWrites whether the request wrote, for routes like POST /graphql whose method does not say it,
+// it must be called before the response is written
+func MarkWrite(c echo.Context, write bool) {
+	c.Set(writeKey, write)
+}
+
+// isWrite is what the handler marked with MarkWrite, otherwise every POST, PUT, PATCH and DELETE is a write
+func isWrite(c echo.Context) bool {
+	if write, ok := c.Get(writeKey).(bool); ok {
+		return write
+	}
+	method := c.Request().Method
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
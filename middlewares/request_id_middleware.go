@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"blogging-platform-api/utils"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxRequestIdLength = 128
+
+// RequestId keeps the X-Request-ID of the client or generates one, the id is sent back in the response and put into the request context
+// so every log line of the request carries it, ids that are too long or contain anything but letters, digits, dashes, dots and underscores are replaced
+func RequestId() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestId := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !isValidRequestId(requestId) {
+				requestId = newRequestId()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+			request := c.Request()
+			c.SetRequest(request.WithContext(utils.ContextWithRequestId(request.Context(), requestId)))
+			return next(c)
+		}
+	}
+}
+
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for _, character := range requestId {
+		isLetter := (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+		isDigit := character >= '0' && character <= '9'
+		if !isLetter && !isDigit && character != '-' && character != '.' && character != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
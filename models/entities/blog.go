@@ -12,4 +12,6 @@ type Blog struct {
 	Tags      pgtype.Text
 	CreatedAt pgtype.Int8
 	UpdatedAt pgtype.Int8
+	Slug      pgtype.Text
+	Author    pgtype.Text
 }
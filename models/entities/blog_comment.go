@@ -0,0 +1,15 @@
+package modelentities
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BlogComment is a comment of a post, SourceId is the id the comment had where it was imported from and is unique per post
+type BlogComment struct {
+	Id        pgtype.Int4
+	PostId    pgtype.Int4
+	SourceId  pgtype.Text
+	Author    pgtype.Text
+	Content   pgtype.Text
+	CreatedAt pgtype.Int8
+}
@@ -0,0 +1,14 @@
+package modelentities
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BlogEvent is one entry of the bounded log of post changes, Payload is the json of the post as it was after the change
+type BlogEvent struct {
+	Id        pgtype.Int8
+	Type      pgtype.Text
+	PostId    pgtype.Int4
+	Payload   pgtype.Text
+	CreatedAt pgtype.Int8
+}
@@ -0,0 +1,11 @@
+package modelentities
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Taxonomy struct {
+	Name      pgtype.Text
+	UpdatedAt pgtype.Int8
+	Count     pgtype.Int8
+}
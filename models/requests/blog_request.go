@@ -1,15 +1,23 @@
 package modelrequests
 
 type CreateRequest struct {
-	Title    string   `json:"title" validate:"required"`
+	Title    string   `json:"title" validate:"required,max=50"`
 	Content  string   `json:"content" validate:"required"`
-	Category string   `json:"category" validate:"required"`
-	Tags     []string `json:"tags" validate:"required"`
+	Category string   `json:"category" validate:"required,max=50"`
+	Tags     []string `json:"tags" validate:"required,max=10,dive,required,max=30"`
 }
 
 type UpdateRequest struct {
-	Title    string   `json:"title" validate:"required"`
+	Title    string   `json:"title" validate:"required,max=50"`
 	Content  string   `json:"content" validate:"required"`
-	Category string   `json:"category" validate:"required"`
-	Tags     []string `json:"tags" validate:"required"`
+	Category string   `json:"category" validate:"required,max=50"`
+	Tags     []string `json:"tags" validate:"required,max=10,dive,required,max=30"`
+}
+
+// FindPageRequest selects First posts after the post of the opaque cursor After, Term matches tags like the term of FindAllPosts and Category matches exactly
+type FindPageRequest struct {
+	Term     string `json:"term"`
+	Category string `json:"category"`
+	First    int    `json:"first" validate:"min=1,max=100"`
+	After    string `json:"after"`
 }
@@ -0,0 +1,8 @@
+package modelrequests
+
+// GraphqlRequest is the body of a graphql over http post request
+type GraphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
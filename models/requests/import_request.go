@@ -0,0 +1,10 @@
+package modelrequests
+
+type ImportRequest struct {
+	Title     string   `json:"title" yaml:"title" validate:"required,max=50"`
+	Content   string   `json:"content" yaml:"content" validate:"required"`
+	Category  string   `json:"category" yaml:"category" validate:"required,max=50"`
+	Tags      []string `json:"tags" yaml:"tags" validate:"required,max=10,dive,required,max=30"`
+	CreatedAt string   `json:"createdAt" yaml:"createdAt" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedAt string   `json:"updatedAt" yaml:"updatedAt" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
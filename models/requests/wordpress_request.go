@@ -0,0 +1,49 @@
+package modelrequests
+
+// WordpressExport is the subset of a WordPress eXtended RSS (WXR) export used by the importer, elements without a namespace in the tag match every WXR version
+type WordpressExport struct {
+	Channel WordpressChannel `xml:"channel"`
+}
+
+type WordpressChannel struct {
+	Authors []WordpressAuthor `xml:"author"`
+	Items   []WordpressItem   `xml:"item"`
+}
+
+type WordpressAuthor struct {
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type WordpressItem struct {
+	Title           string              `xml:"title"`
+	PubDate         string              `xml:"pubDate"`
+	Creator         string              `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content         string              `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostId          string              `xml:"post_id"`
+	PostDate        string              `xml:"post_date"`
+	PostDateGmt     string              `xml:"post_date_gmt"`
+	PostModified    string              `xml:"post_modified"`
+	PostModifiedGmt string              `xml:"post_modified_gmt"`
+	PostName        string              `xml:"post_name"`
+	Status          string              `xml:"status"`
+	PostType        string              `xml:"post_type"`
+	Categories      []WordpressCategory `xml:"category"`
+	Comments        []WordpressComment  `xml:"comment"`
+}
+
+type WordpressCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type WordpressComment struct {
+	Id       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Date     string `xml:"comment_date"`
+	DateGmt  string `xml:"comment_date_gmt"`
+}
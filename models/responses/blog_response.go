@@ -39,3 +39,40 @@ type FindResponse struct {
 	CreatedAt string   `json:"createdAt"`
 	UpdatedAt string   `json:"updatedAt"`
 }
+
+// PostResponse is a blog with its slug and trimmed tags, it is the post of the graphql api
+type PostResponse struct {
+	Id        int      `json:"id"`
+	Slug      string   `json:"slug"`
+	Author    string   `json:"author,omitempty"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+type PostEdgeResponse struct {
+	Cursor string       `json:"cursor"`
+	Post   PostResponse `json:"post"`
+}
+
+type PostPageResponse struct {
+	Edges       []PostEdgeResponse `json:"edges"`
+	HasNextPage bool               `json:"hasNextPage"`
+	EndCursor   string             `json:"endCursor"`
+}
+
+type TaxonomyResponse struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"postCount"`
+}
+
+// PostEventResponse is one event of GET /posts/events, it is the data of the server sent event whose id and event fields are Id and Type,
+// Post is the post after the change and missing from reset events
+type PostEventResponse struct {
+	Id   int64         `json:"id"`
+	Type string        `json:"type"`
+	Post *PostResponse `json:"post,omitempty"`
+}
@@ -0,0 +1,9 @@
+package modelresponses
+
+type ExportFrontMatter struct {
+	Title     string   `yaml:"title"`
+	Category  string   `yaml:"category"`
+	Tags      []string `yaml:"tags"`
+	CreatedAt string   `yaml:"createdAt"`
+	UpdatedAt string   `yaml:"updatedAt,omitempty"`
+}
@@ -0,0 +1,27 @@
+package modelresponses
+
+// GraphqlResponse is the result of a graphql operation, Data is null when the operation did not run
+type GraphqlResponse struct {
+	Data   any                    `json:"data"`
+	Errors []GraphqlErrorResponse `json:"errors,omitempty"`
+	// Mutation is true when a mutation ran, it tells the caller the operation may have written and is not sent to the client
+	Mutation bool `json:"-"`
+}
+
+type GraphqlErrorResponse struct {
+	Message    string                          `json:"message"`
+	Locations  []GraphqlLocationResponse       `json:"locations,omitempty"`
+	Path       []any                           `json:"path,omitempty"`
+	Extensions *GraphqlErrorExtensionsResponse `json:"extensions,omitempty"`
+}
+
+type GraphqlLocationResponse struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphqlErrorExtensionsResponse carries the same stable code and field errors as a problem response
+type GraphqlErrorExtensionsResponse struct {
+	Code   string               `json:"code"`
+	Errors []FieldErrorResponse `json:"errors,omitempty"`
+}
@@ -0,0 +1,13 @@
+package modelresponses
+
+// HealthResponse is the body of the health endpoints, Checks is keyed by dependency name and left out by the liveness probe
+type HealthResponse struct {
+	Status string                         `json:"status"`
+	Checks map[string]HealthCheckResponse `json:"checks,omitempty"`
+}
+
+type HealthCheckResponse struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+}
@@ -0,0 +1,16 @@
+package modelresponses
+
+type ImportResponse struct {
+	Total    int                    `json:"total"`
+	Imported int                    `json:"imported"`
+	Failed   int                    `json:"failed"`
+	Records  []ImportRecordResponse `json:"records"`
+}
+
+type ImportRecordResponse struct {
+	Index   int                  `json:"index"`
+	Source  string               `json:"source"`
+	Success bool                 `json:"success"`
+	Message string               `json:"message,omitempty"`
+	Errors  []FieldErrorResponse `json:"errors,omitempty"`
+}
@@ -0,0 +1,78 @@
+package modelresponses
+
+// OpenApiResponse is an OpenAPI 3.1 document, only the parts the api describes are modelled
+type OpenApiResponse struct {
+	Openapi    string                     `json:"openapi"`
+	Info       OpenApiInfoResponse        `json:"info"`
+	Servers    []OpenApiServerResponse    `json:"servers,omitempty"`
+	Paths      map[string]OpenApiPathItem `json:"paths"`
+	Components OpenApiComponentsResponse  `json:"components"`
+}
+
+type OpenApiInfoResponse struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenApiServerResponse struct {
+	Url string `json:"url"`
+}
+
+// OpenApiPathItem is keyed by the lower case http method
+type OpenApiPathItem map[string]OpenApiOperationResponse
+
+type OpenApiOperationResponse struct {
+	OperationId string                                    `json:"operationId"`
+	Summary     string                                    `json:"summary"`
+	Tags        []string                                  `json:"tags,omitempty"`
+	Parameters  []OpenApiParameterResponse                `json:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBodyResponse               `json:"requestBody,omitempty"`
+	Responses   map[string]OpenApiOperationResultResponse `json:"responses"`
+	Deprecated  bool                                      `json:"deprecated,omitempty"`
+}
+
+type OpenApiParameterResponse struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Required    bool           `json:"required"`
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenApiSchema `json:"schema"`
+}
+
+type OpenApiRequestBodyResponse struct {
+	Required bool                                `json:"required"`
+	Content  map[string]OpenApiMediaTypeResponse `json:"content"`
+}
+
+type OpenApiOperationResultResponse struct {
+	Description string                              `json:"description"`
+	Content     map[string]OpenApiMediaTypeResponse `json:"content,omitempty"`
+}
+
+type OpenApiMediaTypeResponse struct {
+	Schema *OpenApiSchema `json:"schema"`
+}
+
+type OpenApiComponentsResponse struct {
+	Schemas map[string]*OpenApiSchema `json:"schemas"`
+}
+
+// OpenApiSchema is a json schema as used by OpenAPI 3.1, Ref points to a schema of the components
+type OpenApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*OpenApiSchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *OpenApiSchema            `json:"additionalProperties,omitempty"`
+	Items                *OpenApiSchema            `json:"items,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+}
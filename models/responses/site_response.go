@@ -0,0 +1,66 @@
+package modelresponses
+
+import (
+	"encoding/xml"
+	"html/template"
+)
+
+type SitePage struct {
+	SiteTitle  string
+	BaseUrl    string
+	Title      string
+	Post       SitePost
+	Posts      []SitePost
+	Taxonomy   SiteLink
+	Categories []SiteLink
+	Tags       []SiteLink
+	Pagination SitePagination
+	FeedUrl    string
+}
+
+type SitePost struct {
+	Id        int
+	Url       string
+	Title     string
+	Summary   string
+	Content   template.HTML
+	Category  SiteLink
+	Tags      []SiteLink
+	CreatedAt string
+	UpdatedAt string
+}
+
+type SiteLink struct {
+	Name  string
+	Url   string
+	Count int
+}
+
+type SitePagination struct {
+	Page        int
+	TotalPages  int
+	PreviousUrl string
+	NextUrl     string
+}
+
+type SiteFeed struct {
+	XMLName xml.Name        `xml:"rss"`
+	Version string          `xml:"version,attr"`
+	Channel SiteFeedChannel `xml:"channel"`
+}
+
+type SiteFeedChannel struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Items       []SiteFeedItem `xml:"item"`
+}
+
+type SiteFeedItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Guid        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Category    string `xml:"category"`
+	Description string `xml:"description"`
+}
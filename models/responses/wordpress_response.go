@@ -0,0 +1,22 @@
+package modelresponses
+
+type WordpressImportResponse struct {
+	Total           int                             `json:"total"`
+	Created         int                             `json:"created"`
+	Updated         int                             `json:"updated"`
+	Skipped         int                             `json:"skipped"`
+	Failed          int                             `json:"failed"`
+	Comments        int                             `json:"comments"`
+	SkippedComments int                             `json:"skippedComments"`
+	Records         []WordpressImportRecordResponse `json:"records"`
+}
+
+type WordpressImportRecordResponse struct {
+	Index   int                  `json:"index"`
+	PostId  string               `json:"postId"`
+	Slug    string               `json:"slug"`
+	Id      int                  `json:"id,omitempty"`
+	Status  string               `json:"status"`
+	Message string               `json:"message,omitempty"`
+	Errors  []FieldErrorResponse `json:"errors,omitempty"`
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BlogInstrumentedRepositoryImplementation observes the duration of every call of another BlogRepository by method and logs failed calls,
+// it wraps the storage repository directly so retries are observed once per attempt
+type BlogInstrumentedRepositoryImplementation struct {
+	BlogRepository BlogRepository
+	MetricsUtil    utils.MetricsUtil
+}
+
+func NewInstrumentedBlogRepository(blogRepository BlogRepository, metricsUtil utils.MetricsUtil) BlogRepository {
+	return &BlogInstrumentedRepositoryImplementation{
+		BlogRepository: blogRepository,
+		MetricsUtil:    metricsUtil,
+	}
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
+	defer repository.observe(ctx, "Create", time.Now(), &err)
+	return repository.BlogRepository.Create(querier, ctx, blog)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error) {
+	defer repository.observe(ctx, "CreateBatch", time.Now(), &err)
+	return repository.BlogRepository.CreateBatch(querier, ctx, blogs)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error) {
+	defer repository.observe(ctx, "UpsertBySlug", time.Now(), &err)
+	return repository.BlogRepository.UpsertBySlug(querier, ctx, blog)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
+	defer repository.observe(ctx, "Update", time.Now(), &err)
+	return repository.BlogRepository.Update(querier, ctx, blog)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error) {
+	defer repository.observe(ctx, "Delete", time.Now(), &err)
+	return repository.BlogRepository.Delete(querier, ctx, id)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error) {
+	defer repository.observe(ctx, "FindById", time.Now(), &err)
+	return repository.BlogRepository.FindById(querier, ctx, id)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error) {
+	defer repository.observe(ctx, "FindBySlug", time.Now(), &err)
+	return repository.BlogRepository.FindBySlug(querier, ctx, slug)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error) {
+	defer repository.observe(ctx, "FindByIds", time.Now(), &err)
+	return repository.BlogRepository.FindByIds(querier, ctx, ids)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
+	defer repository.observe(ctx, "FindAll", time.Now(), &err)
+	return repository.BlogRepository.FindAll(querier, ctx, term)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) CountAll(querier Querier, ctx context.Context) (count int, err error) {
+	defer repository.observe(ctx, "CountAll", time.Now(), &err)
+	return repository.BlogRepository.CountAll(querier, ctx)
+}
+
+// StreamAll includes the time spent in callback, a slow client makes the stream look slow
+func (repository *BlogInstrumentedRepositoryImplementation) StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error) {
+	defer repository.observe(ctx, "StreamAll", time.Now(), &err)
+	return repository.BlogRepository.StreamAll(querier, ctx, term, limit, offset, callback)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error) {
+	defer repository.observe(ctx, "FindAfter", time.Now(), &err)
+	return repository.BlogRepository.FindAfter(querier, ctx, term, category, afterId, limit)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error) {
+	defer repository.observe(ctx, "FindCategories", time.Now(), &err)
+	return repository.BlogRepository.FindCategories(querier, ctx)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error) {
+	defer repository.observe(ctx, "FindTags", time.Now(), &err)
+	return repository.BlogRepository.FindTags(querier, ctx)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error) {
+	defer repository.observe(ctx, "FindCategoriesByNames", time.Now(), &err)
+	return repository.BlogRepository.FindCategoriesByNames(querier, ctx, names)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error) {
+	defer repository.observe(ctx, "FindTagsByNames", time.Now(), &err)
+	return repository.BlogRepository.FindTagsByNames(querier, ctx, names)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error) {
+	defer repository.observe(ctx, "CreateEvent", time.Now(), &err)
+	return repository.BlogRepository.CreateEvent(querier, ctx, event)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error) {
+	defer repository.observe(ctx, "FindEventsAfter", time.Now(), &err)
+	return repository.BlogRepository.FindEventsAfter(querier, ctx, afterId, limit)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error) {
+	defer repository.observe(ctx, "DeleteEventsBefore", time.Now(), &err)
+	return repository.BlogRepository.DeleteEventsBefore(querier, ctx, beforeId)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error) {
+	defer repository.observe(ctx, "UpsertComments", time.Now(), &err)
+	return repository.BlogRepository.UpsertComments(querier, ctx, comments)
+}
+
+func (repository *BlogInstrumentedRepositoryImplementation) CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error) {
+	defer repository.observe(ctx, "CountComments", time.Now(), &err)
+	return repository.BlogRepository.CountComments(querier, ctx, postIds)
+}
+
+// observe is deferred with a pointer to the named error so it sees the error that is returned, no rows is not counted as an error
+// and failures are only warnings because a retry may still succeed
+func (repository *BlogInstrumentedRepositoryImplementation) observe(ctx context.Context, method string, start time.Time, err *error) {
+	duration := time.Since(start)
+	result := "ok"
+	if errors.Is(*err, pgx.ErrNoRows) {
+		result = "no_rows"
+	} else if *err != nil {
+		result = "error"
+		slog.WarnContext(ctx, "blog repository: call failed", "method", method, "latency_ms", float64(duration.Microseconds())/1000, "error", *err)
+	}
+	repository.MetricsUtil.ObserveQuery(method, result, duration)
+}
@@ -0,0 +1,436 @@
+package repositories
+
+import (
+	modelentities "blogging-platform-api/models/entities"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BlogMemoryRepositoryImplementation keeps blogs in memory for tests and local runs without postgres, the querier is ignored so a rolled back transaction does not undo its writes
+type BlogMemoryRepositoryImplementation struct {
+	mutex       sync.RWMutex
+	blogs       map[int32]modelentities.Blog
+	lastId      int32
+	events      []modelentities.BlogEvent
+	lastEventId int64
+	comments    map[int32][]modelentities.BlogComment
+	lastComment int32
+}
+
+func NewBlogMemoryRepository(blogs ...modelentities.Blog) BlogRepository {
+	repository := &BlogMemoryRepositoryImplementation{
+		blogs:    map[int32]modelentities.Blog{},
+		comments: map[int32][]modelentities.BlogComment{},
+	}
+	for _, blog := range blogs {
+		repository.insert(blog)
+	}
+	return repository
+}
+
+func (repository *BlogMemoryRepositoryImplementation) Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	if err = repository.checkSlug(blog); err != nil {
+		return
+	}
+	insertedId = int(repository.insert(blog))
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	for _, blog := range blogs {
+		if err = repository.checkSlug(blog); err != nil {
+			return
+		}
+	}
+	for _, blog := range blogs {
+		repository.insert(blog)
+		rowsAffected++
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	for _, existing := range repository.blogs {
+		if existing.Slug.Valid && blog.Slug.Valid && existing.Slug.String == blog.Slug.String {
+			blog.Id = existing.Id
+			repository.blogs[existing.Id.Int32] = blog
+			id = int(existing.Id.Int32)
+			return
+		}
+	}
+	id = int(repository.insert(blog))
+	inserted = true
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	existing, ok := repository.blogs[blog.Id.Int32]
+	if !ok {
+		return
+	}
+	existing.Title = blog.Title
+	existing.Content = blog.Content
+	existing.Category = blog.Category
+	existing.Tags = blog.Tags
+	existing.UpdatedAt = blog.UpdatedAt
+	repository.blogs[blog.Id.Int32] = existing
+	rowsAffected = 1
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	blog, ok := repository.blogs[int32(id)]
+	if !ok {
+		err = pgx.ErrNoRows
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	for _, existing := range repository.blogs {
+		if existing.Slug.Valid && existing.Slug.String == slug {
+			return existing, nil
+		}
+	}
+	err = pgx.ErrNoRows
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	for _, blog := range repository.matching("") {
+		if slices.Contains(ids, int(blog.Id.Int32)) {
+			blogs = append(blogs, blog)
+		}
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	if _, ok := repository.blogs[int32(id)]; ok {
+		delete(repository.blogs, int32(id))
+		delete(repository.comments, int32(id))
+		rowsAffected = 1
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	blogs = repository.matching(term)
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) CountAll(querier Querier, ctx context.Context) (count int, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	count = len(repository.blogs)
+	return
+}
+
+// StreamAll calls callback on a copy of the matching rows so callback may call the repository again
+func (repository *BlogMemoryRepositoryImplementation) StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	blogs := repository.matching(term)
+	repository.mutex.RUnlock()
+
+	if offset >= len(blogs) {
+		return
+	}
+	blogs = blogs[offset:]
+	if limit > 0 && limit < len(blogs) {
+		blogs = blogs[:limit]
+	}
+	for _, blog := range blogs {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		err = callback(blog)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	for _, blog := range repository.matching(term) {
+		if len(blogs) == limit {
+			break
+		}
+		if int(blog.Id.Int32) > afterId && (category == "" || blog.Category.String == category) {
+			blogs = append(blogs, blog)
+		}
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	return repository.taxonomies(func(blog modelentities.Blog) []string {
+		return []string{blog.Category.String}
+	}), nil
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	return repository.taxonomies(func(blog modelentities.Blog) []string {
+		names := []string{}
+		for _, tag := range strings.Split(blog.Tags.String, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				names = append(names, tag)
+			}
+		}
+		return names
+	}), nil
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error) {
+	categories, err = repository.FindCategories(querier, ctx)
+	return named(categories, names), err
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error) {
+	tags, err = repository.FindTags(querier, ctx)
+	return named(tags, names), err
+}
+
+func (repository *BlogMemoryRepositoryImplementation) CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.lastEventId++
+	event.Id = pgtype.Int8{Valid: true, Int64: repository.lastEventId}
+	repository.events = append(repository.events, event)
+	return repository.lastEventId, nil
+}
+
+func (repository *BlogMemoryRepositoryImplementation) FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	for _, event := range repository.events {
+		if len(events) == limit {
+			break
+		}
+		if event.Id.Int64 > afterId {
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	kept := repository.events[:0]
+	for _, event := range repository.events {
+		if event.Id.Int64 < beforeId {
+			rowsAffected++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	repository.events = kept
+	return
+}
+
+// UpsertComments returns the foreign key violation postgres returns for a comment of a missing post
+func (repository *BlogMemoryRepositoryImplementation) UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	for _, comment := range comments {
+		if _, ok := repository.blogs[comment.PostId.Int32]; !ok {
+			err = &pgconn.PgError{Code: "23503", Message: "insert or update on table \"blog_comments\" violates foreign key constraint \"blog_comments_post_id_fkey\""}
+			return
+		}
+		postComments := repository.comments[comment.PostId.Int32]
+		index := slices.IndexFunc(postComments, func(existing modelentities.BlogComment) bool {
+			return existing.SourceId.String == comment.SourceId.String
+		})
+		if index >= 0 {
+			comment.Id = postComments[index].Id
+			postComments[index] = comment
+		} else {
+			repository.lastComment++
+			comment.Id = pgtype.Int4{Valid: true, Int32: repository.lastComment}
+			postComments = append(postComments, comment)
+		}
+		repository.comments[comment.PostId.Int32] = postComments
+		rowsAffected++
+	}
+	return
+}
+
+func (repository *BlogMemoryRepositoryImplementation) CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+	counts = map[int]int{}
+	for _, id := range postIds {
+		if count := len(repository.comments[int32(id)]); count > 0 {
+			counts[id] = count
+		}
+	}
+	return
+}
+
+// insert must be called with the write lock held
+func (repository *BlogMemoryRepositoryImplementation) insert(blog modelentities.Blog) int32 {
+	repository.lastId++
+	blog.Id = pgtype.Int4{Valid: true, Int32: repository.lastId}
+	repository.blogs[repository.lastId] = blog
+	return repository.lastId
+}
+
+// checkSlug returns the unique violation postgres returns for the slug column
+func (repository *BlogMemoryRepositoryImplementation) checkSlug(blog modelentities.Blog) error {
+	if !blog.Slug.Valid {
+		return nil
+	}
+	for _, existing := range repository.blogs {
+		if existing.Slug.Valid && existing.Slug.String == blog.Slug.String {
+			return &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint \"blogs_slug_key\""}
+		}
+	}
+	return nil
+}
+
+// matching filters like tags ILIKE '%' || term || '%' and orders by id, it must be called with the lock held
+func (repository *BlogMemoryRepositoryImplementation) matching(term string) []modelentities.Blog {
+	var blogs []modelentities.Blog
+	for _, blog := range repository.blogs {
+		if term == "" || strings.Contains(strings.ToLower(blog.Tags.String), strings.ToLower(term)) {
+			blogs = append(blogs, blog)
+		}
+	}
+	sort.Slice(blogs, func(i, j int) bool {
+		return blogs[i].Id.Int32 < blogs[j].Id.Int32
+	})
+	return blogs
+}
+
+// taxonomies groups blogs by the names returned by names keeping the latest updated_at and counting every blog once per name like the sql queries,
+// it must be called with the lock held
+func (repository *BlogMemoryRepositoryImplementation) taxonomies(names func(blog modelentities.Blog) []string) []modelentities.Taxonomy {
+	updatedAts := map[string]int64{}
+	counts := map[string]int64{}
+	for _, blog := range repository.blogs {
+		updatedAt := blog.CreatedAt.Int64
+		if blog.UpdatedAt.Valid {
+			updatedAt = blog.UpdatedAt.Int64
+		}
+		seen := map[string]bool{}
+		for _, name := range names(blog) {
+			if current, ok := updatedAts[name]; !ok || updatedAt > current {
+				updatedAts[name] = updatedAt
+			}
+			if !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+	var taxonomies []modelentities.Taxonomy
+	for name, updatedAt := range updatedAts {
+		taxonomies = append(taxonomies, modelentities.Taxonomy{
+			Name:      pgtype.Text{Valid: true, String: name},
+			UpdatedAt: pgtype.Int8{Valid: true, Int64: updatedAt},
+			Count:     pgtype.Int8{Valid: true, Int64: counts[name]},
+		})
+	}
+	sort.Slice(taxonomies, func(i, j int) bool {
+		return taxonomies[i].Name.String < taxonomies[j].Name.String
+	})
+	return taxonomies
+}
+
+func named(taxonomies []modelentities.Taxonomy, names []string) (filtered []modelentities.Taxonomy) {
+	for _, taxonomy := range taxonomies {
+		if slices.Contains(names, taxonomy.Name.String) {
+			filtered = append(filtered, taxonomy)
+		}
+	}
+	return
+}
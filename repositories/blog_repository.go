@@ -3,16 +3,42 @@ package repositories
 import (
 	modelentities "blogging-platform-api/models/entities"
 	"context"
+	"strconv"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// Querier is implemented by both *pgxpool.Pool and pgx.Tx so every repository method can run on its own or inside a transaction
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
 type BlogRepository interface {
-	Create(pool *pgxpool.Pool, ctx context.Context, blog modelentities.Blog) (insertedId int, err error)
-	Update(pool *pgxpool.Pool, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error)
-	FindById(pool *pgxpool.Pool, ctx context.Context, id int) (blog modelentities.Blog, err error)
-	Delete(pool *pgxpool.Pool, ctx context.Context, id int) (rowsAffected int64, err error)
-	FindAll(pool *pgxpool.Pool, ctx context.Context, term string) (blogs []modelentities.Blog, err error)
+	Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error)
+	Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error)
+	FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error)
+	FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error)
+	FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error)
+	Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error)
+	FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error)
+	CountAll(querier Querier, ctx context.Context) (count int, err error)
+	StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error)
+	FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error)
+	FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error)
+	FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error)
+	FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error)
+	FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error)
+	CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error)
+	UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error)
+	CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error)
+	FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error)
+	DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error)
+	UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error)
+	CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error)
 }
 
 type BlogRepositoryImplementation struct {
@@ -22,16 +48,35 @@ func NewBlogRepository() BlogRepository {
 	return &BlogRepositoryImplementation{}
 }
 
-func (repository *BlogRepositoryImplementation) Create(pool *pgxpool.Pool, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
+func (repository *BlogRepositoryImplementation) Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
 	query := `INSERT INTO blogs (title,content,category,tags,created_at,updated_at) 
 		VALUES ($1,$2,$3,$4,$5,$6) RETURNING id;`
-	err = pool.QueryRow(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt).Scan(&insertedId)
+	err = querier.QueryRow(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt).Scan(&insertedId)
+	return
+}
+
+func (repository *BlogRepositoryImplementation) CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error) {
+	rows := make([][]interface{}, 0, len(blogs))
+	for _, blog := range blogs {
+		rows = append(rows, []interface{}{blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt})
+	}
+	columns := []string{"title", "content", "category", "tags", "created_at", "updated_at"}
+	rowsAffected, err = querier.CopyFrom(ctx, pgx.Identifier{"blogs"}, columns, pgx.CopyFromRows(rows))
+	return
+}
+
+// UpsertBySlug inserts the blog or overwrites the blog with the same slug, inserted is false when an existing blog was overwritten
+func (repository *BlogRepositoryImplementation) UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error) {
+	query := `INSERT INTO blogs (title,content,category,tags,created_at,updated_at,slug,author) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) 
+		ON CONFLICT (slug) DO UPDATE SET title = EXCLUDED.title, content = EXCLUDED.content, category = EXCLUDED.category, tags = EXCLUDED.tags, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at, author = EXCLUDED.author 
+		RETURNING id, (xmax = 0) AS inserted;`
+	err = querier.QueryRow(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt, blog.Slug, blog.Author).Scan(&id, &inserted)
 	return
 }
 
-func (repository *BlogRepositoryImplementation) Update(pool *pgxpool.Pool, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
+func (repository *BlogRepositoryImplementation) Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
 	query := `UPDATE blogs SET title = $1, content = $2, category = $3, tags = $4, updated_at = $5 WHERE id = $6;`
-	result, err := pool.Exec(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.UpdatedAt, blog.Id)
+	result, err := querier.Exec(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.UpdatedAt, blog.Id)
 	if err != nil {
 		return
 	}
@@ -39,15 +84,27 @@ func (repository *BlogRepositoryImplementation) Update(pool *pgxpool.Pool, ctx c
 	return
 }
 
-func (repository *BlogRepositoryImplementation) FindById(pool *pgxpool.Pool, ctx context.Context, id int) (blog modelentities.Blog, err error) {
-	query := `SELECT id,title,content,category,tags,created_at,updated_at FROM blogs WHERE id = $1;`
-	err = pool.QueryRow(ctx, query, id).Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt)
+func (repository *BlogRepositoryImplementation) FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE id = $1;`
+	err = querier.QueryRow(ctx, query, id).Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
 	return
 }
 
-func (repository *BlogRepositoryImplementation) Delete(pool *pgxpool.Pool, ctx context.Context, id int) (rowsAffected int64, err error) {
+func (repository *BlogRepositoryImplementation) FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE slug = $1;`
+	err = querier.QueryRow(ctx, query, slug).Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+	return
+}
+
+// FindByIds returns the blogs of ids that exist ordered by id, a missing id is left out instead of failing the whole batch
+func (repository *BlogRepositoryImplementation) FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE id = ANY($1) ORDER BY id;`
+	return repository.findBlogs(querier, ctx, query, ids)
+}
+
+func (repository *BlogRepositoryImplementation) Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error) {
 	query := `DELETE FROM blogs WHERE id = $1;`
-	result, err := pool.Exec(ctx, query, id)
+	result, err := querier.Exec(ctx, query, id)
 	if err != nil {
 		return
 	}
@@ -55,15 +112,15 @@ func (repository *BlogRepositoryImplementation) Delete(pool *pgxpool.Pool, ctx c
 	return
 }
 
-func (repository *BlogRepositoryImplementation) FindAll(pool *pgxpool.Pool, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
+func (repository *BlogRepositoryImplementation) FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
 	whereTerm := ""
 	params := []interface{}{}
 	if term != "" {
 		whereTerm = " WHERE tags ILIKE '%' || $1 || '%'"
 		params = append(params, term)
 	}
-	query := `SELECT id,title,content,category,tags,created_at,updated_at FROM blogs ` + whereTerm + `;`
-	rows, err := pool.Query(ctx, query, params...)
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs ` + whereTerm + `;`
+	rows, err := querier.Query(ctx, query, params...)
 	if err != nil {
 		return
 	}
@@ -71,7 +128,7 @@ func (repository *BlogRepositoryImplementation) FindAll(pool *pgxpool.Pool, ctx
 
 	for rows.Next() {
 		var blog modelentities.Blog
-		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt)
+		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
 		if err != nil {
 			blogs = []modelentities.Blog{}
 			return
@@ -85,3 +142,206 @@ func (repository *BlogRepositoryImplementation) FindAll(pool *pgxpool.Pool, ctx
 	}
 	return
 }
+
+func (repository *BlogRepositoryImplementation) CountAll(querier Querier, ctx context.Context) (count int, err error) {
+	query := `SELECT COUNT(*) FROM blogs;`
+	err = querier.QueryRow(ctx, query).Scan(&count)
+	return
+}
+
+// StreamAll calls callback for every row matching term ordered by id without keeping the rows in memory, a limit of 0 means no limit
+func (repository *BlogRepositoryImplementation) StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error) {
+	whereTerm := ""
+	params := []interface{}{offset}
+	if term != "" {
+		whereTerm = " WHERE tags ILIKE '%' || $2 || '%'"
+		params = append(params, term)
+	}
+	limitOffset := " OFFSET $1"
+	if limit > 0 {
+		limitOffset = " LIMIT $" + strconv.Itoa(len(params)+1) + " OFFSET $1"
+		params = append(params, limit)
+	}
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs` + whereTerm + ` ORDER BY id` + limitOffset + `;`
+	rows, err := querier.Query(ctx, query, params...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blog modelentities.Blog
+		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+		if err != nil {
+			return
+		}
+		err = callback(blog)
+		if err != nil {
+			return
+		}
+	}
+	err = rows.Err()
+	return
+}
+
+// FindAfter is keyset pagination over id, it returns at most limit blogs with an id above afterId matching term like FindAll and category exactly when it is not empty
+func (repository *BlogRepositoryImplementation) FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs 
+		WHERE id > $1 AND ($2 = '' OR tags ILIKE '%' || $2 || '%') AND ($3 = '' OR category = $3) ORDER BY id LIMIT $4;`
+	return repository.findBlogs(querier, ctx, query, afterId, term, category, limit)
+}
+
+func (repository *BlogRepositoryImplementation) findBlogs(querier Querier, ctx context.Context, query string, args ...any) (blogs []modelentities.Blog, err error) {
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blog modelentities.Blog
+		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+		if err != nil {
+			blogs = []modelentities.Blog{}
+			return
+		}
+		blogs = append(blogs, blog)
+	}
+	if rows.Err() != nil {
+		blogs = []modelentities.Blog{}
+		err = rows.Err()
+		return
+	}
+	return
+}
+
+func (repository *BlogRepositoryImplementation) FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error) {
+	query := `SELECT category, MAX(COALESCE(updated_at, created_at)), COUNT(*) FROM blogs GROUP BY category ORDER BY category;`
+	return repository.findTaxonomies(querier, ctx, query)
+}
+
+// FindTags splits the comma separated tags column so every tag is returned once, a blog listing a tag twice is counted once
+func (repository *BlogRepositoryImplementation) FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error) {
+	query := `SELECT TRIM(tag) AS name, MAX(COALESCE(updated_at, created_at)), COUNT(DISTINCT id) FROM blogs, UNNEST(STRING_TO_ARRAY(tags, ',')) AS tag 
+		WHERE TRIM(tag) <> '' GROUP BY name ORDER BY name;`
+	return repository.findTaxonomies(querier, ctx, query)
+}
+
+// FindCategoriesByNames is FindCategories of the categories in names only
+func (repository *BlogRepositoryImplementation) FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error) {
+	query := `SELECT category, MAX(COALESCE(updated_at, created_at)), COUNT(*) FROM blogs WHERE category = ANY($1) GROUP BY category ORDER BY category;`
+	return repository.findTaxonomies(querier, ctx, query, names)
+}
+
+// FindTagsByNames is FindTags of the tags in names only, the tags column is still split for every blog but only the tags in names are counted
+func (repository *BlogRepositoryImplementation) FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error) {
+	query := `SELECT TRIM(tag) AS name, MAX(COALESCE(updated_at, created_at)), COUNT(DISTINCT id) FROM blogs, UNNEST(STRING_TO_ARRAY(tags, ',')) AS tag 
+		WHERE TRIM(tag) = ANY($1) GROUP BY name ORDER BY name;`
+	return repository.findTaxonomies(querier, ctx, query, names)
+}
+
+func (repository *BlogRepositoryImplementation) findTaxonomies(querier Querier, ctx context.Context, query string, args ...any) (taxonomies []modelentities.Taxonomy, err error) {
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taxonomy modelentities.Taxonomy
+		err = rows.Scan(&taxonomy.Name, &taxonomy.UpdatedAt, &taxonomy.Count)
+		if err != nil {
+			taxonomies = []modelentities.Taxonomy{}
+			return
+		}
+		taxonomies = append(taxonomies, taxonomy)
+	}
+	if rows.Err() != nil {
+		taxonomies = []modelentities.Taxonomy{}
+		err = rows.Err()
+		return
+	}
+	return
+}
+
+// CreateEvent appends event to the log, the transaction lock makes events of concurrent transactions commit in the order of their ids
+// so a reader that read up to an id never misses a smaller one that commits later, it is held until the transaction ends so writers
+// append their events as their last statement and only appending and committing are serialized while their rows are written concurrently
+func (repository *BlogRepositoryImplementation) CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error) {
+	_, err = querier.Exec(ctx, `SELECT pg_advisory_xact_lock('blog_events'::regclass::oid::bigint);`)
+	if err != nil {
+		return
+	}
+	query := `INSERT INTO blog_events (type,post_id,payload,created_at) VALUES ($1,$2,$3,$4) RETURNING id;`
+	err = querier.QueryRow(ctx, query, event.Type, event.PostId, event.Payload, event.CreatedAt).Scan(&insertedId)
+	return
+}
+
+func (repository *BlogRepositoryImplementation) FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error) {
+	query := `SELECT id,type,post_id,payload,created_at FROM blog_events WHERE id > $1 ORDER BY id LIMIT $2;`
+	rows, err := querier.Query(ctx, query, afterId, limit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event modelentities.BlogEvent
+		err = rows.Scan(&event.Id, &event.Type, &event.PostId, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			events = []modelentities.BlogEvent{}
+			return
+		}
+		events = append(events, event)
+	}
+	err = rows.Err()
+	return
+}
+
+// DeleteEventsBefore trims the log, ids only grow so deleting below an id keeps the newest events
+func (repository *BlogRepositoryImplementation) DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error) {
+	query := `DELETE FROM blog_events WHERE id < $1;`
+	result, err := querier.Exec(ctx, query, beforeId)
+	if err != nil {
+		return
+	}
+	rowsAffected = result.RowsAffected()
+	return
+}
+
+// UpsertComments inserts the comments or overwrites the comment of the same post with the same source id so importing them again does not duplicate them
+func (repository *BlogRepositoryImplementation) UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error) {
+	query := `INSERT INTO blog_comments (post_id,source_id,author,content,created_at) VALUES ($1,$2,$3,$4,$5) 
+		ON CONFLICT (post_id, source_id) DO UPDATE SET author = EXCLUDED.author, content = EXCLUDED.content, created_at = EXCLUDED.created_at;`
+	for _, comment := range comments {
+		var result pgconn.CommandTag
+		result, err = querier.Exec(ctx, query, comment.PostId, comment.SourceId, comment.Author, comment.Content, comment.CreatedAt)
+		if err != nil {
+			return
+		}
+		rowsAffected += result.RowsAffected()
+	}
+	return
+}
+
+// CountComments returns the number of comments of every post of postIds that has any, posts without comments are missing from counts
+func (repository *BlogRepositoryImplementation) CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error) {
+	query := `SELECT post_id, COUNT(*) FROM blog_comments WHERE post_id = ANY($1) GROUP BY post_id;`
+	rows, err := querier.Query(ctx, query, postIds)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	counts = map[int]int{}
+	for rows.Next() {
+		var postId, count int
+		err = rows.Scan(&postId, &count)
+		if err != nil {
+			return
+		}
+		counts[postId] = count
+	}
+	err = rows.Err()
+	return
+}
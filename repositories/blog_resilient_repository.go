@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/utils"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BlogResilientRepositoryImplementation wraps another BlogRepository with retries and a circuit breaker,
+// calls inside a transaction are never retried because the transaction is aborted after an error, the caller retries the whole transaction instead
+type BlogResilientRepositoryImplementation struct {
+	BlogRepository BlogRepository
+	RetryPolicy    utils.RetryPolicy
+	CircuitBreaker *utils.CircuitBreaker
+}
+
+func NewResilientBlogRepository(blogRepository BlogRepository, retryPolicy utils.RetryPolicy, circuitBreaker *utils.CircuitBreaker) BlogRepository {
+	return &BlogResilientRepositoryImplementation{
+		BlogRepository: blogRepository,
+		RetryPolicy:    retryPolicy,
+		CircuitBreaker: circuitBreaker,
+	}
+}
+
+// Create is retried only when the insert surely did not run, retrying after a dropped connection could insert the blog twice
+func (repository *BlogResilientRepositoryImplementation) Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
+	err = repository.call(querier, ctx, utils.IsRetryableError, func() (err error) {
+		insertedId, err = repository.BlogRepository.Create(querier, ctx, blog)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error) {
+	err = repository.call(querier, ctx, utils.IsRetryableError, func() (err error) {
+		rowsAffected, err = repository.BlogRepository.CreateBatch(querier, ctx, blogs)
+		return
+	})
+	return
+}
+
+// UpsertBySlug is not idempotent in its result, a retry after a dropped connection would report an inserted blog as updated
+func (repository *BlogResilientRepositoryImplementation) UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error) {
+	err = repository.call(querier, ctx, utils.IsRetryableError, func() (err error) {
+		id, inserted, err = repository.BlogRepository.UpsertBySlug(querier, ctx, blog)
+		return
+	})
+	return
+}
+
+// Update sets the same values when it runs twice so it is retried after a dropped connection too
+func (repository *BlogResilientRepositoryImplementation) Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		rowsAffected, err = repository.BlogRepository.Update(querier, ctx, blog)
+		return
+	})
+	return
+}
+
+// Delete is not idempotent in its result, a retry after a dropped connection would report zero rows affected
+func (repository *BlogResilientRepositoryImplementation) Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error) {
+	err = repository.call(querier, ctx, utils.IsRetryableError, func() (err error) {
+		rowsAffected, err = repository.BlogRepository.Delete(querier, ctx, id)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		blog, err = repository.BlogRepository.FindById(querier, ctx, id)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		blog, err = repository.BlogRepository.FindBySlug(querier, ctx, slug)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		blogs, err = repository.BlogRepository.FindByIds(querier, ctx, ids)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		blogs, err = repository.BlogRepository.FindAll(querier, ctx, term)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) CountAll(querier Querier, ctx context.Context) (count int, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		count, err = repository.BlogRepository.CountAll(querier, ctx)
+		return
+	})
+	return
+}
+
+// StreamAll is retried only until the first row reached callback, rows already written by callback can not be taken back,
+// errors of callback are returned as they are and never count as database failures
+func (repository *BlogResilientRepositoryImplementation) StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error) {
+	streamed := false
+	var errCallback error
+	retryable := func(err error) bool {
+		return !streamed && isIdempotentRetryable(err)
+	}
+	err = repository.call(querier, ctx, retryable, func() error {
+		err := repository.BlogRepository.StreamAll(querier, ctx, term, limit, offset, func(blog modelentities.Blog) error {
+			streamed = true
+			errCallback = callback(blog)
+			return errCallback
+		})
+		if errCallback != nil {
+			return nil
+		}
+		return err
+	})
+	if errCallback != nil {
+		return errCallback
+	}
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		blogs, err = repository.BlogRepository.FindAfter(querier, ctx, term, category, afterId, limit)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		categories, err = repository.BlogRepository.FindCategories(querier, ctx)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		tags, err = repository.BlogRepository.FindTags(querier, ctx)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		categories, err = repository.BlogRepository.FindCategoriesByNames(querier, ctx, names)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		tags, err = repository.BlogRepository.FindTagsByNames(querier, ctx, names)
+		return
+	})
+	return
+}
+
+// CreateEvent is retried only when the insert surely did not run like Create
+func (repository *BlogResilientRepositoryImplementation) CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error) {
+	err = repository.call(querier, ctx, utils.IsRetryableError, func() (err error) {
+		insertedId, err = repository.BlogRepository.CreateEvent(querier, ctx, event)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		events, err = repository.BlogRepository.FindEventsAfter(querier, ctx, afterId, limit)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		rowsAffected, err = repository.BlogRepository.DeleteEventsBefore(querier, ctx, beforeId)
+		return
+	})
+	return
+}
+
+// UpsertComments is idempotent because a comment is keyed by its post and source id
+func (repository *BlogResilientRepositoryImplementation) UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		rowsAffected, err = repository.BlogRepository.UpsertComments(querier, ctx, comments)
+		return
+	})
+	return
+}
+
+func (repository *BlogResilientRepositoryImplementation) CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error) {
+	err = repository.call(querier, ctx, isIdempotentRetryable, func() (err error) {
+		counts, err = repository.BlogRepository.CountComments(querier, ctx, postIds)
+		return
+	})
+	return
+}
+
+// call runs operation through the circuit breaker and retries it while retryable accepts the error
+func (repository *BlogResilientRepositoryImplementation) call(querier Querier, ctx context.Context, retryable func(err error) bool, operation func() error) error {
+	if _, ok := querier.(pgx.Tx); ok {
+		return repository.CircuitBreaker.Execute(operation)
+	}
+	return utils.Retry(ctx, repository.RetryPolicy, retryable, func() error {
+		return repository.CircuitBreaker.Execute(operation)
+	})
+}
+
+// isIdempotentRetryable also retries after the connection dropped mid statement, running an idempotent statement twice is harmless
+func isIdempotentRetryable(err error) bool {
+	return utils.IsRetryableError(err) || utils.IsConnectionError(err)
+}
@@ -0,0 +1,423 @@
+package repositories
+
+import (
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteExecutor is implemented by both *sql.DB and *sql.Tx
+type sqliteExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// BlogSqliteRepositoryImplementation stores blogs in sqlite, a *utils.SqliteTx querier runs the statement in that transaction and any other querier runs it on the database,
+// errors are returned as the pgx errors the postgres repository returns so services handle both backends alike
+type BlogSqliteRepositoryImplementation struct {
+	DB *sql.DB
+}
+
+func NewBlogSqliteRepository(db *sql.DB) BlogRepository {
+	return &BlogSqliteRepositoryImplementation{
+		DB: db,
+	}
+}
+
+func (repository *BlogSqliteRepositoryImplementation) Create(querier Querier, ctx context.Context, blog modelentities.Blog) (insertedId int, err error) {
+	query := `INSERT INTO blogs (title,content,category,tags,created_at,updated_at)
+		VALUES (?,?,?,?,?,?) RETURNING id;`
+	err = repository.executor(querier).QueryRowContext(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt).Scan(&insertedId)
+	err = sqliteError(err)
+	return
+}
+
+// CreateBatch inserts the blogs with one prepared statement, sqlite has no copy protocol
+func (repository *BlogSqliteRepositoryImplementation) CreateBatch(querier Querier, ctx context.Context, blogs []modelentities.Blog) (rowsAffected int64, err error) {
+	executor := repository.executor(querier)
+	query := `INSERT INTO blogs (title,content,category,tags,created_at,updated_at) VALUES (?,?,?,?,?,?);`
+	var statement *sql.Stmt
+	switch executor := executor.(type) {
+	case *sql.Tx:
+		statement, err = executor.PrepareContext(ctx, query)
+	case *sql.DB:
+		statement, err = executor.PrepareContext(ctx, query)
+	}
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer statement.Close()
+
+	for _, blog := range blogs {
+		_, err = statement.ExecContext(ctx, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt)
+		if err != nil {
+			err = sqliteError(err)
+			return
+		}
+		rowsAffected++
+	}
+	return
+}
+
+// UpsertBySlug inserts the blog or overwrites the blog with the same slug, inserted is false when an existing blog was overwritten
+func (repository *BlogSqliteRepositoryImplementation) UpsertBySlug(querier Querier, ctx context.Context, blog modelentities.Blog) (id int, inserted bool, err error) {
+	executor := repository.executor(querier)
+	var existingId int
+	err = executor.QueryRowContext(ctx, `SELECT id FROM blogs WHERE slug = ?;`, blog.Slug).Scan(&existingId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = sqliteError(err)
+		return
+	}
+	inserted = errors.Is(err, sql.ErrNoRows)
+	query := `INSERT INTO blogs (title,content,category,tags,created_at,updated_at,slug,author) VALUES (?,?,?,?,?,?,?,?)
+		ON CONFLICT (slug) DO UPDATE SET title = excluded.title, content = excluded.content, category = excluded.category, tags = excluded.tags, created_at = excluded.created_at, updated_at = excluded.updated_at, author = excluded.author
+		RETURNING id;`
+	err = executor.QueryRowContext(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.CreatedAt, blog.UpdatedAt, blog.Slug, blog.Author).Scan(&id)
+	err = sqliteError(err)
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) Update(querier Querier, ctx context.Context, blog modelentities.Blog) (rowsAffected int64, err error) {
+	query := `UPDATE blogs SET title = ?, content = ?, category = ?, tags = ?, updated_at = ? WHERE id = ?;`
+	result, err := repository.executor(querier).ExecContext(ctx, query, blog.Title, blog.Content, blog.Category, blog.Tags, blog.UpdatedAt, blog.Id)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	rowsAffected, err = result.RowsAffected()
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindById(querier Querier, ctx context.Context, id int) (blog modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE id = ?;`
+	err = repository.executor(querier).QueryRowContext(ctx, query, id).Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+	err = sqliteError(err)
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindBySlug(querier Querier, ctx context.Context, slug string) (blog modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE slug = ?;`
+	err = repository.executor(querier).QueryRowContext(ctx, query, slug).Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+	err = sqliteError(err)
+	return
+}
+
+// FindByIds binds one placeholder per id, sqlite has no array parameters
+func (repository *BlogSqliteRepositoryImplementation) FindByIds(querier Querier, ctx context.Context, ids []int) (blogs []modelentities.Blog, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	params := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs WHERE id IN (?` + strings.Repeat(",?", len(ids)-1) + `) ORDER BY id;`
+	return repository.findBlogs(querier, ctx, query, params...)
+}
+
+func (repository *BlogSqliteRepositoryImplementation) Delete(querier Querier, ctx context.Context, id int) (rowsAffected int64, err error) {
+	query := `DELETE FROM blogs WHERE id = ?;`
+	result, err := repository.executor(querier).ExecContext(ctx, query, id)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	rowsAffected, err = result.RowsAffected()
+	return
+}
+
+// FindAll matches term like the postgres ILIKE, sqlite LIKE is case insensitive for ascii letters only
+func (repository *BlogSqliteRepositoryImplementation) FindAll(querier Querier, ctx context.Context, term string) (blogs []modelentities.Blog, err error) {
+	err = repository.StreamAll(querier, ctx, term, 0, 0, func(blog modelentities.Blog) error {
+		blogs = append(blogs, blog)
+		return nil
+	})
+	if err != nil {
+		blogs = []modelentities.Blog{}
+	}
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) CountAll(querier Querier, ctx context.Context) (count int, err error) {
+	query := `SELECT COUNT(*) FROM blogs;`
+	err = repository.executor(querier).QueryRowContext(ctx, query).Scan(&count)
+	err = sqliteError(err)
+	return
+}
+
+// StreamAll calls callback for every row matching term ordered by id without keeping the rows in memory, a limit of 0 means no limit
+func (repository *BlogSqliteRepositoryImplementation) StreamAll(querier Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) (err error) {
+	whereTerm := ""
+	params := []interface{}{}
+	if term != "" {
+		whereTerm = " WHERE tags LIKE '%' || ? || '%'"
+		params = append(params, term)
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	params = append(params, limit, offset)
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs` + whereTerm + ` ORDER BY id LIMIT ? OFFSET ?;`
+	rows, err := repository.executor(querier).QueryContext(ctx, query, params...)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blog modelentities.Blog
+		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+		if err != nil {
+			return
+		}
+		err = callback(blog)
+		if err != nil {
+			return
+		}
+	}
+	err = sqliteError(rows.Err())
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindAfter(querier Querier, ctx context.Context, term string, category string, afterId int, limit int) (blogs []modelentities.Blog, err error) {
+	query := `SELECT id,title,content,category,tags,created_at,updated_at,slug,author FROM blogs
+		WHERE id > ?1 AND (?2 = '' OR tags LIKE '%' || ?2 || '%') AND (?3 = '' OR category = ?3) ORDER BY id LIMIT ?4;`
+	return repository.findBlogs(querier, ctx, query, afterId, term, category, limit)
+}
+
+func (repository *BlogSqliteRepositoryImplementation) findBlogs(querier Querier, ctx context.Context, query string, args ...any) (blogs []modelentities.Blog, err error) {
+	rows, err := repository.executor(querier).QueryContext(ctx, query, args...)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blog modelentities.Blog
+		err = rows.Scan(&blog.Id, &blog.Title, &blog.Content, &blog.Category, &blog.Tags, &blog.CreatedAt, &blog.UpdatedAt, &blog.Slug, &blog.Author)
+		if err != nil {
+			blogs = []modelentities.Blog{}
+			return
+		}
+		blogs = append(blogs, blog)
+	}
+	if rows.Err() != nil {
+		blogs = []modelentities.Blog{}
+		err = sqliteError(rows.Err())
+		return
+	}
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindCategories(querier Querier, ctx context.Context) (categories []modelentities.Taxonomy, err error) {
+	query := `SELECT category, MAX(COALESCE(updated_at, created_at)), COUNT(*) FROM blogs GROUP BY category ORDER BY category;`
+	return repository.findTaxonomies(querier, ctx, query)
+}
+
+// FindTags splits the comma separated tags column with a recursive query so every tag is returned once, a blog listing a tag twice is counted once
+func (repository *BlogSqliteRepositoryImplementation) FindTags(querier Querier, ctx context.Context) (tags []modelentities.Taxonomy, err error) {
+	query := `WITH RECURSIVE ` + sqliteSplitTags + `
+		SELECT TRIM(tag) AS name, MAX(updated_at), COUNT(DISTINCT id) FROM split WHERE TRIM(tag) <> '' GROUP BY name ORDER BY name;`
+	return repository.findTaxonomies(querier, ctx, query)
+}
+
+// sqliteSplitTags is the recursive table of FindTags with one row per blog and tag
+const sqliteSplitTags = `split (id, tag, rest, updated_at) AS (
+			SELECT id, '', tags || ',', COALESCE(updated_at, created_at) FROM blogs
+			UNION ALL
+			SELECT id, substr(rest, 1, instr(rest, ',') - 1), substr(rest, instr(rest, ',') + 1), updated_at FROM split WHERE rest <> ''
+		)`
+
+// FindCategoriesByNames binds one placeholder per name like FindByIds
+func (repository *BlogSqliteRepositoryImplementation) FindCategoriesByNames(querier Querier, ctx context.Context, names []string) (categories []modelentities.Taxonomy, err error) {
+	if len(names) == 0 {
+		return
+	}
+	query := `SELECT category, MAX(COALESCE(updated_at, created_at)), COUNT(*) FROM blogs WHERE category IN (?` + strings.Repeat(",?", len(names)-1) + `) GROUP BY category ORDER BY category;`
+	return repository.findTaxonomies(querier, ctx, query, sqliteParams(names)...)
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindTagsByNames(querier Querier, ctx context.Context, names []string) (tags []modelentities.Taxonomy, err error) {
+	if len(names) == 0 {
+		return
+	}
+	query := `WITH RECURSIVE ` + sqliteSplitTags + `
+		SELECT TRIM(tag) AS name, MAX(updated_at), COUNT(DISTINCT id) FROM split WHERE TRIM(tag) IN (?` + strings.Repeat(",?", len(names)-1) + `) GROUP BY name ORDER BY name;`
+	return repository.findTaxonomies(querier, ctx, query, sqliteParams(names)...)
+}
+
+func sqliteParams(names []string) []interface{} {
+	params := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		params = append(params, name)
+	}
+	return params
+}
+
+func (repository *BlogSqliteRepositoryImplementation) findTaxonomies(querier Querier, ctx context.Context, query string, args ...interface{}) (taxonomies []modelentities.Taxonomy, err error) {
+	rows, err := repository.executor(querier).QueryContext(ctx, query, args...)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taxonomy modelentities.Taxonomy
+		err = rows.Scan(&taxonomy.Name, &taxonomy.UpdatedAt, &taxonomy.Count)
+		if err != nil {
+			taxonomies = []modelentities.Taxonomy{}
+			return
+		}
+		taxonomies = append(taxonomies, taxonomy)
+	}
+	if rows.Err() != nil {
+		taxonomies = []modelentities.Taxonomy{}
+		err = sqliteError(rows.Err())
+		return
+	}
+	return
+}
+
+// CreateEvent needs no lock to keep ids in commit order, sqlite runs one write transaction at a time
+func (repository *BlogSqliteRepositoryImplementation) CreateEvent(querier Querier, ctx context.Context, event modelentities.BlogEvent) (insertedId int64, err error) {
+	query := `INSERT INTO blog_events (type,post_id,payload,created_at) VALUES (?,?,?,?) RETURNING id;`
+	err = repository.executor(querier).QueryRowContext(ctx, query, event.Type, event.PostId, event.Payload, event.CreatedAt).Scan(&insertedId)
+	err = sqliteError(err)
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) FindEventsAfter(querier Querier, ctx context.Context, afterId int64, limit int) (events []modelentities.BlogEvent, err error) {
+	query := `SELECT id,type,post_id,payload,created_at FROM blog_events WHERE id > ? ORDER BY id LIMIT ?;`
+	rows, err := repository.executor(querier).QueryContext(ctx, query, afterId, limit)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event modelentities.BlogEvent
+		err = rows.Scan(&event.Id, &event.Type, &event.PostId, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			events = []modelentities.BlogEvent{}
+			return
+		}
+		events = append(events, event)
+	}
+	if rows.Err() != nil {
+		events = []modelentities.BlogEvent{}
+		err = sqliteError(rows.Err())
+		return
+	}
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) DeleteEventsBefore(querier Querier, ctx context.Context, beforeId int64) (rowsAffected int64, err error) {
+	query := `DELETE FROM blog_events WHERE id < ?;`
+	result, err := repository.executor(querier).ExecContext(ctx, query, beforeId)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	rowsAffected, err = result.RowsAffected()
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) UpsertComments(querier Querier, ctx context.Context, comments []modelentities.BlogComment) (rowsAffected int64, err error) {
+	query := `INSERT INTO blog_comments (post_id,source_id,author,content,created_at) VALUES (?,?,?,?,?)
+		ON CONFLICT (post_id, source_id) DO UPDATE SET author = excluded.author, content = excluded.content, created_at = excluded.created_at;`
+	executor := repository.executor(querier)
+	for _, comment := range comments {
+		var result sql.Result
+		result, err = executor.ExecContext(ctx, query, comment.PostId, comment.SourceId, comment.Author, comment.Content, comment.CreatedAt)
+		if err != nil {
+			err = sqliteError(err)
+			return
+		}
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err != nil {
+			return
+		}
+		rowsAffected += affected
+	}
+	return
+}
+
+// CountComments binds one placeholder per id because sqlite has no array parameters
+func (repository *BlogSqliteRepositoryImplementation) CountComments(querier Querier, ctx context.Context, postIds []int) (counts map[int]int, err error) {
+	counts = map[int]int{}
+	if len(postIds) == 0 {
+		return
+	}
+	params := make([]interface{}, 0, len(postIds))
+	for _, id := range postIds {
+		params = append(params, id)
+	}
+	query := `SELECT post_id, COUNT(*) FROM blog_comments WHERE post_id IN (?` + strings.Repeat(",?", len(postIds)-1) + `) GROUP BY post_id;`
+	rows, err := repository.executor(querier).QueryContext(ctx, query, params...)
+	if err != nil {
+		err = sqliteError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId, count int
+		err = rows.Scan(&postId, &count)
+		if err != nil {
+			return
+		}
+		counts[postId] = count
+	}
+	err = sqliteError(rows.Err())
+	return
+}
+
+func (repository *BlogSqliteRepositoryImplementation) executor(querier Querier) sqliteExecutor {
+	if tx, ok := querier.(*utils.SqliteTx); ok {
+		return tx.Tx
+	}
+	return repository.DB
+}
+
+// sqliteError translates sqlite errors to the errors of pgx and the sqlstate codes exceptions.NewDatabaseError knows
+func sqliteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	var code string
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		code = "23505"
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		code = "23502"
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		code = "23514"
+	default:
+		if sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY {
+			code = "40001"
+		}
+	}
+	if code == "" {
+		return err
+	}
+	return &pgconn.PgError{Code: code, Message: sqliteErr.Error()}
+}
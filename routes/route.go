@@ -2,14 +2,71 @@ package routes
 
 import (
 	"blogging-platform-api/controllers"
+	"blogging-platform-api/protos/blogpb"
 
 	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
 )
 
-func BlogRoute(e *echo.Echo, controller controllers.BlogController) {
-	e.POST("/posts", controller.Create)
-	e.PUT("/posts/:id", controller.Update)
-	e.DELETE("/posts/:id", controller.Delete)
-	e.GET("/posts/:id", controller.FindById)
-	e.GET("/posts", controller.FindAll)
+// Router is implemented by *echo.Echo and *echo.Group so the routes of an api version can be mounted under its prefix and at the root,
+// a new version registers its own controllers with their own response types on its own group side by side with the older ones
+type Router interface {
+	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+func BlogRoute(router Router, controller controllers.BlogController, m ...echo.MiddlewareFunc) {
+	router.POST("/posts", controller.Create, m...)
+	router.PUT("/posts/:id", controller.Update, m...)
+	router.DELETE("/posts/:id", controller.Delete, m...)
+	router.GET("/posts/:id", controller.FindById, m...)
+	router.GET("/posts", controller.FindAll, m...)
+}
+
+func PostEventRoute(router Router, controller controllers.PostEventController, m ...echo.MiddlewareFunc) {
+	router.GET("/posts/events", controller.Events, m...)
+}
+
+func SitemapRoute(e *echo.Echo, controller controllers.SitemapController) {
+	e.GET("/sitemap.xml", controller.Sitemap)
+	e.GET("/sitemap-posts-:page", controller.PostsSitemap)
+	e.GET("/sitemap-taxonomies-:page", controller.TaxonomiesSitemap)
+}
+
+func ImportRoute(router Router, controller controllers.ImportController, m ...echo.MiddlewareFunc) {
+	router.POST("/posts/import", controller.Import, m...)
+}
+
+func ExportRoute(router Router, controller controllers.ExportController, m ...echo.MiddlewareFunc) {
+	router.GET("/posts/export", controller.Export, m...)
+}
+
+func WordpressImportRoute(router Router, controller controllers.WordpressImportController, m ...echo.MiddlewareFunc) {
+	router.POST("/posts/import/wordpress", controller.Import, m...)
+}
+
+func HealthRoute(e *echo.Echo, controller controllers.HealthController) {
+	e.GET("/healthz", controller.Healthz)
+	e.GET("/readyz", controller.Readyz)
+	e.GET("/health", controller.Health)
+}
+
+func MetricsRoute(e *echo.Echo, controller controllers.MetricsController) {
+	e.GET("/metrics", controller.Metrics)
+}
+
+func OpenApiRoute(e *echo.Echo, controller controllers.OpenApiController) {
+	e.GET("/openapi.json", controller.OpenApi)
+	e.GET("/docs", controller.Docs)
+	e.GET("/docs/redoc.standalone.js", controller.Redoc)
+}
+
+func GraphqlRoute(e *echo.Echo, controller controllers.GraphqlController, m ...echo.MiddlewareFunc) {
+	e.POST("/graphql", controller.Graphql, m...)
+}
+
+func BlogGrpcRoute(server *grpc.Server, controller blogpb.BlogServiceServer) {
+	blogpb.RegisterBlogServiceServer(server, controller)
 }
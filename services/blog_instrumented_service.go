@@ -0,0 +1,101 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/utils"
+	"context"
+	"log/slog"
+)
+
+// BlogInstrumentedServiceImplementation counts every operation of another BlogService by its result, the result is ok or the kind of the error,
+// server errors are logged with the context of the operation so the log line carries the request id
+type BlogInstrumentedServiceImplementation struct {
+	BlogService BlogService
+	MetricsUtil utils.MetricsUtil
+}
+
+func NewInstrumentedBlogService(blogService BlogService, metricsUtil utils.MetricsUtil) BlogService {
+	return &BlogInstrumentedServiceImplementation{
+		BlogService: blogService,
+		MetricsUtil: metricsUtil,
+	}
+}
+
+func (service *BlogInstrumentedServiceImplementation) Create(ctx context.Context, createRequest modelrequests.CreateRequest) (response modelresponses.CreateResponse, err error) {
+	defer service.count(ctx, "Create", &err)
+	return service.BlogService.Create(ctx, createRequest)
+}
+
+func (service *BlogInstrumentedServiceImplementation) Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (response modelresponses.UpdateResponse, err error) {
+	defer service.count(ctx, "Update", &err)
+	return service.BlogService.Update(ctx, idBlog, updateRequest)
+}
+
+func (service *BlogInstrumentedServiceImplementation) Delete(ctx context.Context, idBlog int) (err error) {
+	defer service.count(ctx, "Delete", &err)
+	return service.BlogService.Delete(ctx, idBlog)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindById(ctx context.Context, idBlog int) (response modelresponses.FindByIdResponse, err error) {
+	defer service.count(ctx, "FindById", &err)
+	return service.BlogService.FindById(ctx, idBlog)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindAllPosts(ctx context.Context, term string) (response []modelresponses.FindResponse, err error) {
+	defer service.count(ctx, "FindAllPosts", &err)
+	return service.BlogService.FindAllPosts(ctx, term)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindBySlug(ctx context.Context, slug string) (response modelresponses.PostResponse, err error) {
+	defer service.count(ctx, "FindBySlug", &err)
+	return service.BlogService.FindBySlug(ctx, slug)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindPostsByIds(ctx context.Context, idBlogs []int) (response []modelresponses.PostResponse, err error) {
+	defer service.count(ctx, "FindPostsByIds", &err)
+	return service.BlogService.FindPostsByIds(ctx, idBlogs)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindPostsPage(ctx context.Context, findPageRequest modelrequests.FindPageRequest) (response modelresponses.PostPageResponse, err error) {
+	defer service.count(ctx, "FindPostsPage", &err)
+	return service.BlogService.FindPostsPage(ctx, findPageRequest)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindCategories(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	defer service.count(ctx, "FindCategories", &err)
+	return service.BlogService.FindCategories(ctx)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindTags(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	defer service.count(ctx, "FindTags", &err)
+	return service.BlogService.FindTags(ctx)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindCategoriesByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	defer service.count(ctx, "FindCategoriesByNames", &err)
+	return service.BlogService.FindCategoriesByNames(ctx, names)
+}
+
+func (service *BlogInstrumentedServiceImplementation) FindTagsByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	defer service.count(ctx, "FindTagsByNames", &err)
+	return service.BlogService.FindTagsByNames(ctx, names)
+}
+
+func (service *BlogInstrumentedServiceImplementation) CountComments(ctx context.Context, idBlogs []int) (response map[int]int, err error) {
+	defer service.count(ctx, "CountComments", &err)
+	return service.BlogService.CountComments(ctx, idBlogs)
+}
+
+func (service *BlogInstrumentedServiceImplementation) count(ctx context.Context, operation string, err *error) {
+	result := "ok"
+	if *err != nil {
+		kind := exceptions.AsAppError(*err).Kind
+		result = kind.String()
+		if kind == exceptions.KindInternal || kind == exceptions.KindUnavailable {
+			slog.ErrorContext(ctx, "blog service: operation failed", "operation", operation, "error", *err)
+		}
+	}
+	service.MetricsUtil.CountServiceOperation(operation, result)
+}
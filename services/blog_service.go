@@ -1,13 +1,18 @@
 package services
 
 import (
+	"blogging-platform-api/exceptions"
 	modelentities "blogging-platform-api/models/entities"
 	modelrequests "blogging-platform-api/models/requests"
 	modelresponses "blogging-platform-api/models/responses"
 	"blogging-platform-api/repositories"
 	"blogging-platform-api/utils"
 	"context"
-	"net/http"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,11 +22,19 @@ import (
 )
 
 type BlogService interface {
-	Create(ctx context.Context, createRequest modelrequests.CreateRequest) (httpCode int, response interface{})
-	Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (httpCode int, response interface{})
-	Delete(ctx context.Context, idBlog int) (httpCode int, response interface{})
-	FindById(ctx context.Context, idBlog int) (httpCode int, response interface{})
-	FindAllPosts(ctx context.Context, term string) (httpCode int, response interface{})
+	Create(ctx context.Context, createRequest modelrequests.CreateRequest) (response modelresponses.CreateResponse, err error)
+	Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (response modelresponses.UpdateResponse, err error)
+	Delete(ctx context.Context, idBlog int) (err error)
+	FindById(ctx context.Context, idBlog int) (response modelresponses.FindByIdResponse, err error)
+	FindAllPosts(ctx context.Context, term string) (response []modelresponses.FindResponse, err error)
+	FindBySlug(ctx context.Context, slug string) (response modelresponses.PostResponse, err error)
+	FindPostsByIds(ctx context.Context, idBlogs []int) (response []modelresponses.PostResponse, err error)
+	FindPostsPage(ctx context.Context, findPageRequest modelrequests.FindPageRequest) (response modelresponses.PostPageResponse, err error)
+	FindCategories(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error)
+	FindTags(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error)
+	FindCategoriesByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error)
+	FindTagsByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error)
+	CountComments(ctx context.Context, idBlogs []int) (response map[int]int, err error)
 	// Filter()
 }
 
@@ -29,21 +42,26 @@ type BlogServiceImplementation struct {
 	PostgresUtil   utils.PostgresUtil
 	Validate       *validator.Validate
 	BlogRepository repositories.BlogRepository
+	RetryPolicy    utils.RetryPolicy
+	EventLogSize   int
 }
 
-func NewBlogService(postgresUtil utils.PostgresUtil, validate *validator.Validate, blogRepository repositories.BlogRepository) BlogService {
+// NewBlogService retries whole transactions with retryPolicy after serialization failures and deadlocks,
+// every write records its post events in the same transaction and trims the log to the newest eventLogSize of them once it committed
+func NewBlogService(postgresUtil utils.PostgresUtil, validate *validator.Validate, blogRepository repositories.BlogRepository, retryPolicy utils.RetryPolicy, eventLogSize int) BlogService {
 	return &BlogServiceImplementation{
 		PostgresUtil:   postgresUtil,
 		Validate:       validate,
 		BlogRepository: blogRepository,
+		RetryPolicy:    retryPolicy,
+		EventLogSize:   eventLogSize,
 	}
 }
 
-func (service *BlogServiceImplementation) Create(ctx context.Context, createRequest modelrequests.CreateRequest) (httpCode int, response interface{}) {
-	err := service.Validate.Struct(createRequest)
+func (service *BlogServiceImplementation) Create(ctx context.Context, createRequest modelrequests.CreateRequest) (response modelresponses.CreateResponse, err error) {
+	err = service.Validate.Struct(createRequest)
 	if err != nil {
-		httpCode = http.StatusBadRequest
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx))
 		return
 	}
 	var blog modelentities.Blog
@@ -53,31 +71,35 @@ func (service *BlogServiceImplementation) Create(ctx context.Context, createRequ
 	blog.Tags = pgtype.Text{Valid: true, String: strings.Join(createRequest.Tags, ", ")}
 	blog.CreatedAt = pgtype.Int8{Valid: true, Int64: time.Now().UnixMilli()}
 	blog.UpdatedAt = pgtype.Int8{Valid: true, Int64: time.Now().UnixMilli()}
-	insertedId, err := service.BlogRepository.Create(service.PostgresUtil.GetPool(), ctx, blog)
+	var lastEventId int64
+	err = service.inTransaction(ctx, pgx.ReadCommitted, func(tx pgx.Tx) error {
+		insertedId, err := service.BlogRepository.Create(tx, ctx, blog)
+		if err != nil {
+			return err
+		}
+		blog.Id = pgtype.Int4{Valid: true, Int32: int32(insertedId)}
+		lastEventId, err = service.recordEvents(tx, ctx, blog, PostEventCreated, PostEventPublished)
+		return err
+	})
 	if err != nil {
-		httpCode = http.StatusBadRequest
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	var createResponse modelresponses.CreateResponse
-	createResponse.Id = insertedId
-	createResponse.Title = createRequest.Title
-	createResponse.Content = createRequest.Content
-	createResponse.Category = createRequest.Category
-	createResponse.Tags = createRequest.Tags
-	createResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-	createResponse.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-
-	httpCode = http.StatusCreated
-	response = createResponse
+	service.trimEvents(ctx, lastEventId)
+	response.Id = int(blog.Id.Int32)
+	response.Title = createRequest.Title
+	response.Content = createRequest.Content
+	response.Category = createRequest.Category
+	response.Tags = createRequest.Tags
+	response.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	response.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
 	return
 }
 
-func (service *BlogServiceImplementation) Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (httpCode int, response interface{}) {
-	err := service.Validate.Struct(updateRequest)
+func (service *BlogServiceImplementation) Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (response modelresponses.UpdateResponse, err error) {
+	err = service.Validate.Struct(updateRequest)
 	if err != nil {
-		httpCode = http.StatusBadRequest
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx))
 		return
 	}
 	var blog modelentities.Blog
@@ -87,108 +109,310 @@ func (service *BlogServiceImplementation) Update(ctx context.Context, idBlog int
 	blog.Category = pgtype.Text{Valid: true, String: updateRequest.Category}
 	blog.Tags = pgtype.Text{Valid: true, String: strings.Join(updateRequest.Tags, ", ")}
 	blog.UpdatedAt = pgtype.Int8{Valid: true, Int64: time.Now().UnixMilli()}
-	rowsAffected, err := service.BlogRepository.Update(service.PostgresUtil.GetPool(), ctx, blog)
+	// the update and the read of the updated row run in one transaction so the response never shows another request's write
+	var lastEventId int64
+	err = service.inTransaction(ctx, pgx.ReadCommitted, func(tx pgx.Tx) error {
+		rowsAffected, err := service.BlogRepository.Update(tx, ctx, blog)
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return exceptions.NewNotFoundError(exceptions.CodePostNotFound, "post not found")
+		}
+		if rowsAffected != 1 {
+			return exceptions.NewInternalError(errors.New("rows affected update not one"))
+		}
+		blog, err = service.BlogRepository.FindById(tx, ctx, idBlog)
+		if err != nil {
+			return err
+		}
+		lastEventId, err = service.recordEvents(tx, ctx, blog, PostEventUpdated)
+		return err
+	})
 	if err != nil {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	if rowsAffected != 1 {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse("rows affeted update not one")
+	service.trimEvents(ctx, lastEventId)
+	response.Id = int(blog.Id.Int32)
+	response.Title = blog.Title.String
+	response.Content = blog.Content.String
+	response.Category = blog.Category.String
+	response.Tags = strings.Split(blog.Tags.String, ",")
+	response.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	response.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	return
+}
+
+func (service *BlogServiceImplementation) Delete(ctx context.Context, idBlog int) (err error) {
+	// repeatable read makes a concurrent delete between the existence check and the delete fail with a serialization error instead of deleting nothing
+	var lastEventId int64
+	err = service.inTransaction(ctx, pgx.RepeatableRead, func(tx pgx.Tx) error {
+		blog, err := service.BlogRepository.FindById(tx, ctx, idBlog)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := service.BlogRepository.Delete(tx, ctx, idBlog)
+		if err != nil {
+			return err
+		}
+		if rowsAffected != 1 {
+			return exceptions.NewInternalError(errors.New("rows affected not one"))
+		}
+		lastEventId, err = service.recordEvents(tx, ctx, blog, PostEventDeleted)
+		return err
+	})
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	blog, err = service.BlogRepository.FindById(service.PostgresUtil.GetPool(), ctx, idBlog)
+	service.trimEvents(ctx, lastEventId)
+	return
+}
+
+func (service *BlogServiceImplementation) FindById(ctx context.Context, idBlog int) (response modelresponses.FindByIdResponse, err error) {
+	blog, err := service.BlogRepository.FindById(service.PostgresUtil.GetReadPool(ctx), ctx, idBlog)
 	if err != nil {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	var updateResponse modelresponses.UpdateResponse
-	updateResponse.Id = int(blog.Id.Int32)
-	updateResponse.Title = blog.Title.String
-	updateResponse.Content = blog.Content.String
-	updateResponse.Category = blog.Category.String
-	updateResponse.Tags = strings.Split(blog.Tags.String, ",")
-	updateResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-	updateResponse.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-	httpCode = http.StatusOK
-	response = updateResponse
+	response.Id = int(blog.Id.Int32)
+	response.Title = blog.Title.String
+	response.Content = blog.Content.String
+	response.Category = blog.Category.String
+	response.Tags = strings.Split(blog.Tags.String, ",")
+	response.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	response.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
 	return
 }
 
-func (service *BlogServiceImplementation) Delete(ctx context.Context, idBlog int) (httpCode int, response interface{}) {
-	_, err := service.BlogRepository.FindById(service.PostgresUtil.GetPool(), ctx, idBlog)
-	if err != nil && err != pgx.ErrNoRows {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
+func (service *BlogServiceImplementation) FindAllPosts(ctx context.Context, term string) (response []modelresponses.FindResponse, err error) {
+	blogs, err := service.BlogRepository.FindAll(service.PostgresUtil.GetReadPool(ctx), ctx, term)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
-	} else if err == pgx.ErrNoRows {
-		httpCode = http.StatusNotFound
-		response = modelresponses.ToErrorResponse("not found")
+	}
+
+	for _, blog := range blogs {
+		var findResponse modelresponses.FindResponse
+		findResponse.Id = int(blog.Id.Int32)
+		findResponse.Title = blog.Title.String
+		findResponse.Content = blog.Content.String
+		findResponse.Category = blog.Category.String
+		findResponse.Tags = strings.Split(blog.Tags.String, ",")
+		findResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+		findResponse.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+		response = append(response, findResponse)
+	}
+	return
+}
+
+func (service *BlogServiceImplementation) FindBySlug(ctx context.Context, slug string) (response modelresponses.PostResponse, err error) {
+	blog, err := service.BlogRepository.FindBySlug(service.PostgresUtil.GetReadPool(ctx), ctx, slug)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	rowsAffected, err := service.BlogRepository.Delete(service.PostgresUtil.GetPool(), ctx, idBlog)
+	response = postResponse(blog)
+	return
+}
+
+// FindPostsByIds returns the posts of idBlogs that exist in one query ordered by id, it is the batch function of the graphql post loader
+func (service *BlogServiceImplementation) FindPostsByIds(ctx context.Context, idBlogs []int) (response []modelresponses.PostResponse, err error) {
+	blogs, err := service.BlogRepository.FindByIds(service.PostgresUtil.GetReadPool(ctx), ctx, idBlogs)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
+		return
+	}
+	for _, blog := range blogs {
+		response = append(response, postResponse(blog))
+	}
+	return
+}
+
+// FindPostsPage pages through the posts ordered by id, the cursor of a post is its id so a page does not shift when earlier posts are created or deleted,
+// one more post than requested is read to know whether there is a next page
+func (service *BlogServiceImplementation) FindPostsPage(ctx context.Context, findPageRequest modelrequests.FindPageRequest) (response modelresponses.PostPageResponse, err error) {
+	err = service.Validate.Struct(findPageRequest)
 	if err != nil {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
+		err = exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx))
 		return
 	}
-	if rowsAffected != 1 {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse("rows affected not one")
+	afterId := 0
+	if findPageRequest.After != "" {
+		afterId, err = decodeCursor(findPageRequest.After)
+		if err != nil {
+			err = exceptions.NewBadRequestError(exceptions.CodeInvalidCursor, "after is not a cursor of this api", err)
+			return
+		}
+	}
+	blogs, err := service.BlogRepository.FindAfter(service.PostgresUtil.GetReadPool(ctx), ctx, findPageRequest.Term, findPageRequest.Category, afterId, findPageRequest.First+1)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	httpCode = http.StatusNoContent
-	response = ""
+	if len(blogs) > findPageRequest.First {
+		blogs = blogs[:findPageRequest.First]
+		response.HasNextPage = true
+	}
+	response.Edges = []modelresponses.PostEdgeResponse{}
+	for _, blog := range blogs {
+		response.Edges = append(response.Edges, modelresponses.PostEdgeResponse{
+			Cursor: encodeCursor(int(blog.Id.Int32)),
+			Post:   postResponse(blog),
+		})
+	}
+	if len(response.Edges) > 0 {
+		response.EndCursor = response.Edges[len(response.Edges)-1].Cursor
+	}
 	return
 }
 
-func (service *BlogServiceImplementation) FindById(ctx context.Context, idBlog int) (httpCode int, response interface{}) {
-	blog, err := service.BlogRepository.FindById(service.PostgresUtil.GetPool(), ctx, idBlog)
-	if err != nil && err != pgx.ErrNoRows {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
+func (service *BlogServiceImplementation) FindCategories(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	categories, err := service.BlogRepository.FindCategories(service.PostgresUtil.GetReadPool(ctx), ctx)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
-	} else if err == pgx.ErrNoRows {
-		httpCode = http.StatusBadRequest
-		response = modelresponses.ToErrorResponse(err.Error())
+	}
+	return taxonomyResponses(categories), nil
+}
+
+func (service *BlogServiceImplementation) FindTags(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	tags, err := service.BlogRepository.FindTags(service.PostgresUtil.GetReadPool(ctx), ctx)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
 		return
 	}
-	var findByIdResponse modelresponses.FindByIdResponse
-	findByIdResponse.Id = int(blog.Id.Int32)
-	findByIdResponse.Title = blog.Title.String
-	findByIdResponse.Content = blog.Content.String
-	findByIdResponse.Category = blog.Category.String
-	findByIdResponse.Tags = strings.Split(blog.Tags.String, ",")
-	findByIdResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-	findByIdResponse.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-	httpCode = http.StatusOK
-	response = findByIdResponse
+	return taxonomyResponses(tags), nil
+}
+
+// FindCategoriesByNames returns the categories of names that have posts, it is the batch function of the graphql category loader
+func (service *BlogServiceImplementation) FindCategoriesByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	categories, err := service.BlogRepository.FindCategoriesByNames(service.PostgresUtil.GetReadPool(ctx), ctx, names)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
+		return
+	}
+	return taxonomyResponses(categories), nil
+}
+
+// FindTagsByNames returns the tags of names that have posts, it is the batch function of the graphql tag loader
+func (service *BlogServiceImplementation) FindTagsByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	tags, err := service.BlogRepository.FindTagsByNames(service.PostgresUtil.GetReadPool(ctx), ctx, names)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
+		return
+	}
+	return taxonomyResponses(tags), nil
+}
+
+// CountComments returns the number of comments of the posts of idBlogs that have any, it is the batch function of the graphql comment count loader
+func (service *BlogServiceImplementation) CountComments(ctx context.Context, idBlogs []int) (response map[int]int, err error) {
+	response, err = service.BlogRepository.CountComments(service.PostgresUtil.GetReadPool(ctx), ctx, idBlogs)
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
+		return
+	}
+	return
+}
+
+// postResponse trims the tags so they match the names FindTags returns
+func postResponse(blog modelentities.Blog) (response modelresponses.PostResponse) {
+	response.Id = int(blog.Id.Int32)
+	response.Slug = blog.Slug.String
+	response.Author = blog.Author.String
+	response.Title = blog.Title.String
+	response.Content = blog.Content.String
+	response.Category = blog.Category.String
+	response.Tags = TrimTags(strings.Split(blog.Tags.String, ","))
+	response.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	response.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	return
+}
+
+// TrimTags drops the spaces around tags and the empty tags, the v1 responses keep the stored tags as they are for the clients that predate it
+func TrimTags(tags []string) (trimmed []string) {
+	trimmed = []string{}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			trimmed = append(trimmed, tag)
+		}
+	}
+	return
+}
+
+func taxonomyResponses(taxonomies []modelentities.Taxonomy) (response []modelresponses.TaxonomyResponse) {
+	response = []modelresponses.TaxonomyResponse{}
+	for _, taxonomy := range taxonomies {
+		response = append(response, modelresponses.TaxonomyResponse{Name: taxonomy.Name.String, PostCount: int(taxonomy.Count.Int64)})
+	}
 	return
 }
 
-func (service *BlogServiceImplementation) FindAllPosts(ctx context.Context, term string) (httpCode int, response interface{}) {
-	blogs, err := service.BlogRepository.FindAll(service.PostgresUtil.GetPool(), ctx, term)
+// encodeCursor hides the id behind base64 so clients treat cursors as opaque and the keyset can change without breaking them
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("post:" + strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (id int, err error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		httpCode = http.StatusInternalServerError
-		response = modelresponses.ToErrorResponse(err.Error())
 		return
 	}
+	value, ok := strings.CutPrefix(string(decoded), "post:")
+	if !ok {
+		err = errors.New("cursor has no post: prefix")
+		return
+	}
+	return strconv.Atoi(value)
+}
 
-	var findAll []modelresponses.FindResponse
-	for _, blog := range blogs {
-		var findResponse modelresponses.FindResponse
-		findResponse.Id = int(blog.Id.Int32)
-		findResponse.Title = blog.Title.String
-		findResponse.Content = blog.Content.String
-		findResponse.Category = blog.Category.String
-		findResponse.Tags = strings.Split(blog.Tags.String, ",")
-		findResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-		findResponse.UpdatedAt = time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
-		findAll = append(findAll, findResponse)
+// recordEvents appends an event of every type with blog as it is now to the log in tx and notifies the event streams of every api instance
+// with the newest id, the notification is sent when tx commits, it is the last step of a write because appending holds the lock of the log until tx ends
+func (service *BlogServiceImplementation) recordEvents(tx pgx.Tx, ctx context.Context, blog modelentities.Blog, eventTypes ...string) (lastId int64, err error) {
+	payload, err := json.Marshal(postResponse(blog))
+	if err != nil {
+		return
+	}
+	for _, eventType := range eventTypes {
+		lastId, err = service.BlogRepository.CreateEvent(tx, ctx, modelentities.BlogEvent{
+			Type:      pgtype.Text{Valid: true, String: eventType},
+			PostId:    blog.Id,
+			Payload:   pgtype.Text{Valid: true, String: string(payload)},
+			CreatedAt: pgtype.Int8{Valid: true, Int64: time.Now().UnixMilli()},
+		})
+		if err != nil {
+			return
+		}
 	}
-	httpCode = http.StatusOK
-	response = findAll
+	err = service.PostgresUtil.Notify(tx, ctx, PostEventsChannel, strconv.FormatInt(lastId, 10))
 	return
 }
+
+// trimEvents keeps the newest EventLogSize events of the log, it runs as a statement of its own after the write committed so concurrent
+// repeatable read writes do not fail on deleting the same rows, a failed trim is only logged because the next write trims the same events
+func (service *BlogServiceImplementation) trimEvents(ctx context.Context, lastEventId int64) {
+	_, err := service.BlogRepository.DeleteEventsBefore(service.PostgresUtil.GetPool(), ctx, lastEventId-int64(service.EventLogSize)+1)
+	if err != nil {
+		slog.WarnContext(ctx, "post events: trimming the log failed", "last_event_id", lastEventId, "error", err)
+	}
+}
+
+// inTransaction is the unit of work of the service, callback runs in one transaction with isoLevel that is committed when callback returns nil and rolled back otherwise,
+// the whole transaction runs again with a new callback call after a serialization failure or deadlock
+func (service *BlogServiceImplementation) inTransaction(ctx context.Context, isoLevel pgx.TxIsoLevel, callback func(tx pgx.Tx) error) error {
+	return utils.Retry(ctx, service.RetryPolicy, utils.IsRetryableError, func() (err error) {
+		tx, err := service.PostgresUtil.BeginTx(ctx, pgx.TxOptions{IsoLevel: isoLevel})
+		if err != nil {
+			return
+		}
+		defer func() {
+			errCommitOrRollback := service.PostgresUtil.CommitOrRollback(tx, ctx, err)
+			if errCommitOrRollback != nil {
+				err = errCommitOrRollback
+			}
+		}()
+		err = callback(tx)
+		return
+	})
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/utils"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BlogTracedServiceImplementation starts a span for every operation of another BlogService, the queries of the operation become its children
+type BlogTracedServiceImplementation struct {
+	BlogService BlogService
+}
+
+func NewTracedBlogService(blogService BlogService) BlogService {
+	return &BlogTracedServiceImplementation{
+		BlogService: blogService,
+	}
+}
+
+func (service *BlogTracedServiceImplementation) Create(ctx context.Context, createRequest modelrequests.CreateRequest) (response modelresponses.CreateResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.Create")
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.Create(ctx, createRequest)
+}
+
+func (service *BlogTracedServiceImplementation) Update(ctx context.Context, idBlog int, updateRequest modelrequests.UpdateRequest) (response modelresponses.UpdateResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.Update")
+	span.SetAttributes(attribute.Int("blog.id", idBlog))
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.Update(ctx, idBlog, updateRequest)
+}
+
+func (service *BlogTracedServiceImplementation) Delete(ctx context.Context, idBlog int) (err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.Delete")
+	span.SetAttributes(attribute.Int("blog.id", idBlog))
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.Delete(ctx, idBlog)
+}
+
+func (service *BlogTracedServiceImplementation) FindById(ctx context.Context, idBlog int) (response modelresponses.FindByIdResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindById")
+	span.SetAttributes(attribute.Int("blog.id", idBlog))
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.FindById(ctx, idBlog)
+}
+
+func (service *BlogTracedServiceImplementation) FindAllPosts(ctx context.Context, term string) (response []modelresponses.FindResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindAllPosts")
+	span.SetAttributes(attribute.String("blog.term", term))
+	defer func() {
+		span.SetAttributes(attribute.Int("blog.count", len(response)))
+		endSpan(span, err)
+	}()
+	return service.BlogService.FindAllPosts(ctx, term)
+}
+
+func (service *BlogTracedServiceImplementation) FindBySlug(ctx context.Context, slug string) (response modelresponses.PostResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindBySlug")
+	span.SetAttributes(attribute.String("blog.slug", slug))
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.FindBySlug(ctx, slug)
+}
+
+func (service *BlogTracedServiceImplementation) FindPostsByIds(ctx context.Context, idBlogs []int) (response []modelresponses.PostResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindPostsByIds")
+	span.SetAttributes(attribute.IntSlice("blog.ids", idBlogs))
+	defer func() {
+		span.SetAttributes(attribute.Int("blog.count", len(response)))
+		endSpan(span, err)
+	}()
+	return service.BlogService.FindPostsByIds(ctx, idBlogs)
+}
+
+func (service *BlogTracedServiceImplementation) FindPostsPage(ctx context.Context, findPageRequest modelrequests.FindPageRequest) (response modelresponses.PostPageResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindPostsPage")
+	span.SetAttributes(attribute.String("blog.term", findPageRequest.Term), attribute.String("blog.category", findPageRequest.Category), attribute.Int("blog.first", findPageRequest.First))
+	defer func() {
+		span.SetAttributes(attribute.Int("blog.count", len(response.Edges)))
+		endSpan(span, err)
+	}()
+	return service.BlogService.FindPostsPage(ctx, findPageRequest)
+}
+
+func (service *BlogTracedServiceImplementation) FindCategories(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindCategories")
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.FindCategories(ctx)
+}
+
+func (service *BlogTracedServiceImplementation) FindTags(ctx context.Context) (response []modelresponses.TaxonomyResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindTags")
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.FindTags(ctx)
+}
+
+func (service *BlogTracedServiceImplementation) FindCategoriesByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindCategoriesByNames")
+	span.SetAttributes(attribute.StringSlice("blog.names", names))
+	defer func() {
+		span.SetAttributes(attribute.Int("blog.count", len(response)))
+		endSpan(span, err)
+	}()
+	return service.BlogService.FindCategoriesByNames(ctx, names)
+}
+
+func (service *BlogTracedServiceImplementation) FindTagsByNames(ctx context.Context, names []string) (response []modelresponses.TaxonomyResponse, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.FindTagsByNames")
+	span.SetAttributes(attribute.StringSlice("blog.names", names))
+	defer func() {
+		span.SetAttributes(attribute.Int("blog.count", len(response)))
+		endSpan(span, err)
+	}()
+	return service.BlogService.FindTagsByNames(ctx, names)
+}
+
+func (service *BlogTracedServiceImplementation) CountComments(ctx context.Context, idBlogs []int) (response map[int]int, err error) {
+	ctx, span := utils.Tracer().Start(ctx, "BlogService.CountComments")
+	span.SetAttributes(attribute.IntSlice("blog.ids", idBlogs))
+	defer func() { endSpan(span, err) }()
+	return service.BlogService.CountComments(ctx, idBlogs)
+}
+
+// endSpan marks only server errors as span errors, a post that is not found or a failed validation is a normal outcome of the operation
+func endSpan(span trace.Span, err error) {
+	if err == nil {
+		span.End()
+		return
+	}
+	kind := exceptions.AsAppError(err).Kind
+	span.SetAttributes(attribute.String("error.kind", kind.String()))
+	if kind == exceptions.KindInternal || kind == exceptions.KindUnavailable {
+		utils.EndSpan(span, err)
+		return
+	}
+	span.End()
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"archive/zip"
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ExportFormatJsonLines   = "jsonl"
+	ExportFormatCsv         = "csv"
+	ExportFormatMarkdownZip = "markdown-zip"
+)
+
+type ExportService interface {
+	Export(ctx context.Context, format string, term string, writer io.Writer) (err error)
+}
+
+type ExportServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	BlogRepository repositories.BlogRepository
+}
+
+func NewExportService(postgresUtil utils.PostgresUtil, blogRepository repositories.BlogRepository) ExportService {
+	return &ExportServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		BlogRepository: blogRepository,
+	}
+}
+
+// IsExportFormat reports whether format is one of the supported export formats
+func IsExportFormat(format string) bool {
+	return format == ExportFormatJsonLines || format == ExportFormatCsv || format == ExportFormatMarkdownZip
+}
+
+// Export streams every post matching term from a read replica to writer one row at a time, nothing is written when the format is unsupported,
+// errors are mapped like the errors of the blog service so an unavailable database is a 503 as long as nothing was written
+func (service *ExportServiceImplementation) Export(ctx context.Context, format string, term string, writer io.Writer) (err error) {
+	switch format {
+	case ExportFormatJsonLines:
+		err = service.exportJsonLines(ctx, term, writer)
+	case ExportFormatCsv:
+		err = service.exportCsv(ctx, term, writer)
+	case ExportFormatMarkdownZip:
+		err = service.exportMarkdownZip(ctx, term, writer)
+	default:
+		err = exceptions.NewBadRequestError(exceptions.CodeUnsupportedFormat, "unsupported export format, use jsonl, csv or markdown-zip", nil)
+		return
+	}
+	if err != nil {
+		err = exceptions.NewDatabaseError(err)
+	}
+	return
+}
+
+func (service *ExportServiceImplementation) exportJsonLines(ctx context.Context, term string, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	return service.BlogRepository.StreamAll(service.PostgresUtil.GetReadPool(ctx), ctx, term, 0, 0, func(blog modelentities.Blog) error {
+		var findResponse modelresponses.FindResponse
+		findResponse.Id = int(blog.Id.Int32)
+		findResponse.Title = blog.Title.String
+		findResponse.Content = blog.Content.String
+		findResponse.Category = blog.Category.String
+		findResponse.Tags = splitTags(blog.Tags.String)
+		findResponse.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+		findResponse.UpdatedAt = formatUpdatedAt(blog)
+		return encoder.Encode(findResponse)
+	})
+}
+
+func (service *ExportServiceImplementation) exportCsv(ctx context.Context, term string, writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write([]string{"id", "title", "content", "category", "tags", "createdAt", "updatedAt"})
+	if err != nil {
+		return err
+	}
+	err = service.BlogRepository.StreamAll(service.PostgresUtil.GetReadPool(ctx), ctx, term, 0, 0, func(blog modelentities.Blog) error {
+		err := csvWriter.Write([]string{
+			strconv.Itoa(int(blog.Id.Int32)),
+			blog.Title.String,
+			blog.Content.String,
+			blog.Category.String,
+			strings.Join(splitTags(blog.Tags.String), ","),
+			time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z"),
+			formatUpdatedAt(blog),
+		})
+		if err != nil {
+			return err
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// exportMarkdownZip writes one markdown file per post whose front matter matches the import format
+func (service *ExportServiceImplementation) exportMarkdownZip(ctx context.Context, term string, writer io.Writer) error {
+	zipWriter := zip.NewWriter(writer)
+	err := service.BlogRepository.StreamAll(service.PostgresUtil.GetReadPool(ctx), ctx, term, 0, 0, func(blog modelentities.Blog) error {
+		var frontMatter modelresponses.ExportFrontMatter
+		frontMatter.Title = blog.Title.String
+		frontMatter.Category = blog.Category.String
+		frontMatter.Tags = splitTags(blog.Tags.String)
+		frontMatter.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+		frontMatter.UpdatedAt = formatUpdatedAt(blog)
+		fileWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     "posts/" + strconv.Itoa(int(blog.Id.Int32)) + ".md",
+			Method:   zip.Deflate,
+			Modified: time.UnixMilli(blog.CreatedAt.Int64).UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return utils.WriteFrontMatter(fileWriter, frontMatter, blog.Content.String)
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+// splitTags splits the comma separated tags column and trims the space added when the tags were joined
+func splitTags(tags string) []string {
+	splitted := []string{}
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			splitted = append(splitted, tag)
+		}
+	}
+	return splitted
+}
+
+func formatUpdatedAt(blog modelentities.Blog) string {
+	if !blog.UpdatedAt.Valid {
+		return ""
+	}
+	return time.Unix(blog.UpdatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+}
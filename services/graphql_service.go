@@ -0,0 +1,568 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type GraphqlService interface {
+	Execute(ctx context.Context, graphqlRequest modelrequests.GraphqlRequest) (response modelresponses.GraphqlResponse)
+}
+
+// GraphqlServiceImplementation resolves the graphql schema of posts through BlogService, related data is loaded with one dataloader per request
+// so a page of posts costs one query for the categories, one for the tags and one for the comment counts instead of one per post,
+// operations whose complexity is above MaxComplexity are rejected before any resolver runs
+type GraphqlServiceImplementation struct {
+	BlogService   BlogService
+	MaxComplexity int
+	Schema        graphql.Schema
+}
+
+// graphqlLoaders are the dataloaders of one request, they cache what they loaded so they must not outlive it
+type graphqlLoaders struct {
+	posts      *utils.DataLoader[int, modelresponses.PostResponse]
+	categories *utils.DataLoader[string, modelresponses.TaxonomyResponse]
+	tags       *utils.DataLoader[string, modelresponses.TaxonomyResponse]
+	// commentCounts is keyed by post id, posts without comments are not found
+	commentCounts *utils.DataLoader[int, int]
+}
+
+type graphqlLoadersContextKey struct{}
+
+func NewGraphqlService(blogService BlogService, maxComplexity int) (GraphqlService, error) {
+	service := &GraphqlServiceImplementation{
+		BlogService:   blogService,
+		MaxComplexity: maxComplexity,
+	}
+	schema, err := service.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	service.Schema = schema
+	return service, nil
+}
+
+// Execute parses, validates and runs the operation, errors are returned in the errors member like every graphql server does,
+// errors of BlogService keep their message and carry their code and field errors in extensions
+func (service *GraphqlServiceImplementation) Execute(ctx context.Context, graphqlRequest modelrequests.GraphqlRequest) (response modelresponses.GraphqlResponse) {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(graphqlRequest.Query), Name: "GraphQL request"})})
+	if err != nil {
+		response.Errors = graphqlErrors(gqlerrors.FormatErrors(err))
+		return
+	}
+	validation := graphql.ValidateDocument(&service.Schema, document, nil)
+	if !validation.IsValid {
+		response.Errors = graphqlErrors(validation.Errors)
+		return
+	}
+	complexity := service.complexity(document, graphqlRequest.OperationName, graphqlRequest.Variables)
+	if complexity > service.MaxComplexity {
+		err = exceptions.NewBadRequestError(exceptions.CodeQueryTooComplex, fmt.Sprintf("query complexity %d is above the limit of %d", complexity, service.MaxComplexity), nil)
+		response.Errors = graphqlErrors(gqlerrors.FormatErrors(err))
+		return
+	}
+
+	ctx = context.WithValue(ctx, graphqlLoadersContextKey{}, service.newLoaders())
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        service.Schema,
+		AST:           document,
+		OperationName: graphqlRequest.OperationName,
+		Args:          graphqlRequest.Variables,
+		Context:       ctx,
+	})
+	response.Data = result.Data
+	response.Errors = graphqlErrors(result.Errors)
+	operation, _ := graphqlOperation(document, graphqlRequest.OperationName)
+	response.Mutation = operation != nil && operation.Operation == ast.OperationTypeMutation
+	return
+}
+
+func (service *GraphqlServiceImplementation) newLoaders() *graphqlLoaders {
+	return &graphqlLoaders{
+		posts: utils.NewDataLoader(func(ctx context.Context, ids []int) (values map[int]modelresponses.PostResponse, err error) {
+			posts, err := service.BlogService.FindPostsByIds(ctx, ids)
+			values = map[int]modelresponses.PostResponse{}
+			for _, post := range posts {
+				values[post.Id] = post
+			}
+			return
+		}),
+		categories: utils.NewDataLoader(func(ctx context.Context, names []string) (map[string]modelresponses.TaxonomyResponse, error) {
+			return taxonomiesByName(service.BlogService.FindCategoriesByNames(ctx, names))
+		}),
+		tags: utils.NewDataLoader(func(ctx context.Context, names []string) (map[string]modelresponses.TaxonomyResponse, error) {
+			return taxonomiesByName(service.BlogService.FindTagsByNames(ctx, names))
+		}),
+		commentCounts: utils.NewDataLoader(func(ctx context.Context, ids []int) (map[int]int, error) {
+			return service.BlogService.CountComments(ctx, ids)
+		}),
+	}
+}
+
+func loadersFromContext(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersContextKey{}).(*graphqlLoaders)
+}
+
+// taxonomiesByName keys the taxonomies loaded for the names of one batch by name
+func taxonomiesByName(taxonomies []modelresponses.TaxonomyResponse, err error) (values map[string]modelresponses.TaxonomyResponse, errTaxonomies error) {
+	values = map[string]modelresponses.TaxonomyResponse{}
+	for _, taxonomy := range taxonomies {
+		values[taxonomy.Name] = taxonomy
+	}
+	return values, err
+}
+
+func (service *GraphqlServiceImplementation) newSchema() (graphql.Schema, error) {
+	categoryType := taxonomyType("Category", "a category, every post has exactly one", func(loaders *graphqlLoaders) *utils.DataLoader[string, modelresponses.TaxonomyResponse] {
+		return loaders.categories
+	})
+	tagType := taxonomyType("Tag", "a tag, a post has up to 10", func(loaders *graphqlLoaders) *utils.DataLoader[string, modelresponses.TaxonomyResponse] {
+		return loaders.tags
+	})
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"slug": &graphql.Field{
+				Type:        graphql.String,
+				Description: "set for imported posts only",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if slug := p.Source.(modelresponses.PostResponse).Slug; slug != "" {
+						return slug, nil
+					}
+					return nil, nil
+				},
+			},
+			"author": &graphql.Field{
+				Type:        graphql.String,
+				Description: "set for posts imported from wordpress only",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if author := p.Source.(modelresponses.PostResponse).Author; author != "" {
+						return author, nil
+					}
+					return nil, nil
+				},
+			},
+			"title":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"content":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"category": &graphql.Field{Type: graphql.NewNonNull(categoryType)},
+			"tags":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType)))},
+			"commentCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "comments are imported from wordpress only",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thunk := loadersFromContext(p.Context).commentCounts.Load(p.Context, p.Source.(modelresponses.PostResponse).Id)
+					return func() (interface{}, error) {
+						count, _, err := thunk()
+						return count, err
+					}, nil
+				},
+			},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "utc time formatted as 2006-01-02T15:04:05Z"},
+			"updatedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "utc time formatted as 2006-01-02T15:04:05Z"},
+		},
+	})
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if endCursor := p.Source.(modelresponses.PostPageResponse).EndCursor; endCursor != "" {
+						return endCursor, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+	postEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(modelresponses.PostEdgeResponse).Post, nil
+				},
+			},
+		},
+	})
+	postConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostConnection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postEdgeType)))},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(pageInfoType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+	postFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PostFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"term":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "matches tags case insensitively like the term of /v1/posts"},
+			"category": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "matches the category exactly"},
+		},
+	})
+	postInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"category": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "the post with id or slug, null when there is none",
+				Args: graphql.FieldConfigArgument{
+					"id":   &graphql.ArgumentConfig{Type: graphql.ID},
+					"slug": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: service.resolvePost,
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(postConnectionType),
+				Description: "posts ordered by id, first is at most 100",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: postFilterType},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: service.resolvePosts,
+			},
+			"categories": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					categories, err := service.BlogService.FindCategories(p.Context)
+					return primeTaxonomies(loadersFromContext(p.Context).categories, categories, err)
+				},
+			},
+			"tags": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tags, err := service.BlogService.FindTags(p.Context)
+					return primeTaxonomies(loadersFromContext(p.Context).tags, tags, err)
+				},
+			},
+		},
+	})
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					response, err := service.BlogService.Create(p.Context, postInput(p.Args["input"]))
+					if err != nil {
+						return nil, err
+					}
+					return modelresponses.PostResponse{Id: response.Id, Title: response.Title, Content: response.Content, Category: response.Category,
+						Tags: TrimTags(response.Tags), CreatedAt: response.CreatedAt, UpdatedAt: response.UpdatedAt}, nil
+				},
+			},
+			"updatePost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphqlId(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					response, err := service.BlogService.Update(p.Context, id, modelrequests.UpdateRequest(postInput(p.Args["input"])))
+					if err != nil {
+						return nil, err
+					}
+					return modelresponses.PostResponse{Id: response.Id, Title: response.Title, Content: response.Content, Category: response.Category,
+						Tags: TrimTags(response.Tags), CreatedAt: response.CreatedAt, UpdatedAt: response.UpdatedAt}, nil
+				},
+			},
+			"deletePost": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "deletes the post and returns its id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphqlId(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					err = service.BlogService.Delete(p.Context, id)
+					if err != nil {
+						return nil, err
+					}
+					return id, nil
+				},
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+}
+
+// taxonomyType is resolved from the name of the category or tag, postCount is loaded through loader so it is only queried when it is selected
+func taxonomyType(name string, description string, loader func(loaders *graphqlLoaders) *utils.DataLoader[string, modelresponses.TaxonomyResponse]) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        name,
+		Description: description,
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+			"postCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thunk := loader(loadersFromContext(p.Context)).Load(p.Context, p.Source.(string))
+					return func() (interface{}, error) {
+						taxonomy, _, err := thunk()
+						return taxonomy.PostCount, err
+					}, nil
+				},
+			},
+		},
+	})
+}
+
+// primeTaxonomies hands the loaded taxonomies to loader so their postCount needs no second query
+func primeTaxonomies(loader *utils.DataLoader[string, modelresponses.TaxonomyResponse], taxonomies []modelresponses.TaxonomyResponse, err error) (names []string, errTaxonomies error) {
+	if err != nil {
+		return nil, err
+	}
+	names = []string{}
+	for _, taxonomy := range taxonomies {
+		loader.Prime(taxonomy.Name, taxonomy)
+		names = append(names, taxonomy.Name)
+	}
+	return
+}
+
+// resolvePost loads posts by id through the post loader so aliased post fields of one query are read in one batch
+func (service *GraphqlServiceImplementation) resolvePost(p graphql.ResolveParams) (interface{}, error) {
+	id, hasId := p.Args["id"]
+	slug, hasSlug := p.Args["slug"].(string)
+	if hasId == hasSlug {
+		return nil, exceptions.NewBadRequestError(exceptions.CodeBadRequest, "post needs either id or slug", nil)
+	}
+	if hasSlug {
+		post, err := service.BlogService.FindBySlug(p.Context, slug)
+		if exceptions.AsAppError(err).Code == exceptions.CodePostNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return post, nil
+	}
+	idBlog, err := graphqlId(id)
+	if err != nil {
+		return nil, err
+	}
+	thunk := loadersFromContext(p.Context).posts.Load(p.Context, idBlog)
+	return func() (interface{}, error) {
+		post, found, err := thunk()
+		if err != nil || !found {
+			return nil, err
+		}
+		return post, nil
+	}, nil
+}
+
+func (service *GraphqlServiceImplementation) resolvePosts(p graphql.ResolveParams) (interface{}, error) {
+	var findPageRequest modelrequests.FindPageRequest
+	if filter, ok := p.Args["filter"].(map[string]interface{}); ok {
+		findPageRequest.Term, _ = filter["term"].(string)
+		findPageRequest.Category, _ = filter["category"].(string)
+	}
+	findPageRequest.First, _ = p.Args["first"].(int)
+	findPageRequest.After, _ = p.Args["after"].(string)
+	return service.BlogService.FindPostsPage(p.Context, findPageRequest)
+}
+
+func postInput(value interface{}) (createRequest modelrequests.CreateRequest) {
+	input, _ := value.(map[string]interface{})
+	createRequest.Title, _ = input["title"].(string)
+	createRequest.Content, _ = input["content"].(string)
+	createRequest.Category, _ = input["category"].(string)
+	tags, _ := input["tags"].([]interface{})
+	createRequest.Tags = []string{}
+	for _, tag := range tags {
+		if tag, ok := tag.(string); ok {
+			createRequest.Tags = append(createRequest.Tags, tag)
+		}
+	}
+	return
+}
+
+// graphqlId parses an ID argument, ids of posts are integers like the id path parameter of /v1/posts/{id}
+func graphqlId(value interface{}) (id int, err error) {
+	id, err = strconv.Atoi(fmt.Sprint(value))
+	if err != nil || id < 1 || id > math.MaxInt32 {
+		err = exceptions.NewBadRequestError(exceptions.CodeInvalidId, "id must be a positive 32 bit integer", err)
+	}
+	return
+}
+
+// complexity is the cost of the operation that will run, every field costs 1 and the fields below a field with a first argument are counted first times,
+// an operation that does not exist costs 0 and is reported by Execute
+func (service *GraphqlServiceImplementation) complexity(document *ast.Document, operationName string, variables map[string]interface{}) int {
+	operation, fragments := graphqlOperation(document, operationName)
+	if operation == nil {
+		return 0
+	}
+	// variables that are not sent take the default of their definition
+	values := map[string]interface{}{}
+	for _, variable := range operation.VariableDefinitions {
+		if value, ok := variables[variable.Variable.Name.Value]; ok {
+			values[variable.Variable.Name.Value] = value
+		} else if variable.DefaultValue != nil {
+			values[variable.Variable.Name.Value] = variable.DefaultValue.GetValue()
+		}
+	}
+	root := service.Schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = service.Schema.MutationType()
+	}
+	return selectionComplexity(root, operation.SelectionSet, fragments, values)
+}
+
+// graphqlOperation is the operation of the document that runs for operationName with the fragments of the document, the operation is nil when there is none
+func graphqlOperation(document *ast.Document, operationName string) (operation *ast.OperationDefinition, fragments map[string]*ast.FragmentDefinition) {
+	fragments = map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		}
+	}
+	return
+}
+
+func selectionComplexity(parent *graphql.Object, selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, variables map[string]interface{}) (complexity int) {
+	if parent == nil || selectionSet == nil {
+		return
+	}
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			complexity++
+			definition, ok := parent.Fields()[selection.Name.Value]
+			if !ok {
+				// __typename and the introspection fields are bounded by the size of the schema
+				continue
+			}
+			child, _ := graphql.GetNamed(definition.Type).(*graphql.Object)
+			complexity += fieldMultiplier(definition, selection, variables) * selectionComplexity(child, selection.SelectionSet, fragments, variables)
+		case *ast.InlineFragment:
+			complexity += selectionComplexity(parent, selection.SelectionSet, fragments, variables)
+		case *ast.FragmentSpread:
+			// fragment cycles are rejected by the validation that runs before
+			if fragment, ok := fragments[selection.Name.Value]; ok {
+				complexity += selectionComplexity(parent, fragment.SelectionSet, fragments, variables)
+			}
+		}
+	}
+	return
+}
+
+// fieldMultiplier is the first argument of the field, its default when it is not set and 1 for fields without first
+func fieldMultiplier(definition *graphql.FieldDefinition, field *ast.Field, variables map[string]interface{}) int {
+	var value interface{}
+	for _, argument := range definition.Args {
+		if argument.Name() == "first" {
+			value = argument.DefaultValue
+		}
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch argumentValue := argument.Value.(type) {
+		case *ast.Variable:
+			value = variables[argumentValue.Name.Value]
+		default:
+			value = argumentValue.GetValue()
+		}
+	}
+	multiplier, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil || multiplier < 1 {
+		return 1
+	}
+	return multiplier
+}
+
+// graphqlErrors converts the errors of graphql-go, the message of an error of BlogService is replaced by the message of its AppError
+// so the internal cause is never sent, the cause of server errors is logged by the instrumented blog service
+func graphqlErrors(formattedErrors []gqlerrors.FormattedError) (response []modelresponses.GraphqlErrorResponse) {
+	for _, formattedError := range formattedErrors {
+		errorResponse := modelresponses.GraphqlErrorResponse{
+			Message: formattedError.Message,
+			Path:    formattedError.Path,
+		}
+		for _, location := range formattedError.Locations {
+			errorResponse.Locations = append(errorResponse.Locations, modelresponses.GraphqlLocationResponse{Line: location.Line, Column: location.Column})
+		}
+		if appError := appErrorOf(formattedError); appError != nil {
+			errorResponse.Message = appError.Message
+			errorResponse.Extensions = &modelresponses.GraphqlErrorExtensionsResponse{Code: appError.Code}
+			for _, field := range appError.Fields {
+				errorResponse.Extensions.Errors = append(errorResponse.Extensions.Errors, modelresponses.FieldErrorResponse(field))
+			}
+		}
+		response = append(response, errorResponse)
+	}
+	return
+}
+
+// appErrorOf finds the AppError a resolver returned, graphql-go wraps it in its own error types that do not implement Unwrap
+func appErrorOf(err error) *exceptions.AppError {
+	for err != nil {
+		var appError *exceptions.AppError
+		if errors.As(err, &appError) {
+			return appError
+		}
+		switch wrapped := err.(type) {
+		case gqlerrors.FormattedError:
+			err = wrapped.OriginalError()
+		case *gqlerrors.Error:
+			err = wrapped.OriginalError
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HealthStatusUp       = "up"
+	HealthStatusDown     = "down"
+	HealthStatusDraining = "draining"
+)
+
+// healthDetail is a check error whose message is safe to show on the unauthenticated health routes, any other error is only logged
+type healthDetail string
+
+func (detail healthDetail) Error() string {
+	return string(detail)
+}
+
+type HealthService interface {
+	Live() (response modelresponses.HealthResponse)
+	Ready(ctx context.Context) (response modelresponses.HealthResponse, ready bool)
+	Health(ctx context.Context) (response modelresponses.HealthResponse, healthy bool)
+	Drain()
+}
+
+// HealthServiceImplementation checks the dependencies of the api, every check gives up after CheckTimeout
+type HealthServiceImplementation struct {
+	PostgresUtil  utils.PostgresUtil
+	MigrationUtil utils.MigrationUtil
+	CheckTimeout  time.Duration
+	draining      atomic.Bool
+}
+
+func NewHealthService(postgresUtil utils.PostgresUtil, migrationUtil utils.MigrationUtil, checkTimeout time.Duration) HealthService {
+	return &HealthServiceImplementation{
+		PostgresUtil:  postgresUtil,
+		MigrationUtil: migrationUtil,
+		CheckTimeout:  checkTimeout,
+	}
+}
+
+// Live only tells the process is running, it never checks dependencies so a database outage does not get the process restarted
+func (service *HealthServiceImplementation) Live() (response modelresponses.HealthResponse) {
+	return modelresponses.HealthResponse{Status: HealthStatusUp}
+}
+
+// Ready is false while draining without checking anything, otherwise the database must answer and every migration must be applied
+func (service *HealthServiceImplementation) Ready(ctx context.Context) (response modelresponses.HealthResponse, ready bool) {
+	if service.draining.Load() {
+		return modelresponses.HealthResponse{Status: HealthStatusDraining}, false
+	}
+	return service.Health(ctx)
+}
+
+// Health runs every check and reports each with its latency, the status is draining during shutdown even when every check is up
+func (service *HealthServiceImplementation) Health(ctx context.Context) (response modelresponses.HealthResponse, healthy bool) {
+	response = modelresponses.HealthResponse{
+		Status: HealthStatusUp,
+		Checks: map[string]modelresponses.HealthCheckResponse{
+			"database":   service.check(ctx, "database", service.checkDatabase),
+			"migrations": service.check(ctx, "migrations", service.checkMigrations),
+		},
+	}
+	for _, check := range response.Checks {
+		if check.Status != HealthStatusUp {
+			response.Status = HealthStatusDown
+		}
+	}
+	if response.Status == HealthStatusUp && service.draining.Load() {
+		response.Status = HealthStatusDraining
+	}
+	return response, response.Status == HealthStatusUp
+}
+
+// Drain makes readiness fail from now on so the load balancer stops sending requests before the server shuts down
+func (service *HealthServiceImplementation) Drain() {
+	service.draining.Store(true)
+}
+
+// check logs why a check failed, the response only has a healthDetail or a generic reason since driver errors name hosts and users
+func (service *HealthServiceImplementation) check(ctx context.Context, name string, checker func(ctx context.Context) error) (response modelresponses.HealthCheckResponse) {
+	checkCtx, cancel := context.WithTimeout(ctx, service.CheckTimeout)
+	defer cancel()
+	start := time.Now()
+	err := checker(checkCtx)
+	response.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Status = HealthStatusUp
+	if err == nil {
+		return
+	}
+	response.Status = HealthStatusDown
+	var detail healthDetail
+	switch {
+	case errors.As(err, &detail):
+		response.Detail = detail.Error()
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Detail = "timed out"
+	default:
+		response.Detail = "unavailable"
+	}
+	slog.WarnContext(ctx, "health check failed", "check", name, "error", err)
+	return
+}
+
+func (service *HealthServiceImplementation) checkDatabase(ctx context.Context) error {
+	return service.PostgresUtil.Ping(ctx)
+}
+
+func (service *HealthServiceImplementation) checkMigrations(ctx context.Context) error {
+	pending, err := service.MigrationUtil.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return healthDetail(strconv.Itoa(pending) + " migrations pending")
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ImportFormatJsonLines = "jsonl"
+	ImportFormatZip       = "zip"
+	ImportFormatTar       = "tar"
+	ImportFormatTarGzip   = "tar.gz"
+)
+
+const importBatchSize = 1000
+
+type ImportService interface {
+	Import(ctx context.Context, format string, reader io.Reader) (response modelresponses.ImportResponse, err error)
+}
+
+// ImportServiceImplementation reads at most MaxSize bytes of an import, the markdown files of an archive count with their decompressed size,
+// and no json line or markdown file may be larger than MaxEntrySize
+type ImportServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	Validate       *validator.Validate
+	BlogRepository repositories.BlogRepository
+	MaxSize        int64
+	MaxEntrySize   int64
+}
+
+func NewImportService(postgresUtil utils.PostgresUtil, validate *validator.Validate, blogRepository repositories.BlogRepository, maxSize int64, maxEntrySize int64) ImportService {
+	return &ImportServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		Validate:       validate,
+		BlogRepository: blogRepository,
+		MaxSize:        maxSize,
+		MaxEntrySize:   maxEntrySize,
+	}
+}
+
+type importRecord struct {
+	source  string
+	request modelrequests.ImportRequest
+	err     error
+}
+
+// ImportFormatFromName guesses the import format from a file name, it returns an empty string when the extension is unknown
+func ImportFormatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".ndjson"):
+		return ImportFormatJsonLines
+	case strings.HasSuffix(name, ".zip"):
+		return ImportFormatZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ImportFormatTarGzip
+	case strings.HasSuffix(name, ".tar"):
+		return ImportFormatTar
+	}
+	return ""
+}
+
+// ImportFormatFromContentType guesses the import format from a request content type, it returns an empty string when the type is unknown
+func ImportFormatFromContentType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(contentType)) {
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return ImportFormatJsonLines
+	case "application/zip":
+		return ImportFormatZip
+	case "application/x-tar":
+		return ImportFormatTar
+	case "application/gzip", "application/x-gzip":
+		return ImportFormatTarGzip
+	}
+	return ""
+}
+
+// Import validates every record and inserts the valid ones in batches inside one transaction, the response reports the result of every record,
+// imported posts record no post events because the batches are copied without returning ids, event streams see them after reloading the posts
+func (service *ImportServiceImplementation) Import(ctx context.Context, format string, reader io.Reader) (response modelresponses.ImportResponse, err error) {
+	var records []importRecord
+	switch format {
+	case ImportFormatJsonLines:
+		records, err = service.readJsonLines(reader)
+	case ImportFormatZip:
+		records, err = service.readZip(reader)
+	case ImportFormatTar:
+		records, err = service.readTar(reader)
+	case ImportFormatTarGzip:
+		var gzipReader *gzip.Reader
+		gzipReader, err = gzip.NewReader(reader)
+		if err == nil {
+			defer gzipReader.Close()
+			records, err = service.readTar(gzipReader)
+		}
+	default:
+		err = exceptions.NewBadRequestError(exceptions.CodeUnsupportedFormat, "unsupported import format, use jsonl, zip, tar or tar.gz", nil)
+		return
+	}
+	var appError *exceptions.AppError
+	if err != nil && !errors.As(err, &appError) {
+		err = exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "import file can not be read: "+err.Error(), err)
+	}
+	if err != nil {
+		return
+	}
+
+	var importResponse modelresponses.ImportResponse
+	importResponse.Total = len(records)
+	importResponse.Records = make([]modelresponses.ImportRecordResponse, len(records))
+	var blogs []modelentities.Blog
+	var blogIndexes []int
+	for i, record := range records {
+		importResponse.Records[i] = modelresponses.ImportRecordResponse{Index: i + 1, Source: record.source}
+		err = record.err
+		if err == nil {
+			err = service.Validate.Struct(record.request)
+		}
+		var blog modelentities.Blog
+		if err == nil {
+			blog, err = service.toBlog(record.request)
+		}
+		if err != nil {
+			appError := exceptions.AsAppError(exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx)))
+			importResponse.Records[i].Message = appError.Message
+			for _, field := range appError.Fields {
+				importResponse.Records[i].Errors = append(importResponse.Records[i].Errors, modelresponses.FieldErrorResponse(field))
+			}
+			importResponse.Failed++
+			continue
+		}
+		blogs = append(blogs, blog)
+		blogIndexes = append(blogIndexes, i)
+	}
+
+	if len(blogs) > 0 {
+		err = service.createBatches(ctx, blogs)
+		if err != nil {
+			err = exceptions.NewDatabaseError(err)
+			return
+		}
+	}
+	for _, i := range blogIndexes {
+		importResponse.Records[i].Success = true
+	}
+	importResponse.Imported = len(blogIndexes)
+	response = importResponse
+	return
+}
+
+func (service *ImportServiceImplementation) createBatches(ctx context.Context, blogs []modelentities.Blog) (err error) {
+	tx, err := service.PostgresUtil.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return
+	}
+	defer func() {
+		errCommitOrRollback := service.PostgresUtil.CommitOrRollback(tx, ctx, err)
+		if errCommitOrRollback != nil {
+			err = errCommitOrRollback
+		}
+	}()
+	for start := 0; start < len(blogs); start += importBatchSize {
+		end := min(start+importBatchSize, len(blogs))
+		_, err = service.BlogRepository.CreateBatch(tx, ctx, blogs[start:end])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (service *ImportServiceImplementation) toBlog(importRequest modelrequests.ImportRequest) (blog modelentities.Blog, err error) {
+	createdAt := time.Now()
+	if importRequest.CreatedAt != "" {
+		createdAt, err = time.Parse(time.RFC3339, importRequest.CreatedAt)
+		if err != nil {
+			return
+		}
+	}
+	updatedAt := createdAt
+	if importRequest.UpdatedAt != "" {
+		updatedAt, err = time.Parse(time.RFC3339, importRequest.UpdatedAt)
+		if err != nil {
+			return
+		}
+	}
+	blog.Title = pgtype.Text{Valid: true, String: importRequest.Title}
+	blog.Content = pgtype.Text{Valid: true, String: importRequest.Content}
+	blog.Category = pgtype.Text{Valid: true, String: importRequest.Category}
+	blog.Tags = pgtype.Text{Valid: true, String: strings.Join(importRequest.Tags, ", ")}
+	blog.CreatedAt = pgtype.Int8{Valid: true, Int64: createdAt.UnixMilli()}
+	blog.UpdatedAt = pgtype.Int8{Valid: true, Int64: updatedAt.UnixMilli()}
+	return
+}
+
+func (service *ImportServiceImplementation) readJsonLines(reader io.Reader) (records []importRecord, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, min(64*1024, service.MaxEntrySize)), int(service.MaxEntrySize))
+	remaining := service.MaxSize
+	line := 0
+	for scanner.Scan() {
+		line++
+		remaining -= int64(len(scanner.Bytes())) + 1
+		if remaining < 0 {
+			err = tooLargeError("import", service.MaxSize)
+			return
+		}
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		record := importRecord{source: "line " + strconv.Itoa(line)}
+		record.err = json.Unmarshal(text, &record.request)
+		records = append(records, record)
+	}
+	err = scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		err = tooLargeError("line "+strconv.Itoa(line+1), service.MaxEntrySize)
+	}
+	return
+}
+
+func (service *ImportServiceImplementation) readZip(reader io.Reader) (records []importRecord, err error) {
+	readerAt, size, cleanup, err := service.spool(reader)
+	defer cleanup()
+	if err != nil {
+		return
+	}
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return
+	}
+	remaining := service.MaxSize
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() || !isMarkdownFile(file.Name) {
+			continue
+		}
+		var fileReader io.ReadCloser
+		fileReader, err = file.Open()
+		if err != nil {
+			return
+		}
+		var content []byte
+		content, err = service.readEntry(fileReader, file.Name, &remaining)
+		fileReader.Close()
+		if err != nil {
+			return
+		}
+		records = append(records, readMarkdown(file.Name, content))
+	}
+	return
+}
+
+// spool gives the zip reader the random access it needs, an uploaded file is read in place and a request body is copied to a temporary file
+// instead of memory, cleanup removes that file
+func (service *ImportServiceImplementation) spool(reader io.Reader) (readerAt io.ReaderAt, size int64, cleanup func(), err error) {
+	cleanup = func() {}
+	if file, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err = file.Seek(0, io.SeekEnd)
+		if err == nil && size > service.MaxSize {
+			err = tooLargeError("import", service.MaxSize)
+		}
+		return file, size, cleanup, err
+	}
+	file, err := os.CreateTemp("", "import-*.zip")
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	cleanup = func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	size, err = io.Copy(file, io.LimitReader(reader, service.MaxSize+1))
+	if err == nil && size > service.MaxSize {
+		err = tooLargeError("import", service.MaxSize)
+	}
+	return file, size, cleanup, err
+}
+
+func (service *ImportServiceImplementation) readTar(reader io.Reader) (records []importRecord, err error) {
+	tarReader := tar.NewReader(reader)
+	remaining := service.MaxSize
+	for {
+		var header *tar.Header
+		header, err = tarReader.Next()
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+		if header.Typeflag != tar.TypeReg || !isMarkdownFile(header.Name) {
+			continue
+		}
+		var content []byte
+		content, err = service.readEntry(tarReader, header.Name, &remaining)
+		if err != nil {
+			return
+		}
+		records = append(records, readMarkdown(header.Name, content))
+	}
+}
+
+// readEntry reads a markdown file of an archive without trusting the size in its header, the file is subtracted from remaining
+func (service *ImportServiceImplementation) readEntry(reader io.Reader, name string, remaining *int64) (content []byte, err error) {
+	content, err = io.ReadAll(io.LimitReader(reader, service.MaxEntrySize+1))
+	if err != nil {
+		return
+	}
+	if int64(len(content)) > service.MaxEntrySize {
+		err = tooLargeError(name, service.MaxEntrySize)
+		return
+	}
+	*remaining -= int64(len(content))
+	if *remaining < 0 {
+		err = tooLargeError("decompressed import", service.MaxSize)
+	}
+	return
+}
+
+func tooLargeError(name string, limit int64) error {
+	return exceptions.NewTooLargeError(name+" is larger than "+strconv.FormatInt(limit, 10)+" bytes", nil)
+}
+
+func isMarkdownFile(name string) bool {
+	extension := strings.ToLower(path.Ext(name))
+	return extension == ".md" || extension == ".markdown"
+}
+
+func readMarkdown(name string, content []byte) (record importRecord) {
+	record.source = name
+	body, err := utils.ParseFrontMatter(content, &record.request)
+	if err != nil {
+		record.err = err
+		return
+	}
+	record.request.Content = body
+	return
+}
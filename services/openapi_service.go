@@ -0,0 +1,373 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const OpenApiVersion = "3.1.0"
+
+type OpenApiService interface {
+	Document() modelresponses.OpenApiResponse
+	// Json is the document encoded once so every request gets the same bytes
+	Json() []byte
+	// Operation finds the operation of a route, path is the echo route like /posts/:id
+	Operation(method string, path string) (operation modelresponses.OpenApiOperationResponse, ok bool)
+	// Validate checks a decoded json value against schema and returns every failed rule, messages are translated with the translator of ctx
+	Validate(ctx context.Context, schema *modelresponses.OpenApiSchema, value any, field string) (fields []exceptions.FieldError)
+}
+
+type OpenApiServiceImplementation struct {
+	document   modelresponses.OpenApiResponse
+	json       []byte
+	operations map[string]modelresponses.OpenApiOperationResponse
+}
+
+// openApiOperation describes one route of the v1 routes, path uses the echo syntax so it can be compared with the registered routes
+type openApiOperation struct {
+	method      string
+	path        string
+	operationId string
+	summary     string
+	parameters  []modelresponses.OpenApiParameterResponse
+	request     any
+	// upload are the media types of a file sent as request body, the file can also be sent in the file field of a multipart form
+	upload   []string
+	status   int
+	response any
+	// stream are the media types of a success response that is streamed instead of a json document, its schema is a string
+	stream []string
+	errors []int
+}
+
+var blogOperations = []openApiOperation{
+	{
+		method: http.MethodPost, path: "/posts", operationId: "createPost", summary: "create a post",
+		request: modelrequests.CreateRequest{}, status: http.StatusCreated, response: modelresponses.CreateResponse{},
+		errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: http.MethodPut, path: "/posts/:id", operationId: "updatePost", summary: "replace the title, content, category and tags of a post",
+		request: modelrequests.UpdateRequest{}, status: http.StatusOK, response: modelresponses.UpdateResponse{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: http.MethodDelete, path: "/posts/:id", operationId: "deletePost", summary: "delete a post",
+		status: http.StatusNoContent, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/posts/:id", operationId: "findPostById", summary: "find a post by its id",
+		status: http.StatusOK, response: modelresponses.FindByIdResponse{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: http.MethodGet, path: "/posts", operationId: "findPosts", summary: "list posts",
+		parameters: []modelresponses.OpenApiParameterResponse{
+			{Name: "term", In: "query", Description: "only posts with a tag containing term, case insensitive", Schema: &modelresponses.OpenApiSchema{Type: "string"}},
+		},
+		status: http.StatusOK, response: []modelresponses.FindResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/posts/events", operationId: "streamPostEvents",
+		summary: "stream created, updated, deleted and published events of posts as server sent events, Last-Event-ID resumes after an event, a reset event means missed events are gone and posts have to be reloaded",
+		parameters: []modelresponses.OpenApiParameterResponse{
+			{Name: "Last-Event-ID", In: "header", Description: "id of the last event the client got", Schema: &modelresponses.OpenApiSchema{Type: "integer"}},
+		},
+		status: http.StatusOK, stream: []string{"text/event-stream"}, errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: http.MethodPost, path: "/posts/import", operationId: "importPosts",
+		summary: "import posts from json lines or from markdown files with front matter in a zip or tar archive, valid posts are inserted and the result of every record is reported",
+		parameters: []modelresponses.OpenApiParameterResponse{
+			{
+				Name: "format", In: "query", Description: "format of the file, by default it is detected from the content type or the name of the uploaded file",
+				Schema: &modelresponses.OpenApiSchema{Type: "string", Enum: []string{ImportFormatJsonLines, ImportFormatZip, ImportFormatTar, ImportFormatTarGzip}},
+			},
+		},
+		upload: []string{"application/jsonl", "application/x-ndjson", "application/zip", "application/x-tar", "application/gzip"},
+		status: http.StatusOK, response: modelresponses.ImportResponse{}, errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	},
+	{
+		method: http.MethodPost, path: "/posts/import/wordpress", operationId: "importWordpressPosts",
+		summary: "import the published posts and approved comments of a wordpress export file, posts are matched by their slug so an import can be repeated",
+		upload:  []string{"application/xml", "text/xml"},
+		status:  http.StatusOK, response: modelresponses.WordpressImportResponse{}, errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	},
+	{
+		method: http.MethodGet, path: "/posts/export", operationId: "exportPosts", summary: "export posts as a file, the posts are streamed so a failure after the first post ends the file early",
+		parameters: []modelresponses.OpenApiParameterResponse{
+			{
+				Name: "format", In: "query", Description: "format of the file, jsonl by default",
+				Schema: &modelresponses.OpenApiSchema{Type: "string", Enum: []string{ExportFormatJsonLines, ExportFormatCsv, ExportFormatMarkdownZip}},
+			},
+			{Name: "term", In: "query", Description: "only posts with a tag containing term, case insensitive", Schema: &modelresponses.OpenApiSchema{Type: "string"}},
+		},
+		status: http.StatusOK, stream: []string{"application/jsonl", "text/csv", "application/zip"}, errors: []int{http.StatusBadRequest},
+	},
+}
+
+// openApiVersion is an api version mounted under prefix, a new version lists its own operations with its own response types,
+// rootAlias documents the operations at the root too as deprecated for the clients that predate versioning
+type openApiVersion struct {
+	prefix     string
+	operations []openApiOperation
+	rootAlias  bool
+}
+
+var apiVersions = []openApiVersion{
+	{prefix: "/v1", operations: blogOperations, rootAlias: true},
+}
+
+var echoPathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// NewOpenApiService describes every route of the v1 routes in every api version, the schemas are generated from the request and response types
+// and their validate tags so they cannot disagree with the validation of the service, baseUrl is listed as server when it is set
+func NewOpenApiService(baseUrl string) OpenApiService {
+	service := &OpenApiServiceImplementation{operations: map[string]modelresponses.OpenApiOperationResponse{}}
+	components := map[string]*modelresponses.OpenApiSchema{}
+	document := modelresponses.OpenApiResponse{
+		Openapi: OpenApiVersion,
+		Info: modelresponses.OpenApiInfoResponse{
+			Title:       "Blogging Platform API",
+			Version:     "1.0.0",
+			Description: "errors are returned as application/problem+json (RFC 7807), code is stable and can be used by clients",
+		},
+		Paths:      map[string]modelresponses.OpenApiPathItem{},
+		Components: modelresponses.OpenApiComponentsResponse{Schemas: components},
+	}
+	if baseUrl != "" {
+		document.Servers = []modelresponses.OpenApiServerResponse{{Url: baseUrl}}
+	}
+	service.document = document
+	problem := schemaOf(reflect.TypeOf(modelresponses.ProblemResponse{}), "", components)
+	for _, version := range apiVersions {
+		for _, operation := range version.operations {
+			result := operationOf(operation, problem, components)
+			service.addOperation(operation.method, version.prefix+operation.path, result)
+			if version.rootAlias {
+				result.OperationId += "Unversioned"
+				result.Summary += ", deprecated, use " + version.prefix + operation.path
+				result.Deprecated = true
+				service.addOperation(operation.method, operation.path, result)
+			}
+		}
+	}
+	service.json, _ = json.Marshal(service.document)
+	return service
+}
+
+func (service *OpenApiServiceImplementation) addOperation(method string, path string, operation modelresponses.OpenApiOperationResponse) {
+	openApiPath := echoPathParam.ReplaceAllString(path, "{$1}")
+	item, ok := service.document.Paths[openApiPath]
+	if !ok {
+		item = modelresponses.OpenApiPathItem{}
+		service.document.Paths[openApiPath] = item
+	}
+	item[strings.ToLower(method)] = operation
+	service.operations[method+" "+path] = operation
+}
+
+func (service *OpenApiServiceImplementation) Document() modelresponses.OpenApiResponse {
+	return service.document
+}
+
+func (service *OpenApiServiceImplementation) Json() []byte {
+	return service.json
+}
+
+func (service *OpenApiServiceImplementation) Operation(method string, path string) (operation modelresponses.OpenApiOperationResponse, ok bool) {
+	operation, ok = service.operations[method+" "+path]
+	return
+}
+
+func operationOf(operation openApiOperation, problem *modelresponses.OpenApiSchema, components map[string]*modelresponses.OpenApiSchema) (result modelresponses.OpenApiOperationResponse) {
+	result = modelresponses.OpenApiOperationResponse{
+		OperationId: operation.operationId,
+		Summary:     operation.summary,
+		Tags:        []string{"posts"},
+		Responses:   map[string]modelresponses.OpenApiOperationResultResponse{},
+	}
+	for _, match := range echoPathParam.FindAllStringSubmatch(operation.path, -1) {
+		result.Parameters = append(result.Parameters, modelresponses.OpenApiParameterResponse{
+			Name: match[1], In: "path", Required: true, Schema: idSchema(),
+		})
+	}
+	result.Parameters = append(result.Parameters, operation.parameters...)
+	if operation.request != nil {
+		result.RequestBody = &modelresponses.OpenApiRequestBodyResponse{
+			Required: true,
+			Content: map[string]modelresponses.OpenApiMediaTypeResponse{
+				"application/json": {Schema: schemaOf(reflect.TypeOf(operation.request), "", components)},
+			},
+		}
+	}
+	if len(operation.upload) > 0 {
+		file := &modelresponses.OpenApiSchema{Type: "string", Format: "binary"}
+		result.RequestBody = &modelresponses.OpenApiRequestBodyResponse{
+			Required: true,
+			Content: map[string]modelresponses.OpenApiMediaTypeResponse{
+				"multipart/form-data": {Schema: &modelresponses.OpenApiSchema{Type: "object", Properties: map[string]*modelresponses.OpenApiSchema{"file": file}, Required: []string{"file"}}},
+			},
+		}
+		for _, mediaType := range operation.upload {
+			result.RequestBody.Content[mediaType] = modelresponses.OpenApiMediaTypeResponse{Schema: file}
+		}
+	}
+	success := modelresponses.OpenApiOperationResultResponse{Description: http.StatusText(operation.status)}
+	if len(operation.stream) > 0 {
+		success.Content = map[string]modelresponses.OpenApiMediaTypeResponse{}
+		for _, mediaType := range operation.stream {
+			success.Content[mediaType] = modelresponses.OpenApiMediaTypeResponse{Schema: &modelresponses.OpenApiSchema{Type: "string"}}
+		}
+	}
+	if operation.response != nil {
+		success.Content = map[string]modelresponses.OpenApiMediaTypeResponse{
+			"application/json": {Schema: schemaOf(reflect.TypeOf(operation.response), "", components)},
+		}
+	}
+	result.Responses[strconv.Itoa(operation.status)] = success
+	// every operation can fail with an internal error and with service_unavailable while the database is down
+	for _, status := range append(operation.errors, http.StatusInternalServerError, http.StatusServiceUnavailable) {
+		result.Responses[strconv.Itoa(status)] = modelresponses.OpenApiOperationResultResponse{
+			Description: http.StatusText(status),
+			Content: map[string]modelresponses.OpenApiMediaTypeResponse{
+				"application/problem+json": {Schema: problem},
+			},
+		}
+	}
+	return
+}
+
+// schemaOf is the schema of a value of type t checked with the validate rules in rules, structs are added to components once and referenced,
+// a struct field is required when its validate tag has required or, for types without validate tags like responses, when it is never omitted from the json
+func schemaOf(t reflect.Type, rules string, components map[string]*modelresponses.OpenApiSchema) (schema *modelresponses.OpenApiSchema) {
+	outer, inner, _ := strings.Cut(rules, ",dive")
+	inner = strings.TrimPrefix(inner, ",")
+	schema = &modelresponses.OpenApiSchema{}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), rules, components)
+	case reflect.String:
+		schema.Type = "string"
+	case reflect.Bool:
+		schema.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema.Type = "number"
+	case reflect.Slice, reflect.Array:
+		schema.Type = "array"
+		schema.Items = schemaOf(t.Elem(), inner, components)
+	case reflect.Map:
+		schema.Type = "object"
+		schema.AdditionalProperties = schemaOf(t.Elem(), inner, components)
+	case reflect.Struct:
+		if _, ok := components[t.Name()]; !ok {
+			// the placeholder stops types that contain themselves from recursing forever
+			components[t.Name()] = nil
+			components[t.Name()] = structSchemaOf(t, components)
+		}
+		return &modelresponses.OpenApiSchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	applyRules(schema, outer)
+	return
+}
+
+func structSchemaOf(t reflect.Type, components map[string]*modelresponses.OpenApiSchema) (schema *modelresponses.OpenApiSchema) {
+	schema = &modelresponses.OpenApiSchema{Type: "object", Properties: map[string]*modelresponses.OpenApiSchema{}}
+	validated := false
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("validate"); ok {
+			validated = true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		rules := field.Tag.Get("validate")
+		schema.Properties[name] = schemaOf(field.Type, rules, components)
+		outer, _, _ := strings.Cut(rules, ",dive")
+		required := hasRule(outer, "required")
+		if !validated {
+			required = !strings.Contains(options, "omitempty")
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return
+}
+
+func applyRules(schema *modelresponses.OpenApiSchema, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if schema.Type == "string" {
+				schema.MinLength = intPointer(1)
+			}
+		case "min", "max":
+			limit, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case schema.Type == "string" && name == "min":
+				schema.MinLength = intPointer(limit)
+			case schema.Type == "string":
+				schema.MaxLength = intPointer(limit)
+			case schema.Type == "array" && name == "min":
+				schema.MinItems = intPointer(limit)
+			case schema.Type == "array":
+				schema.MaxItems = intPointer(limit)
+			case name == "min":
+				minimum := float64(limit)
+				schema.Minimum = &minimum
+			default:
+				maximum := float64(limit)
+				schema.Maximum = &maximum
+			}
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "datetime":
+			schema.Format = "date-time"
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		}
+	}
+}
+
+func hasRule(rules string, name string) bool {
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// idSchema is the schema of the id path parameter, ids are int4 in the database and start at 1
+func idSchema() *modelresponses.OpenApiSchema {
+	minimum, maximum := float64(1), float64(math.MaxInt32)
+	return &modelresponses.OpenApiSchema{Type: "integer", Format: "int32", Minimum: &minimum, Maximum: &maximum}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
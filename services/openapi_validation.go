@@ -0,0 +1,151 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Validate expects value to be decoded with json.Decoder.UseNumber so integers can be told apart from other numbers,
+// nested fields are named like tags[2] and a rule of a value is only checked when the value has the type of the schema
+func (service *OpenApiServiceImplementation) Validate(ctx context.Context, schema *modelresponses.OpenApiSchema, value any, field string) (fields []exceptions.FieldError) {
+	translator := utils.TranslatorFromContext(ctx)
+	return service.validate(translator, schema, value, field, fields)
+}
+
+func (service *OpenApiServiceImplementation) validate(translator ut.Translator, schema *modelresponses.OpenApiSchema, value any, field string, fields []exceptions.FieldError) []exceptions.FieldError {
+	if schema == nil {
+		return fields
+	}
+	if schema.Ref != "" {
+		return service.validate(translator, service.document.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, field, fields)
+	}
+	fail := func(rule string, key string, param string) {
+		fields = append(fields, exceptions.FieldError{Field: field, Rule: rule, Param: param, Message: utils.SchemaMessage(translator, key, field, param)})
+	}
+
+	switch schema.Type {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail("type", "type", schema.Type)
+			return fields
+		}
+		length := utf8.RuneCountInString(text)
+		// the generated schema turns the required rule of a string into a minimum length of 1
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				fail("required", "required", "")
+			} else {
+				fail("min", "min-string", strconv.Itoa(*schema.MinLength))
+			}
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("max", "max-string", strconv.Itoa(*schema.MaxLength))
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, text) {
+			fail("oneof", "oneof", strings.Join(schema.Enum, " "))
+		}
+		if schema.Format == "date-time" && text != "" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				fail("datetime", "datetime", time.RFC3339)
+			}
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			fail("type", "type", schema.Type)
+			return fields
+		}
+		parsed, err := number.Float64()
+		if schema.Type == "integer" {
+			var integer int64
+			integer, err = number.Int64()
+			if err == nil && schema.Format == "int32" && integer != int64(int32(integer)) {
+				fail("type", "type", schema.Format)
+				return fields
+			}
+		}
+		if err != nil {
+			fail("type", "type", schema.Type)
+			return fields
+		}
+		if schema.Minimum != nil && parsed < *schema.Minimum {
+			fail("min", "min-number", strconv.FormatFloat(*schema.Minimum, 'f', -1, 64))
+		}
+		if schema.Maximum != nil && parsed > *schema.Maximum {
+			fail("max", "max-number", strconv.FormatFloat(*schema.Maximum, 'f', -1, 64))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("type", "type", schema.Type)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("type", "type", schema.Type)
+			return fields
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			fail("min", "min-items", strconv.Itoa(*schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			fail("max", "max-items", strconv.Itoa(*schema.MaxItems))
+		}
+		for i, item := range items {
+			fields = service.validate(translator, schema.Items, item, field+"["+strconv.Itoa(i)+"]", fields)
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("type", "type", schema.Type)
+			return fields
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				fields = append(fields, exceptions.FieldError{Field: joinField(field, name), Rule: "required", Message: utils.SchemaMessage(translator, "required", joinField(field, name), "")})
+			}
+		}
+		for _, name := range sortedKeys(object) {
+			property := schema.Properties[name]
+			if property == nil {
+				property = schema.AdditionalProperties
+			}
+			fields = service.validate(translator, property, object[name], joinField(field, name), fields)
+		}
+	}
+	return fields
+}
+
+func joinField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](values map[string]V) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
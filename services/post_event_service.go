@@ -0,0 +1,246 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PostEventsChannel is the postgres notification channel BlogService notifies with the id of the newest event of a write
+const PostEventsChannel = "blog_events"
+
+// types of the post events, posts have no drafts so a created post is published at once and a create records created and then published
+const (
+	PostEventCreated   = "created"
+	PostEventUpdated   = "updated"
+	PostEventDeleted   = "deleted"
+	PostEventPublished = "published"
+	// PostEventReset tells a resuming client that events it missed are no longer in the log so it has to reload the posts
+	PostEventReset = "reset"
+)
+
+const (
+	// eventPageSize is the number of events read from the log at once
+	eventPageSize = 100
+	// subscriptionBuffer is the number of events a subscriber may fall behind before its subscription is ended
+	subscriptionBuffer = 64
+	// listenRetryDelay is the wait before listening again after the listening connection failed
+	listenRetryDelay = time.Second
+)
+
+type PostEventService interface {
+	// Run listens for the notifications of BlogService writes in every api instance and fans the new events of the log out to the subscriptions,
+	// it listens again after the connection failed and ends every subscription when ctx is done
+	Run(ctx context.Context) error
+	// Subscribe starts a subscription, with lastEventId the events after it that are still in the log are the backlog,
+	// when they are not the backlog is a reset event
+	Subscribe(ctx context.Context, lastEventId string) (subscription *PostEventSubscription, err error)
+	Unsubscribe(subscription *PostEventSubscription)
+}
+
+// PostEventSubscription gets every event in id order once, the events up to the subscription are in Backlog and the later ones arrive on Events
+type PostEventSubscription struct {
+	Backlog []modelresponses.PostEventResponse
+	// Events is closed when the subscriber fell behind or the service stopped, the client resumes from the log with the id of the last event it got
+	Events <-chan modelresponses.PostEventResponse
+
+	events chan modelresponses.PostEventResponse
+	after  int64
+}
+
+// PostEventServiceImplementation reads the log from the primary because a replica may not have the event of a notification yet,
+// the log is the source of the events and notifications only tell that there is something new so a missed notification loses nothing
+type PostEventServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	BlogRepository repositories.BlogRepository
+
+	mutex         sync.Mutex
+	ready         bool
+	lastId        int64
+	subscriptions map[*PostEventSubscription]struct{}
+}
+
+func NewPostEventService(postgresUtil utils.PostgresUtil, blogRepository repositories.BlogRepository) PostEventService {
+	return &PostEventServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		BlogRepository: blogRepository,
+		subscriptions:  map[*PostEventSubscription]struct{}{},
+	}
+}
+
+func (service *PostEventServiceImplementation) Run(ctx context.Context) error {
+	defer service.closeSubscriptions()
+	for {
+		err := service.PostgresUtil.Listen(ctx, PostEventsChannel, func(payload string) {
+			service.poll(ctx, payload)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.Warn("post events: listening failed", "retry_in", listenRetryDelay.String(), "error", err)
+		if utils.Sleep(ctx, listenRetryDelay) != nil {
+			return nil
+		}
+	}
+}
+
+// Subscribe registers the subscription before reading the backlog so no event falls between them, the backlog ends at the last event fanned out
+// and Events starts after it, subscribing fails with service_unavailable until Run read the log once
+func (service *PostEventServiceImplementation) Subscribe(ctx context.Context, lastEventId string) (subscription *PostEventSubscription, err error) {
+	var afterId int64
+	if lastEventId != "" {
+		afterId, err = strconv.ParseInt(lastEventId, 10, 64)
+		if err != nil || afterId < 0 {
+			err = exceptions.NewBadRequestError(exceptions.CodeInvalidEventId, "Last-Event-ID must be the id of an event of this stream", err)
+			return
+		}
+	}
+	events := make(chan modelresponses.PostEventResponse, subscriptionBuffer)
+	subscription = &PostEventSubscription{Events: events, events: events}
+	service.mutex.Lock()
+	if !service.ready {
+		service.mutex.Unlock()
+		err = exceptions.NewUnavailableError(errors.New("post events are not listened to yet"))
+		return nil, err
+	}
+	lastId := service.lastId
+	subscription.after = max(lastId, afterId)
+	service.subscriptions[subscription] = struct{}{}
+	service.mutex.Unlock()
+	if lastEventId == "" {
+		return
+	}
+
+	subscription.Backlog, err = service.backlog(ctx, afterId, lastId)
+	if err != nil {
+		service.Unsubscribe(subscription)
+		err = exceptions.NewDatabaseError(err)
+		return nil, err
+	}
+	return
+}
+
+func (service *PostEventServiceImplementation) Unsubscribe(subscription *PostEventSubscription) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	service.remove(subscription)
+}
+
+// backlog reads the events after afterId up to lastId, the event of afterId is read too because when it is gone from the log
+// the events after it may have been trimmed as well and the client gets a reset event with the id the stream goes on from
+func (service *PostEventServiceImplementation) backlog(ctx context.Context, afterId int64, lastId int64) (backlog []modelresponses.PostEventResponse, err error) {
+	backlog = []modelresponses.PostEventResponse{}
+	events, err := service.BlogRepository.FindEventsAfter(service.PostgresUtil.GetPool(), ctx, afterId-1, eventPageSize)
+	if err != nil {
+		return
+	}
+	if len(events) == 0 || events[0].Id.Int64 != afterId {
+		backlog = append(backlog, modelresponses.PostEventResponse{Id: max(lastId, afterId), Type: PostEventReset})
+		return
+	}
+	full := len(events) == eventPageSize
+	events = events[1:]
+	for {
+		for _, event := range events {
+			if event.Id.Int64 > lastId {
+				return
+			}
+			backlog = append(backlog, postEventResponse(event))
+		}
+		if !full {
+			return
+		}
+		events, err = service.BlogRepository.FindEventsAfter(service.PostgresUtil.GetPool(), ctx, backlog[len(backlog)-1].Id, eventPageSize)
+		if err != nil {
+			return
+		}
+		full = len(events) == eventPageSize
+	}
+}
+
+// poll fans out the events after the last one fanned out, payload is the id of the newest event of the notifying write
+// so a notification of events that were read together with a later one is skipped, it runs on the listening goroutine only
+func (service *PostEventServiceImplementation) poll(ctx context.Context, payload string) {
+	service.mutex.Lock()
+	lastId, ready := service.lastId, service.ready
+	service.mutex.Unlock()
+	if id, err := strconv.ParseInt(payload, 10, 64); err == nil && ready && id <= lastId {
+		return
+	}
+	for {
+		events, err := service.BlogRepository.FindEventsAfter(service.PostgresUtil.GetPool(), ctx, lastId, eventPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("post events: reading the log failed", "after_id", lastId, "error", err)
+			}
+			return
+		}
+		service.fanOut(events)
+		if len(events) > 0 {
+			lastId = events[len(events)-1].Id.Int64
+		}
+		if len(events) < eventPageSize {
+			return
+		}
+	}
+}
+
+// fanOut sends events to every subscription without waiting, a subscription whose buffer is full is ended instead of holding up the others,
+// the first call only learns where the log ends, there are no subscriptions before it
+func (service *PostEventServiceImplementation) fanOut(events []modelentities.BlogEvent) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	service.ready = true
+	for _, event := range events {
+		response := postEventResponse(event)
+		for subscription := range service.subscriptions {
+			if response.Id <= subscription.after {
+				continue
+			}
+			select {
+			case subscription.events <- response:
+			default:
+				slog.Warn("post events: subscriber fell behind", "event_id", response.Id)
+				service.remove(subscription)
+			}
+		}
+		service.lastId = response.Id
+	}
+}
+
+func (service *PostEventServiceImplementation) closeSubscriptions() {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	for subscription := range service.subscriptions {
+		service.remove(subscription)
+	}
+	service.ready = false
+}
+
+// remove ends subscription once, it must be called with the lock held
+func (service *PostEventServiceImplementation) remove(subscription *PostEventSubscription) {
+	if _, ok := service.subscriptions[subscription]; !ok {
+		return
+	}
+	delete(service.subscriptions, subscription)
+	close(subscription.events)
+}
+
+func postEventResponse(event modelentities.BlogEvent) (response modelresponses.PostEventResponse) {
+	response.Id = event.Id.Int64
+	response.Type = event.Type.String
+	var post modelresponses.PostResponse
+	if json.Unmarshal([]byte(event.Payload.String), &post) == nil {
+		response.Post = &post
+	}
+	return
+}
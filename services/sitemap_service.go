@@ -0,0 +1,241 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSitemapUrls is the maximum number of urls allowed in one sitemap file by the sitemap protocol
+const MaxSitemapUrls = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type SitemapService interface {
+	WriteSitemap(ctx context.Context, writer io.Writer) (err error)
+	WritePostsSitemap(ctx context.Context, writer io.Writer, page int) (err error)
+	WriteTaxonomiesSitemap(ctx context.Context, writer io.Writer, page int) (err error)
+}
+
+// SitemapServiceImplementation lists the canonical post urls of the api and the category and tag pages of the static site, both are served from BaseUrl,
+// MaxUrls is the number of urls per sitemap file
+type SitemapServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	BlogRepository repositories.BlogRepository
+	BaseUrl        string
+	MaxUrls        int
+}
+
+func NewSitemapService(postgresUtil utils.PostgresUtil, blogRepository repositories.BlogRepository, baseUrl string) SitemapService {
+	return &SitemapServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		BlogRepository: blogRepository,
+		BaseUrl:        strings.TrimSuffix(baseUrl, "/"),
+		MaxUrls:        MaxSitemapUrls,
+	}
+}
+
+// WriteSitemap writes a single urlset while every url fits into one sitemap, otherwise it writes a sitemap index pointing to the paged sitemaps
+func (service *SitemapServiceImplementation) WriteSitemap(ctx context.Context, writer io.Writer) (err error) {
+	count, err := service.BlogRepository.CountAll(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	taxonomies, err := service.taxonomyUrls(ctx)
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+
+	if count+len(taxonomies) <= service.MaxUrls {
+		var encoder *xml.Encoder
+		encoder, err = service.startUrlset(writer)
+		if err == nil {
+			err = service.encodePosts(ctx, encoder, 0, 0)
+		}
+		if err == nil {
+			err = encodeUrls(encoder, taxonomies)
+		}
+		if err == nil {
+			err = service.endUrlset(writer, encoder)
+		}
+		if err != nil {
+			err = exceptions.NewInternalError(err)
+		}
+		return
+	}
+
+	_, err = io.WriteString(writer, xml.Header+`<sitemapindex xmlns="`+sitemapNamespace+`">`)
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	encoder := xml.NewEncoder(writer)
+	err = service.encodeIndexEntries(encoder, "/sitemap-posts-", count)
+	if err == nil {
+		err = service.encodeIndexEntries(encoder, "/sitemap-taxonomies-", len(taxonomies))
+	}
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err == nil {
+		_, err = io.WriteString(writer, `</sitemapindex>`)
+	}
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+	}
+	return
+}
+
+func (service *SitemapServiceImplementation) WritePostsSitemap(ctx context.Context, writer io.Writer, page int) (err error) {
+	count, err := service.BlogRepository.CountAll(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	if !service.hasPage(page, count) {
+		err = exceptions.NewNotFoundError(exceptions.CodeNotFound, "sitemap page not found")
+		return
+	}
+
+	encoder, err := service.startUrlset(writer)
+	if err == nil {
+		err = service.encodePosts(ctx, encoder, service.MaxUrls, (page-1)*service.MaxUrls)
+	}
+	if err == nil {
+		err = service.endUrlset(writer, encoder)
+	}
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+	}
+	return
+}
+
+func (service *SitemapServiceImplementation) WriteTaxonomiesSitemap(ctx context.Context, writer io.Writer, page int) (err error) {
+	taxonomies, err := service.taxonomyUrls(ctx)
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	if !service.hasPage(page, len(taxonomies)) {
+		err = exceptions.NewNotFoundError(exceptions.CodeNotFound, "sitemap page not found")
+		return
+	}
+
+	encoder, err := service.startUrlset(writer)
+	if err == nil {
+		err = encodeUrls(encoder, taxonomies[(page-1)*service.MaxUrls:min(page*service.MaxUrls, len(taxonomies))])
+	}
+	if err == nil {
+		err = service.endUrlset(writer, encoder)
+	}
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+	}
+	return
+}
+
+// hasPage keeps the first page of an empty sitemap so it is an empty urlset instead of not found
+func (service *SitemapServiceImplementation) hasPage(page int, count int) bool {
+	return page == 1 || (page > 1 && (page-1)*service.MaxUrls < count)
+}
+
+func (service *SitemapServiceImplementation) encodeIndexEntries(encoder *xml.Encoder, prefix string, count int) error {
+	pages := max((count+service.MaxUrls-1)/service.MaxUrls, 1)
+	for page := 1; page <= pages; page++ {
+		err := encoder.Encode(modelresponses.SitemapIndexEntry{Loc: service.BaseUrl + prefix + strconv.Itoa(page) + ".xml"})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service *SitemapServiceImplementation) startUrlset(writer io.Writer) (*xml.Encoder, error) {
+	_, err := io.WriteString(writer, xml.Header+`<urlset xmlns="`+sitemapNamespace+`">`)
+	if err != nil {
+		return nil, err
+	}
+	return xml.NewEncoder(writer), nil
+}
+
+func (service *SitemapServiceImplementation) endUrlset(writer io.Writer, encoder *xml.Encoder) error {
+	err := encoder.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, `</urlset>`)
+	return err
+}
+
+func (service *SitemapServiceImplementation) encodePosts(ctx context.Context, encoder *xml.Encoder, limit int, offset int) error {
+	return service.BlogRepository.StreamAll(service.PostgresUtil.GetPool(), ctx, "", limit, offset, func(blog modelentities.Blog) error {
+		lastMod := blog.CreatedAt
+		if blog.UpdatedAt.Valid {
+			lastMod = blog.UpdatedAt
+		}
+		return encoder.Encode(modelresponses.SitemapUrl{
+			Loc:     service.BaseUrl + "/v1/posts/" + strconv.Itoa(int(blog.Id.Int32)),
+			LastMod: formatLastMod(lastMod.Int64),
+		})
+	})
+}
+
+// taxonomyUrls returns the category pages followed by the tag pages the static site writes, names with the same slug share one url
+// with the latest lastmod and names without a slug have no page
+func (service *SitemapServiceImplementation) taxonomyUrls(ctx context.Context) (urls []modelresponses.SitemapUrl, err error) {
+	categories, err := service.BlogRepository.FindCategories(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		return
+	}
+	tags, err := service.BlogRepository.FindTags(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		return
+	}
+	indexes := map[string]int{}
+	lastMods := []int64{}
+	add := func(kind string, taxonomies []modelentities.Taxonomy) {
+		for _, taxonomy := range taxonomies {
+			if utils.Slugify(taxonomy.Name.String) == "" {
+				continue
+			}
+			loc := service.BaseUrl + "/" + siteTaxonomyPath(kind, taxonomy.Name.String)
+			if index, ok := indexes[loc]; ok {
+				lastMods[index] = max(lastMods[index], taxonomy.UpdatedAt.Int64)
+				continue
+			}
+			indexes[loc] = len(urls)
+			urls = append(urls, modelresponses.SitemapUrl{Loc: loc})
+			lastMods = append(lastMods, taxonomy.UpdatedAt.Int64)
+		}
+	}
+	add("categories", categories)
+	add("tags", tags)
+	for i := range urls {
+		urls[i].LastMod = formatLastMod(lastMods[i])
+	}
+	return
+}
+
+func encodeUrls(encoder *xml.Encoder, urls []modelresponses.SitemapUrl) error {
+	for _, url := range urls {
+		err := encoder.Encode(url)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatLastMod(millis int64) string {
+	return time.Unix(millis/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+}
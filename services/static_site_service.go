@@ -0,0 +1,359 @@
+package services
+
+import (
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+)
+
+const siteFeedSize = 20
+
+const siteSummaryLength = 200
+
+type StaticSiteService interface {
+	Generate(ctx context.Context, outputDir string) (err error)
+}
+
+type StaticSiteServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	BlogRepository repositories.BlogRepository
+	ThemeDir       string
+	BaseUrl        string
+	SiteTitle      string
+	PostsPerPage   int
+}
+
+func NewStaticSiteService(postgresUtil utils.PostgresUtil, blogRepository repositories.BlogRepository, themeDir string, baseUrl string, siteTitle string, postsPerPage int) StaticSiteService {
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	if postsPerPage < 1 {
+		postsPerPage = 10
+	}
+	return &StaticSiteServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		BlogRepository: blogRepository,
+		ThemeDir:       themeDir,
+		BaseUrl:        baseUrl,
+		SiteTitle:      siteTitle,
+		PostsPerPage:   postsPerPage,
+	}
+}
+
+type siteTemplates struct {
+	index    *template.Template
+	post     *template.Template
+	taxonomy *template.Template
+}
+
+type sitePostSummary struct {
+	post      modelresponses.SitePost
+	createdAt int64
+}
+
+// Generate renders every post page while streaming the blogs table, only the summaries are kept in memory to build the index, category, tag and feed pages
+func (service *StaticSiteServiceImplementation) Generate(ctx context.Context, outputDir string) (err error) {
+	templates, err := service.parseTemplates()
+	if err != nil {
+		return
+	}
+	categories, err := service.BlogRepository.FindCategories(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		return
+	}
+	tags, err := service.BlogRepository.FindTags(service.PostgresUtil.GetPool(), ctx)
+	if err != nil {
+		return
+	}
+	page := modelresponses.SitePage{
+		SiteTitle:  service.SiteTitle,
+		BaseUrl:    service.BaseUrl,
+		Categories: service.taxonomyLinks("categories", categories),
+		Tags:       service.taxonomyLinks("tags", tags),
+		FeedUrl:    service.BaseUrl + "feed.xml",
+	}
+
+	var summaries []sitePostSummary
+	err = service.BlogRepository.StreamAll(service.PostgresUtil.GetPool(), ctx, "", 0, 0, func(blog modelentities.Blog) error {
+		post, err := service.toSitePost(blog)
+		if err != nil {
+			return err
+		}
+		postPage := page
+		postPage.Title = post.Title
+		postPage.Post = post
+		err = service.render(templates.post, postPage, outputDir, "posts", strconv.Itoa(post.Id), "index.html")
+		if err != nil {
+			return err
+		}
+		post.Content = ""
+		summaries = append(summaries, sitePostSummary{post: post, createdAt: blog.CreatedAt.Int64})
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].createdAt > summaries[j].createdAt
+	})
+	posts := make([]modelresponses.SitePost, 0, len(summaries))
+	for _, summary := range summaries {
+		posts = append(posts, summary.post)
+	}
+
+	indexPage := page
+	indexPage.Title = service.SiteTitle
+	err = service.renderListing(templates.index, indexPage, posts, outputDir)
+	if err != nil {
+		return
+	}
+	err = service.writeFeed(service.SiteTitle, service.BaseUrl, posts, outputDir, "feed.xml")
+	if err != nil {
+		return
+	}
+
+	err = service.renderTaxonomies(templates.taxonomy, page, page.Categories, posts, outputDir, func(post modelresponses.SitePost) []modelresponses.SiteLink {
+		return []modelresponses.SiteLink{post.Category}
+	})
+	if err != nil {
+		return
+	}
+	err = service.renderTaxonomies(templates.taxonomy, page, page.Tags, posts, outputDir, func(post modelresponses.SitePost) []modelresponses.SiteLink {
+		return post.Tags
+	})
+	if err != nil {
+		return
+	}
+	return service.copyStatic(outputDir)
+}
+
+// parseTemplates pairs layout.html of the theme with every page template, each page template defines the blocks used by the layout
+func (service *StaticSiteServiceImplementation) parseTemplates() (templates siteTemplates, err error) {
+	layout := filepath.Join(service.ThemeDir, "layout.html")
+	templates.index, err = template.ParseFiles(layout, filepath.Join(service.ThemeDir, "index.html"))
+	if err != nil {
+		return
+	}
+	templates.post, err = template.ParseFiles(layout, filepath.Join(service.ThemeDir, "post.html"))
+	if err != nil {
+		return
+	}
+	templates.taxonomy, err = template.ParseFiles(layout, filepath.Join(service.ThemeDir, "taxonomy.html"))
+	return
+}
+
+func (service *StaticSiteServiceImplementation) toSitePost(blog modelentities.Blog) (post modelresponses.SitePost, err error) {
+	var content bytes.Buffer
+	err = goldmark.Convert([]byte(blog.Content.String), &content)
+	if err != nil {
+		return
+	}
+	post.Id = int(blog.Id.Int32)
+	post.Url = service.BaseUrl + "posts/" + strconv.Itoa(post.Id) + "/"
+	post.Title = blog.Title.String
+	post.Summary = summarize(blog.Content.String)
+	post.Content = template.HTML(content.String())
+	post.Category = modelresponses.SiteLink{Name: blog.Category.String, Url: service.taxonomyUrl("categories", blog.Category.String)}
+	for _, tag := range splitTags(blog.Tags.String) {
+		post.Tags = append(post.Tags, modelresponses.SiteLink{Name: tag, Url: service.taxonomyUrl("tags", tag)})
+	}
+	post.CreatedAt = time.Unix(blog.CreatedAt.Int64/1000, 0).UTC().Format("2006-01-02T15:04:05Z")
+	post.UpdatedAt = formatUpdatedAt(blog)
+	return
+}
+
+func (service *StaticSiteServiceImplementation) taxonomyLinks(kind string, taxonomies []modelentities.Taxonomy) []modelresponses.SiteLink {
+	links := []modelresponses.SiteLink{}
+	for _, taxonomy := range taxonomies {
+		if utils.Slugify(taxonomy.Name.String) == "" {
+			continue
+		}
+		links = append(links, modelresponses.SiteLink{Name: taxonomy.Name.String, Url: service.taxonomyUrl(kind, taxonomy.Name.String)})
+	}
+	return links
+}
+
+func (service *StaticSiteServiceImplementation) taxonomyUrl(kind string, name string) string {
+	return service.BaseUrl + siteTaxonomyPath(kind, name)
+}
+
+// siteTaxonomyPath is the path of the page of a category or tag relative to the base url, names with the same slug share a page
+func siteTaxonomyPath(kind string, name string) string {
+	return kind + "/" + utils.Slugify(name) + "/"
+}
+
+// renderTaxonomies writes a paginated listing and a feed for every taxonomy link that has posts, postLinks returns the links of a post for this taxonomy
+func (service *StaticSiteServiceImplementation) renderTaxonomies(taxonomyTemplate *template.Template, page modelresponses.SitePage, links []modelresponses.SiteLink, posts []modelresponses.SitePost, outputDir string, postLinks func(post modelresponses.SitePost) []modelresponses.SiteLink) error {
+	postsByUrl := map[string][]modelresponses.SitePost{}
+	for _, post := range posts {
+		for _, link := range postLinks(post) {
+			postsByUrl[link.Url] = append(postsByUrl[link.Url], post)
+		}
+	}
+	for _, link := range links {
+		taxonomyPosts := postsByUrl[link.Url]
+		if len(taxonomyPosts) == 0 {
+			continue
+		}
+		link.Count = len(taxonomyPosts)
+		directory := filepath.Join(outputDir, filepath.FromSlash(strings.TrimPrefix(link.Url, service.BaseUrl)))
+		taxonomyPage := page
+		taxonomyPage.Title = link.Name
+		taxonomyPage.Taxonomy = link
+		taxonomyPage.FeedUrl = link.Url + "feed.xml"
+		err := service.renderListing(taxonomyTemplate, taxonomyPage, taxonomyPosts, directory)
+		if err != nil {
+			return err
+		}
+		err = service.writeFeed(service.SiteTitle+" - "+link.Name, link.Url, taxonomyPosts, directory, "feed.xml")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderListing writes the first page as index.html of directory and the next pages as page/{n}/index.html
+func (service *StaticSiteServiceImplementation) renderListing(listingTemplate *template.Template, page modelresponses.SitePage, posts []modelresponses.SitePost, directory string) error {
+	listingUrl := service.BaseUrl
+	if page.Taxonomy.Url != "" {
+		listingUrl = page.Taxonomy.Url
+	}
+	totalPages := max((len(posts)+service.PostsPerPage-1)/service.PostsPerPage, 1)
+	for number := 1; number <= totalPages; number++ {
+		start := (number - 1) * service.PostsPerPage
+		end := min(start+service.PostsPerPage, len(posts))
+		listingPage := page
+		listingPage.Posts = posts[start:end]
+		listingPage.Pagination = modelresponses.SitePagination{Page: number, TotalPages: totalPages}
+		if number > 1 {
+			listingPage.Pagination.PreviousUrl = listingUrl + "page/" + strconv.Itoa(number-1) + "/"
+			if number == 2 {
+				listingPage.Pagination.PreviousUrl = listingUrl
+			}
+		}
+		if number < totalPages {
+			listingPage.Pagination.NextUrl = listingUrl + "page/" + strconv.Itoa(number+1) + "/"
+		}
+		elements := []string{directory, "index.html"}
+		if number > 1 {
+			elements = []string{directory, "page", strconv.Itoa(number), "index.html"}
+		}
+		err := service.render(listingTemplate, listingPage, elements...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service *StaticSiteServiceImplementation) render(pageTemplate *template.Template, page modelresponses.SitePage, elements ...string) error {
+	file, err := createFile(filepath.Join(elements...))
+	if err != nil {
+		return err
+	}
+	err = pageTemplate.ExecuteTemplate(file, "layout", page)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (service *StaticSiteServiceImplementation) writeFeed(title string, link string, posts []modelresponses.SitePost, elements ...string) error {
+	feed := modelresponses.SiteFeed{Version: "2.0"}
+	feed.Channel.Title = title
+	feed.Channel.Link = link
+	feed.Channel.Description = title
+	for _, post := range posts[:min(siteFeedSize, len(posts))] {
+		pubDate, _ := time.Parse("2006-01-02T15:04:05Z", post.CreatedAt)
+		feed.Channel.Items = append(feed.Channel.Items, modelresponses.SiteFeedItem{
+			Title:       post.Title,
+			Link:        post.Url,
+			Guid:        post.Url,
+			PubDate:     pubDate.Format(time.RFC1123Z),
+			Category:    post.Category.Name,
+			Description: post.Summary,
+		})
+	}
+	file, err := createFile(filepath.Join(elements...))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(file, xml.Header)
+	if err == nil {
+		encoder := xml.NewEncoder(file)
+		encoder.Indent("", "  ")
+		err = encoder.Encode(feed)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// copyStatic copies the static directory of the theme as is, a theme without static files is valid
+func (service *StaticSiteServiceImplementation) copyStatic(outputDir string) error {
+	staticDir := filepath.Join(service.ThemeDir, "static")
+	_, err := os.Stat(staticDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(staticDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(staticDir, path)
+		if err != nil {
+			return err
+		}
+		source, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+		destination, err := createFile(filepath.Join(outputDir, "static", relative))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(destination, source)
+		if err != nil {
+			destination.Close()
+			return err
+		}
+		return destination.Close()
+	})
+}
+
+func createFile(path string) (*os.File, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func summarize(content string) string {
+	summary := strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(summary) <= siteSummaryLength {
+		return summary
+	}
+	return string([]rune(summary)[:siteSummaryLength]) + "…"
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelrequests "blogging-platform-api/models/requests"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	WordpressRecordCreated = "created"
+	WordpressRecordUpdated = "updated"
+	WordpressRecordSkipped = "skipped"
+	WordpressRecordFailed  = "failed"
+)
+
+const wordpressDateLayout = "2006-01-02 15:04:05"
+
+const wordpressDefaultCategory = "Uncategorized"
+
+// wordpressAuthorLength is the length of the author columns
+const wordpressAuthorLength = 100
+
+type WordpressImportService interface {
+	Import(ctx context.Context, reader io.Reader) (response modelresponses.WordpressImportResponse, err error)
+}
+
+type WordpressImportServiceImplementation struct {
+	PostgresUtil   utils.PostgresUtil
+	Validate       *validator.Validate
+	BlogRepository repositories.BlogRepository
+}
+
+func NewWordpressImportService(postgresUtil utils.PostgresUtil, validate *validator.Validate, blogRepository repositories.BlogRepository) WordpressImportService {
+	return &WordpressImportServiceImplementation{
+		PostgresUtil:   postgresUtil,
+		Validate:       validate,
+		BlogRepository: blogRepository,
+	}
+}
+
+// Import upserts every published post of a WXR export by its original slug so running it again updates the same blogs instead of duplicating them,
+// the author of a post is stored by display name and approved comments are upserted by their wordpress id, other comments are counted as skipped,
+// like the other import it records no post events
+func (service *WordpressImportServiceImplementation) Import(ctx context.Context, reader io.Reader) (response modelresponses.WordpressImportResponse, err error) {
+	var export modelrequests.WordpressExport
+	decoder := xml.NewDecoder(reader)
+	decoder.Strict = false
+	err = decoder.Decode(&export)
+	if err != nil {
+		err = exceptions.NewBadRequestError(exceptions.CodeInvalidBody, "wxr file can not be parsed: "+err.Error(), err)
+		return
+	}
+
+	var importResponse modelresponses.WordpressImportResponse
+	importResponse.Records = []modelresponses.WordpressImportRecordResponse{}
+	authors := map[string]string{}
+	for _, author := range export.Channel.Authors {
+		if name := strings.TrimSpace(author.DisplayName); name != "" {
+			authors[strings.TrimSpace(author.Login)] = html.UnescapeString(name)
+		}
+	}
+
+	tx, err := service.PostgresUtil.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		err = exceptions.NewInternalError(err)
+		return
+	}
+	defer func() {
+		errCommitOrRollback := service.PostgresUtil.CommitOrRollback(tx, ctx, err)
+		if errCommitOrRollback != nil {
+			response = modelresponses.WordpressImportResponse{}
+			err = exceptions.NewInternalError(errCommitOrRollback)
+		}
+	}()
+
+	for i, item := range export.Channel.Items {
+		if item.PostType != "post" {
+			continue
+		}
+		importResponse.Total++
+		record := modelresponses.WordpressImportRecordResponse{Index: i + 1, PostId: item.PostId, Slug: wordpressSlug(item)}
+		if item.Status != "publish" {
+			record.Status = WordpressRecordSkipped
+			record.Message = "status is " + item.Status
+			importResponse.Skipped++
+			importResponse.SkippedComments += len(item.Comments)
+			importResponse.Records = append(importResponse.Records, record)
+			continue
+		}
+
+		var comments, skippedComments int
+		var errRecord error
+		record.Id, record.Status, comments, skippedComments, errRecord = service.importItem(tx, ctx, item, record.Slug, authors)
+		if errRecord != nil && ctx.Err() != nil {
+			err = exceptions.NewInternalError(ctx.Err())
+			return
+		}
+		importResponse.Comments += comments
+		importResponse.SkippedComments += skippedComments
+		switch record.Status {
+		case WordpressRecordCreated:
+			importResponse.Created++
+		case WordpressRecordUpdated:
+			importResponse.Updated++
+		default:
+			importResponse.SkippedComments += len(item.Comments)
+			appError := exceptions.AsAppError(exceptions.NewDatabaseError(errRecord))
+			if appError.Kind == exceptions.KindInternal {
+				slog.ErrorContext(ctx, "error when importing wordpress post", "post_id", item.PostId, "error", errRecord)
+			}
+			record.Status = WordpressRecordFailed
+			record.Message = appError.Message
+			for _, field := range appError.Fields {
+				record.Errors = append(record.Errors, modelresponses.FieldErrorResponse(field))
+			}
+			importResponse.Failed++
+		}
+		importResponse.Records = append(importResponse.Records, record)
+	}
+
+	response = importResponse
+	return
+}
+
+// importItem runs inside a savepoint so one failing post does not abort the whole import, the post and its comments are imported together
+func (service *WordpressImportServiceImplementation) importItem(tx pgx.Tx, ctx context.Context, item modelrequests.WordpressItem, slug string, authors map[string]string) (id int, status string, comments int, skippedComments int, err error) {
+	blog, err := service.toBlog(ctx, item, slug, authors)
+	if err != nil {
+		return
+	}
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return
+	}
+	id, inserted, err := service.BlogRepository.UpsertBySlug(savepoint, ctx, blog)
+	if err != nil {
+		savepoint.Rollback(ctx)
+		return
+	}
+	blogComments := toBlogComments(id, item, blog.CreatedAt.Int64)
+	if len(blogComments) > 0 {
+		_, err = service.BlogRepository.UpsertComments(savepoint, ctx, blogComments)
+		if err != nil {
+			savepoint.Rollback(ctx)
+			return
+		}
+	}
+	comments, skippedComments = len(blogComments), len(item.Comments)-len(blogComments)
+	err = savepoint.Commit(ctx)
+	if err != nil {
+		return
+	}
+	status = WordpressRecordUpdated
+	if inserted {
+		status = WordpressRecordCreated
+	}
+	return
+}
+
+func (service *WordpressImportServiceImplementation) toBlog(ctx context.Context, item modelrequests.WordpressItem, slug string, authors map[string]string) (blog modelentities.Blog, err error) {
+	content, err := utils.HtmlToMarkdown(item.Content)
+	if err != nil {
+		return
+	}
+	importRequest := modelrequests.ImportRequest{
+		Title:    html.UnescapeString(strings.TrimSpace(item.Title)),
+		Content:  content,
+		Category: wordpressDefaultCategory,
+		Tags:     []string{},
+	}
+	for _, category := range item.Categories {
+		name := html.UnescapeString(strings.TrimSpace(category.Name))
+		switch category.Domain {
+		case "category":
+			if importRequest.Category == wordpressDefaultCategory {
+				importRequest.Category = name
+			}
+		case "post_tag":
+			importRequest.Tags = append(importRequest.Tags, name)
+		}
+	}
+	err = service.Validate.Struct(importRequest)
+	if err != nil {
+		err = exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx))
+		return
+	}
+
+	createdAt, err := parseWordpressDate(item.PostDateGmt, item.PostDate, item.PubDate)
+	if err != nil {
+		err = exceptions.NewValidationError(err, utils.TranslatorFromContext(ctx))
+		return
+	}
+	updatedAt, err := parseWordpressDate(item.PostModifiedGmt, item.PostModified, "")
+	if err != nil {
+		updatedAt, err = createdAt, nil
+	}
+	blog.Title = pgtype.Text{Valid: true, String: importRequest.Title}
+	blog.Content = pgtype.Text{Valid: true, String: importRequest.Content}
+	blog.Category = pgtype.Text{Valid: true, String: importRequest.Category}
+	blog.Tags = pgtype.Text{Valid: true, String: strings.Join(importRequest.Tags, ", ")}
+	blog.CreatedAt = pgtype.Int8{Valid: true, Int64: createdAt.UnixMilli()}
+	blog.UpdatedAt = pgtype.Int8{Valid: true, Int64: updatedAt.UnixMilli()}
+	blog.Slug = pgtype.Text{Valid: true, String: slug}
+	login := strings.TrimSpace(item.Creator)
+	if author, ok := authors[login]; ok {
+		blog.Author = pgtype.Text{Valid: true, String: truncate(author, wordpressAuthorLength)}
+	} else if login != "" {
+		blog.Author = pgtype.Text{Valid: true, String: truncate(login, wordpressAuthorLength)}
+	}
+	return
+}
+
+// toBlogComments keeps the approved comments, a comment without a date gets the date of its post and one without an id a hash of its author and content
+func toBlogComments(postId int, item modelrequests.WordpressItem, postCreatedAt int64) (comments []modelentities.BlogComment) {
+	for _, comment := range item.Comments {
+		if strings.TrimSpace(comment.Approved) != "1" {
+			continue
+		}
+		content, err := utils.HtmlToMarkdown(comment.Content)
+		if err != nil || content == "" {
+			continue
+		}
+		createdAt := postCreatedAt
+		if date, err := parseWordpressDate(comment.DateGmt, comment.Date, ""); err == nil {
+			createdAt = date.UnixMilli()
+		}
+		sourceId := strings.TrimSpace(comment.Id)
+		if sourceId == "" {
+			sourceId = "hash-" + shortHash(comment.Author, comment.Content)
+		}
+		comments = append(comments, modelentities.BlogComment{
+			PostId:    pgtype.Int4{Valid: true, Int32: int32(postId)},
+			SourceId:  pgtype.Text{Valid: true, String: truncate(sourceId, 64)},
+			Author:    pgtype.Text{Valid: true, String: truncate(html.UnescapeString(strings.TrimSpace(comment.Author)), wordpressAuthorLength)},
+			Content:   pgtype.Text{Valid: true, String: content},
+			CreatedAt: pgtype.Int8{Valid: true, Int64: createdAt},
+		})
+	}
+	return
+}
+
+// wordpressSlug keeps the original post_name, posts without one get a slug derived from the wordpress id so re-imports still match,
+// posts without either get the slug of their title followed by a hash of the title, date and content so they neither collide nor duplicate on a re-import
+func wordpressSlug(item modelrequests.WordpressItem) string {
+	if slug := strings.TrimSpace(item.PostName); slug != "" {
+		return slug
+	}
+	if postId := strings.TrimSpace(item.PostId); postId != "" {
+		return "wordpress-" + postId
+	}
+	hash := shortHash(item.Title, item.PostDateGmt, item.PostDate, item.PubDate, item.Content)
+	slug := strings.TrimSuffix(truncate(utils.Slugify(html.UnescapeString(item.Title)), 80), "-")
+	if slug == "" {
+		return "wordpress-" + hash
+	}
+	return slug + "-" + hash
+}
+
+// shortHash returns the first 12 hex digits of the sha256 of the values separated by a null byte
+func shortHash(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// truncate cuts value to at most length runes
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
+
+// parseWordpressDate prefers the gmt date, wordpress writes 0000-00-00 00:00:00 when it is unknown, the local date is then read as utc and pubDate is the last resort
+func parseWordpressDate(gmt string, local string, pubDate string) (time.Time, error) {
+	for _, value := range []string{gmt, local} {
+		value = strings.TrimSpace(value)
+		if value == "" || strings.HasPrefix(value, "0000-00-00") {
+			continue
+		}
+		return time.Parse(wordpressDateLayout, value)
+	}
+	if strings.TrimSpace(pubDate) != "" {
+		return time.Parse(time.RFC1123Z, strings.TrimSpace(pubDate))
+	}
+	return time.Time{}, errors.New("post has no date")
+}
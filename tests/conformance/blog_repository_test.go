@@ -0,0 +1,561 @@
+package conformance_test
+
+import (
+	"blogging-platform-api/configs"
+	"blogging-platform-api/databases"
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend is one storage implementation under test, transactional is false when a rolled back transaction keeps its writes
+// and constraints is false when column lengths are not enforced by the storage
+type backend struct {
+	postgresUtil   utils.PostgresUtil
+	blogRepository repositories.BlogRepository
+	transactional  bool
+	constraints    bool
+}
+
+func TestMemoryBlogRepository(t *testing.T) {
+	runBlogRepositoryConformance(t, func(t *testing.T) backend {
+		return backend{
+			postgresUtil:   utils.NewFakePostgresUtil(),
+			blogRepository: repositories.NewBlogMemoryRepository(),
+		}
+	})
+}
+
+func TestResilientBlogRepository(t *testing.T) {
+	runBlogRepositoryConformance(t, func(t *testing.T) backend {
+		return backend{
+			postgresUtil:   utils.NewFakePostgresUtil(),
+			blogRepository: repositories.NewResilientBlogRepository(repositories.NewBlogMemoryRepository(), utils.NewDatabaseRetryPolicy(3), utils.NewCircuitBreaker(5, time.Second)),
+		}
+	})
+}
+
+func TestSqliteBlogRepository(t *testing.T) {
+	runBlogRepositoryConformance(t, func(t *testing.T) backend {
+		sqliteUtil, err := utils.OpenSqlite(filepath.Join(t.TempDir(), "blogs.db"))
+		require.NoError(t, err)
+		t.Cleanup(sqliteUtil.Close)
+		migrationUtil, err := utils.NewSqliteMigrationUtil(sqliteUtil, databases.SqliteMigrations)
+		require.NoError(t, err)
+		_, err = migrationUtil.Up(context.Background())
+		require.NoError(t, err)
+		return backend{
+			postgresUtil:   sqliteUtil,
+			blogRepository: repositories.NewBlogSqliteRepository(sqliteUtil.GetDB()),
+			transactional:  true,
+			constraints:    true,
+		}
+	})
+}
+
+// TestPostgresBlogRepository runs against the database of the POSTGRES_* environment variables, every blog in it is deleted
+func TestPostgresBlogRepository(t *testing.T) {
+	if os.Getenv("POSTGRES_HOST") == "" {
+		t.Skip("POSTGRES_HOST is not set")
+	}
+	config, _, err := configs.Load(nil)
+	require.NoError(t, err)
+	postgresUtil := utils.NewPostgresConnection(config.Postgres)
+	t.Cleanup(postgresUtil.Close)
+	migrationUtil, err := utils.NewMigrationUtil(postgresUtil, databases.Migrations)
+	require.NoError(t, err)
+	_, err = migrationUtil.Up(context.Background())
+	require.NoError(t, err)
+
+	runBlogRepositoryConformance(t, func(t *testing.T) backend {
+		_, err := postgresUtil.GetPool().Exec(context.Background(), `TRUNCATE blogs, blog_comments, blog_events RESTART IDENTITY;`)
+		require.NoError(t, err)
+		return backend{
+			postgresUtil:   postgresUtil,
+			blogRepository: repositories.NewBlogRepository(),
+			transactional:  true,
+			constraints:    true,
+		}
+	})
+}
+
+func newBlog(title string, tags string, createdAt int64, updatedAt int64) modelentities.Blog {
+	blog := modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: title},
+		Content:   pgtype.Text{Valid: true, String: "content of " + title},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: tags},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: createdAt},
+	}
+	if updatedAt != 0 {
+		blog.UpdatedAt = pgtype.Int8{Valid: true, Int64: updatedAt}
+	}
+	return blog
+}
+
+func create(t *testing.T, b backend, blogs ...modelentities.Blog) []int {
+	t.Helper()
+	ids := []int{}
+	for _, blog := range blogs {
+		id, err := b.blogRepository.Create(b.postgresUtil.GetPool(), context.Background(), blog)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func titles(blogs []modelentities.Blog) []string {
+	var titles []string
+	for _, blog := range blogs {
+		titles = append(titles, blog.Title.String)
+	}
+	return titles
+}
+
+func runBlogRepositoryConformance(t *testing.T, newBackend func(t *testing.T) backend) {
+	ctx := context.Background()
+
+	t.Run("create and find by id", func(t *testing.T) {
+		b := newBackend(t)
+		ids := create(t, b, newBlog("Go", "go, language", 1000, 0))
+		blog, err := b.blogRepository.FindById(b.postgresUtil.GetPool(), ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, int32(ids[0]), blog.Id.Int32)
+		assert.Equal(t, "Go", blog.Title.String)
+		assert.Equal(t, "content of Go", blog.Content.String)
+		assert.Equal(t, "Programming", blog.Category.String)
+		assert.Equal(t, "go, language", blog.Tags.String)
+		assert.Equal(t, int64(1000), blog.CreatedAt.Int64)
+		assert.False(t, blog.UpdatedAt.Valid)
+		assert.False(t, blog.Slug.Valid)
+	})
+
+	t.Run("find missing id returns no rows", func(t *testing.T) {
+		b := newBackend(t)
+		_, err := b.blogRepository.FindById(b.postgresUtil.GetPool(), ctx, 99)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Equal(t, exceptions.CodePostNotFound, exceptions.AsAppError(exceptions.NewDatabaseError(err)).Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		b := newBackend(t)
+		ids := create(t, b, newBlog("Go", "go", 1000, 0))
+		blog := newBlog("Go 2", "go, generics", 0, 2000)
+		blog.Id = pgtype.Int4{Valid: true, Int32: int32(ids[0])}
+		rowsAffected, err := b.blogRepository.Update(b.postgresUtil.GetPool(), ctx, blog)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+
+		updated, err := b.blogRepository.FindById(b.postgresUtil.GetPool(), ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, "Go 2", updated.Title.String)
+		assert.Equal(t, "go, generics", updated.Tags.String)
+		assert.Equal(t, int64(1000), updated.CreatedAt.Int64)
+		assert.Equal(t, int64(2000), updated.UpdatedAt.Int64)
+
+		blog.Id = pgtype.Int4{Valid: true, Int32: 99}
+		rowsAffected, err = b.blogRepository.Update(b.postgresUtil.GetPool(), ctx, blog)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), rowsAffected)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		b := newBackend(t)
+		ids := create(t, b, newBlog("Go", "go", 1000, 0), newBlog("Rust", "rust", 1000, 0))
+		rowsAffected, err := b.blogRepository.Delete(b.postgresUtil.GetPool(), ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+		rowsAffected, err = b.blogRepository.Delete(b.postgresUtil.GetPool(), ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), rowsAffected)
+		count, err := b.blogRepository.CountAll(b.postgresUtil.GetPool(), ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("find all filters tags", func(t *testing.T) {
+		b := newBackend(t)
+		create(t, b,
+			newBlog("Go", "go, language", 1000, 0),
+			newBlog("Rust", "rust, Language", 1000, 0),
+			newBlog("Cooking", "food", 1000, 0),
+		)
+		tests := []struct {
+			term       string
+			wantTitles []string
+		}{
+			{term: "", wantTitles: []string{"Go", "Rust", "Cooking"}},
+			{term: "language", wantTitles: []string{"Go", "Rust"}},
+			{term: "LANG", wantTitles: []string{"Go", "Rust"}},
+			{term: "oo", wantTitles: []string{"Cooking"}},
+			{term: "Cooking", wantTitles: nil},
+		}
+		for _, test := range tests {
+			blogs, err := b.blogRepository.FindAll(b.postgresUtil.GetPool(), ctx, test.term)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.wantTitles, titles(blogs), "term %q", test.term)
+		}
+	})
+
+	t.Run("stream all orders by id with limit and offset", func(t *testing.T) {
+		b := newBackend(t)
+		create(t, b,
+			newBlog("One", "a", 1000, 0),
+			newBlog("Two", "b", 1000, 0),
+			newBlog("Three", "a", 1000, 0),
+			newBlog("Four", "a", 1000, 0),
+		)
+		tests := []struct {
+			term       string
+			limit      int
+			offset     int
+			wantTitles []string
+		}{
+			{wantTitles: []string{"One", "Two", "Three", "Four"}},
+			{limit: 2, wantTitles: []string{"One", "Two"}},
+			{limit: 2, offset: 3, wantTitles: []string{"Four"}},
+			{offset: 1, wantTitles: []string{"Two", "Three", "Four"}},
+			{term: "a", limit: 1, offset: 1, wantTitles: []string{"Three"}},
+			{offset: 10, wantTitles: nil},
+		}
+		for _, test := range tests {
+			var blogs []modelentities.Blog
+			err := b.blogRepository.StreamAll(b.postgresUtil.GetPool(), ctx, test.term, test.limit, test.offset, func(blog modelentities.Blog) error {
+				blogs = append(blogs, blog)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.wantTitles, titles(blogs), "term %q limit %d offset %d", test.term, test.limit, test.offset)
+		}
+	})
+
+	t.Run("find after pages by id", func(t *testing.T) {
+		b := newBackend(t)
+		life := newBlog("Life", "food, language", 1000, 0)
+		life.Category = pgtype.Text{Valid: true, String: "Life"}
+		ids := create(t, b,
+			newBlog("One", "go, language", 1000, 0),
+			life,
+			newBlog("Two", "rust, Language", 1000, 0),
+			newBlog("Three", "go", 1000, 0),
+		)
+		tests := []struct {
+			term       string
+			category   string
+			afterId    int
+			limit      int
+			wantTitles []string
+		}{
+			{limit: 10, wantTitles: []string{"One", "Life", "Two", "Three"}},
+			{limit: 2, wantTitles: []string{"One", "Life"}},
+			{afterId: ids[1], limit: 2, wantTitles: []string{"Two", "Three"}},
+			{term: "language", afterId: ids[0], limit: 10, wantTitles: []string{"Life", "Two"}},
+			{term: "language", category: "Programming", limit: 10, wantTitles: []string{"One", "Two"}},
+			{category: "Life", afterId: ids[1], limit: 10, wantTitles: nil},
+		}
+		for _, test := range tests {
+			blogs, err := b.blogRepository.FindAfter(b.postgresUtil.GetPool(), ctx, test.term, test.category, test.afterId, test.limit)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantTitles, titles(blogs), "term %q category %q after %d limit %d", test.term, test.category, test.afterId, test.limit)
+		}
+	})
+
+	t.Run("find by ids and slug", func(t *testing.T) {
+		b := newBackend(t)
+		ids := create(t, b, newBlog("One", "a", 1000, 0), newBlog("Two", "b", 1000, 0), newBlog("Three", "c", 1000, 0))
+		blogs, err := b.blogRepository.FindByIds(b.postgresUtil.GetPool(), ctx, []int{ids[2], 99, ids[0]})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"One", "Three"}, titles(blogs))
+		blogs, err = b.blogRepository.FindByIds(b.postgresUtil.GetPool(), ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, blogs)
+
+		blog := newBlog("Go", "go", 1000, 0)
+		blog.Slug = pgtype.Text{Valid: true, String: "go"}
+		_, _, err = b.blogRepository.UpsertBySlug(b.postgresUtil.GetPool(), ctx, blog)
+		require.NoError(t, err)
+		found, err := b.blogRepository.FindBySlug(b.postgresUtil.GetPool(), ctx, "go")
+		require.NoError(t, err)
+		assert.Equal(t, "Go", found.Title.String)
+		_, err = b.blogRepository.FindBySlug(b.postgresUtil.GetPool(), ctx, "rust")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("stream all stops at callback error", func(t *testing.T) {
+		b := newBackend(t)
+		create(t, b, newBlog("One", "a", 1000, 0), newBlog("Two", "a", 1000, 0))
+		errStop := errors.New("stop")
+		calls := 0
+		err := b.blogRepository.StreamAll(b.postgresUtil.GetPool(), ctx, "", 0, 0, func(blog modelentities.Blog) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("taxonomies", func(t *testing.T) {
+		b := newBackend(t)
+		create(t, b,
+			newBlog("Go", "go, language", 1000, 5000),
+			newBlog("Rust", "rust,language ,language", 3000, 0),
+		)
+		rust := newBlog("Life", "food", 2000, 0)
+		rust.Category = pgtype.Text{Valid: true, String: "Life"}
+		create(t, b, rust)
+
+		categories, err := b.blogRepository.FindCategories(b.postgresUtil.GetPool(), ctx)
+		require.NoError(t, err)
+		require.Len(t, categories, 2)
+		assert.Equal(t, "Life", categories[0].Name.String)
+		assert.Equal(t, int64(2000), categories[0].UpdatedAt.Int64)
+		assert.Equal(t, int64(1), categories[0].Count.Int64)
+		assert.Equal(t, "Programming", categories[1].Name.String)
+		assert.Equal(t, int64(5000), categories[1].UpdatedAt.Int64)
+		assert.Equal(t, int64(2), categories[1].Count.Int64)
+
+		tags, err := b.blogRepository.FindTags(b.postgresUtil.GetPool(), ctx)
+		require.NoError(t, err)
+		updatedAts := map[string]int64{}
+		counts := map[string]int64{}
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Name.String)
+			updatedAts[tag.Name.String] = tag.UpdatedAt.Int64
+			counts[tag.Name.String] = tag.Count.Int64
+		}
+		assert.Equal(t, []string{"food", "go", "language", "rust"}, names)
+		assert.Equal(t, map[string]int64{"food": 2000, "go": 5000, "language": 5000, "rust": 3000}, updatedAts)
+		assert.Equal(t, map[string]int64{"food": 1, "go": 1, "language": 2, "rust": 1}, counts)
+
+		categories, err = b.blogRepository.FindCategoriesByNames(b.postgresUtil.GetPool(), ctx, []string{"Programming", "missing"})
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, "Programming", categories[0].Name.String)
+		assert.Equal(t, int64(2), categories[0].Count.Int64)
+
+		tags, err = b.blogRepository.FindTagsByNames(b.postgresUtil.GetPool(), ctx, []string{"language", "rust", "missing"})
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+		assert.Equal(t, "language", tags[0].Name.String)
+		assert.Equal(t, int64(5000), tags[0].UpdatedAt.Int64)
+		assert.Equal(t, int64(2), tags[0].Count.Int64)
+		assert.Equal(t, "rust", tags[1].Name.String)
+
+		tags, err = b.blogRepository.FindTagsByNames(b.postgresUtil.GetPool(), ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("create batch in a transaction", func(t *testing.T) {
+		b := newBackend(t)
+		blogs := []modelentities.Blog{newBlog("One", "a", 1000, 0), newBlog("Two", "b", 1000, 0)}
+		for _, commit := range []bool{false, true} {
+			if !commit && !b.transactional {
+				continue
+			}
+			tx, err := b.postgresUtil.BeginTx(ctx, pgx.TxOptions{})
+			require.NoError(t, err)
+			rowsAffected, err := b.blogRepository.CreateBatch(tx, ctx, blogs)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), rowsAffected)
+			var errCallback error
+			if !commit {
+				errCallback = errors.New("rollback")
+			}
+			require.NoError(t, b.postgresUtil.CommitOrRollback(tx, ctx, errCallback))
+			count, err := b.blogRepository.CountAll(b.postgresUtil.GetPool(), ctx)
+			require.NoError(t, err)
+			if commit {
+				assert.Equal(t, 2, count)
+			} else {
+				assert.Equal(t, 0, count)
+			}
+		}
+	})
+
+	t.Run("upsert by slug", func(t *testing.T) {
+		b := newBackend(t)
+		blog := newBlog("Go", "go", 1000, 0)
+		blog.Slug = pgtype.Text{Valid: true, String: "go"}
+		blog.Author = pgtype.Text{Valid: true, String: "Ada"}
+		tx, err := b.postgresUtil.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		id, inserted, err := b.blogRepository.UpsertBySlug(tx, ctx, blog)
+		require.NoError(t, err)
+		assert.True(t, inserted)
+
+		blog.Title = pgtype.Text{Valid: true, String: "Go 2"}
+		blog.Author = pgtype.Text{Valid: true, String: "Grace"}
+		secondId, inserted, err := b.blogRepository.UpsertBySlug(tx, ctx, blog)
+		require.NoError(t, err)
+		assert.False(t, inserted)
+		assert.Equal(t, id, secondId)
+		require.NoError(t, b.postgresUtil.CommitOrRollback(tx, ctx, nil))
+
+		found, err := b.blogRepository.FindById(b.postgresUtil.GetPool(), ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Go 2", found.Title.String)
+		assert.Equal(t, "go", found.Slug.String)
+		assert.Equal(t, "Grace", found.Author.String)
+		count, err := b.blogRepository.CountAll(b.postgresUtil.GetPool(), ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("savepoint rollback keeps the rest of the transaction", func(t *testing.T) {
+		b := newBackend(t)
+		if !b.transactional {
+			t.Skip("backend is not transactional")
+		}
+		tx, err := b.postgresUtil.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		first := newBlog("One", "a", 1000, 0)
+		first.Slug = pgtype.Text{Valid: true, String: "one"}
+		_, _, err = b.blogRepository.UpsertBySlug(tx, ctx, first)
+		require.NoError(t, err)
+
+		savepoint, err := tx.Begin(ctx)
+		require.NoError(t, err)
+		second := newBlog("Two", "b", 1000, 0)
+		second.Slug = pgtype.Text{Valid: true, String: "two"}
+		_, _, err = b.blogRepository.UpsertBySlug(savepoint, ctx, second)
+		require.NoError(t, err)
+		require.NoError(t, savepoint.Rollback(ctx))
+
+		require.NoError(t, b.postgresUtil.CommitOrRollback(tx, ctx, nil))
+		blogs, err := b.blogRepository.FindAll(b.postgresUtil.GetPool(), ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"One"}, titles(blogs))
+	})
+
+	t.Run("comments are upserted by source id and deleted with their post", func(t *testing.T) {
+		b := newBackend(t)
+		ids := create(t, b, newBlog("Go", "go", 1000, 0), newBlog("Rust", "rust", 2000, 0), newBlog("Zig", "zig", 3000, 0))
+		comment := func(postId int, sourceId string, content string) modelentities.BlogComment {
+			return modelentities.BlogComment{
+				PostId:    pgtype.Int4{Valid: true, Int32: int32(postId)},
+				SourceId:  pgtype.Text{Valid: true, String: sourceId},
+				Author:    pgtype.Text{Valid: true, String: "Ada"},
+				Content:   pgtype.Text{Valid: true, String: content},
+				CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+			}
+		}
+		rowsAffected, err := b.blogRepository.UpsertComments(b.postgresUtil.GetPool(), ctx, []modelentities.BlogComment{
+			comment(ids[0], "1", "first"), comment(ids[0], "2", "second"), comment(ids[1], "1", "first"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), rowsAffected)
+		_, err = b.blogRepository.UpsertComments(b.postgresUtil.GetPool(), ctx, []modelentities.BlogComment{comment(ids[0], "2", "edited")})
+		require.NoError(t, err)
+
+		counts, err := b.blogRepository.CountComments(b.postgresUtil.GetPool(), ctx, ids)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{ids[0]: 2, ids[1]: 1}, counts)
+
+		_, err = b.blogRepository.Delete(b.postgresUtil.GetPool(), ctx, ids[0])
+		require.NoError(t, err)
+		counts, err = b.blogRepository.CountComments(b.postgresUtil.GetPool(), ctx, ids)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{ids[1]: 1}, counts)
+
+		_, err = b.blogRepository.UpsertComments(b.postgresUtil.GetPool(), ctx, []modelentities.BlogComment{comment(ids[0], "3", "orphan")})
+		require.Error(t, err)
+	})
+
+	t.Run("event log pages by id and trims old events", func(t *testing.T) {
+		b := newBackend(t)
+		for _, eventType := range []string{"created", "published", "updated", "deleted"} {
+			_, err := b.blogRepository.CreateEvent(b.postgresUtil.GetPool(), ctx, modelentities.BlogEvent{
+				Type:      pgtype.Text{Valid: true, String: eventType},
+				PostId:    pgtype.Int4{Valid: true, Int32: 1},
+				Payload:   pgtype.Text{Valid: true, String: `{"id":1}`},
+				CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+			})
+			require.NoError(t, err)
+		}
+		events, err := b.blogRepository.FindEventsAfter(b.postgresUtil.GetPool(), ctx, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, []int64{2, 3}, []int64{events[0].Id.Int64, events[1].Id.Int64})
+		assert.Equal(t, "published", events[0].Type.String)
+		assert.Equal(t, `{"id":1}`, events[0].Payload.String)
+
+		rowsAffected, err := b.blogRepository.DeleteEventsBefore(b.postgresUtil.GetPool(), ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), rowsAffected)
+		events, err = b.blogRepository.FindEventsAfter(b.postgresUtil.GetPool(), ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, int64(3), events[0].Id.Int64)
+
+		id, err := b.blogRepository.CreateEvent(b.postgresUtil.GetPool(), ctx, modelentities.BlogEvent{
+			Type:      pgtype.Text{Valid: true, String: "created"},
+			PostId:    pgtype.Int4{Valid: true, Int32: 2},
+			Payload:   pgtype.Text{Valid: true, String: `{"id":2}`},
+			CreatedAt: pgtype.Int8{Valid: true, Int64: 2000},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), id)
+	})
+
+	t.Run("notifications are delivered when the transaction commits", func(t *testing.T) {
+		b := newBackend(t)
+		listenCtx, stopListening := context.WithCancel(ctx)
+		payloads := make(chan string, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- b.postgresUtil.Listen(listenCtx, "blog_events_test", func(payload string) { payloads <- payload })
+		}()
+		t.Cleanup(func() {
+			stopListening()
+			<-done
+		})
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, "", payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("listening did not start")
+		}
+
+		for _, payload := range []string{"rolled back", "committed"} {
+			tx, err := b.postgresUtil.BeginTx(ctx, pgx.TxOptions{})
+			require.NoError(t, err)
+			require.NoError(t, b.postgresUtil.Notify(tx, ctx, "blog_events_test", payload))
+			var errCallback error
+			if payload == "rolled back" {
+				errCallback = errors.New("rollback")
+			}
+			require.NoError(t, b.postgresUtil.CommitOrRollback(tx, ctx, errCallback))
+		}
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, "committed", payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the committed notification was not delivered")
+		}
+	})
+
+	t.Run("too long title is a validation error", func(t *testing.T) {
+		b := newBackend(t)
+		if !b.constraints {
+			t.Skip("backend does not enforce column lengths")
+		}
+		_, err := b.blogRepository.Create(b.postgresUtil.GetPool(), ctx, newBlog(strings.Repeat("a", 51), "a", 1000, 0))
+		require.Error(t, err)
+		assert.Equal(t, exceptions.KindValidation, exceptions.AsAppError(exceptions.NewDatabaseError(err)).Kind)
+	})
+}
@@ -0,0 +1,310 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlog(title string, tags string) modelentities.Blog {
+	return modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: title},
+		Content:   pgtype.Text{Valid: true, String: "content of " + title},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: tags},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1700000000000},
+		UpdatedAt: pgtype.Int8{Valid: true, Int64: 1700000000000},
+	}
+}
+
+var aliasDeprecation = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+var aliasSunset = time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+
+// newServer wires the blog routes the same way main does but on a memory repository
+func newServer() *echo.Echo {
+	validate, universalTranslator := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.Locale(universalTranslator))
+	postgresUtil := utils.NewFakePostgresUtil()
+	blogRepository := repositories.NewBlogMemoryRepository(newBlog("Go", "go, language"), newBlog("Rust", "rust, language"))
+	blogService := services.NewBlogService(postgresUtil, validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100)
+	blogController := controllers.NewBlogController(blogService)
+	postEventController := controllers.NewPostEventController(services.NewPostEventService(postgresUtil, blogRepository), time.Second)
+	openApiValidation := middlewares.OpenApiValidation(services.NewOpenApiService(""), true, 1<<20)
+	routes.BlogRoute(e.Group("/v1"), blogController, openApiValidation)
+	routes.PostEventRoute(e.Group("/v1"), postEventController)
+	deprecation := middlewares.Deprecation(aliasDeprecation, aliasSunset, "/v1")
+	routes.BlogRoute(e, blogController, deprecation, openApiValidation)
+	routes.PostEventRoute(e, postEventController, deprecation)
+	return e
+}
+
+func serve(e *echo.Echo, method string, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	var request *http.Request
+	if body == "" {
+		request = httptest.NewRequest(method, target, nil)
+	} else {
+		request = httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestBlogController(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		headers     map[string]string
+		wantStatus  int
+		wantCode    string
+		wantFields  []string
+		wantMessage string
+		wantBody    func(t *testing.T, body []byte)
+	}{
+		{
+			name:       "create post",
+			method:     http.MethodPost,
+			target:     "/v1/posts",
+			body:       `{"title":"Zig","content":"about zig","category":"Programming","tags":["zig"]}`,
+			wantStatus: http.StatusCreated,
+			wantBody: func(t *testing.T, body []byte) {
+				var response modelresponses.CreateResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 3, response.Id)
+				assert.Equal(t, "Zig", response.Title)
+				assert.Equal(t, []string{"zig"}, response.Tags)
+			},
+		},
+		{
+			name:       "create post with invalid json",
+			method:     http.MethodPost,
+			target:     "/v1/posts",
+			body:       `{"title":`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_body",
+		},
+		{
+			name:        "create post with missing fields",
+			method:      http.MethodPost,
+			target:      "/v1/posts",
+			body:        `{"title":"Zig"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantFields:  []string{"content", "category", "tags"},
+			wantMessage: "content is a required field",
+		},
+		{
+			name:        "create post with missing fields in indonesian",
+			method:      http.MethodPost,
+			target:      "/v1/posts",
+			body:        `{"title":"Zig"}`,
+			headers:     map[string]string{"Accept-Language": "id"},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantFields:  []string{"content", "category", "tags"},
+			wantMessage: "content wajib diisi",
+		},
+		{
+			name:       "update post",
+			method:     http.MethodPut,
+			target:     "/v1/posts/1",
+			body:       `{"title":"Go 2","content":"about go 2","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusOK,
+			wantBody: func(t *testing.T, body []byte) {
+				var response modelresponses.UpdateResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 1, response.Id)
+				assert.Equal(t, "Go 2", response.Title)
+				assert.Equal(t, "2023-11-14T22:13:20Z", response.CreatedAt)
+			},
+		},
+		{
+			name:       "update missing post",
+			method:     http.MethodPut,
+			target:     "/v1/posts/99",
+			body:       `{"title":"Go 2","content":"about go 2","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "post_not_found",
+		},
+		{
+			name:       "update post with invalid id",
+			method:     http.MethodPut,
+			target:     "/v1/posts/abc",
+			body:       `{"title":"Go 2","content":"about go 2","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "delete post",
+			method:     http.MethodDelete,
+			target:     "/v1/posts/2",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "delete missing post",
+			method:     http.MethodDelete,
+			target:     "/v1/posts/99",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "post_not_found",
+		},
+		{
+			name:       "find post by id",
+			method:     http.MethodGet,
+			target:     "/v1/posts/2",
+			wantStatus: http.StatusOK,
+			wantBody: func(t *testing.T, body []byte) {
+				var response modelresponses.FindByIdResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, 2, response.Id)
+				assert.Equal(t, "Rust", response.Title)
+				assert.Equal(t, "content of Rust", response.Content)
+			},
+		},
+		{
+			name:       "find missing post by id",
+			method:     http.MethodGet,
+			target:     "/v1/posts/99",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "post_not_found",
+		},
+		{
+			name:       "find post by invalid id",
+			method:     http.MethodGet,
+			target:     "/v1/posts/abc",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "find all posts",
+			method:     http.MethodGet,
+			target:     "/v1/posts",
+			wantStatus: http.StatusOK,
+			wantBody: func(t *testing.T, body []byte) {
+				var response []modelresponses.FindResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				require.Len(t, response, 2)
+				assert.Equal(t, "Go", response[0].Title)
+				assert.Equal(t, "Rust", response[1].Title)
+			},
+		},
+		{
+			name:       "find posts by term",
+			method:     http.MethodGet,
+			target:     "/v1/posts?term=RUST",
+			wantStatus: http.StatusOK,
+			wantBody: func(t *testing.T, body []byte) {
+				var response []modelresponses.FindResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				require.Len(t, response, 1)
+				assert.Equal(t, 2, response[0].Id)
+			},
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			target:     "/unknown",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(newServer(), test.method, test.target, test.body, test.headers)
+			require.Equal(t, test.wantStatus, recorder.Code, recorder.Body.String())
+			if test.wantCode != "" {
+				assert.Equal(t, controllers.MIMEApplicationProblemJSON, recorder.Header().Get(echo.HeaderContentType))
+				var problem modelresponses.ProblemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				assert.Equal(t, test.wantStatus, problem.Status)
+				assert.Equal(t, test.wantCode, problem.Code)
+				assert.Equal(t, strings.SplitN(test.target, "?", 2)[0], problem.Instance)
+				if test.wantFields != nil {
+					fields := []string{}
+					for _, field := range problem.Errors {
+						fields = append(fields, field.Field)
+					}
+					assert.Equal(t, test.wantFields, fields)
+					assert.Equal(t, test.wantMessage, problem.Errors[0].Message)
+				}
+			}
+			if test.wantBody != nil {
+				test.wantBody(t, recorder.Body.Bytes())
+			}
+		})
+	}
+}
+
+// TestBlogControllerIdRange runs the controller without the openapi validation, ids that do not fit the int4 column must not reach the service
+func TestBlogControllerIdRange(t *testing.T) {
+	validate, _ := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	blogRepository := repositories.NewBlogMemoryRepository(newBlog("Go", "go, language"))
+	blogService := services.NewBlogService(utils.NewFakePostgresUtil(), validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100)
+	routes.BlogRoute(e, controllers.NewBlogController(blogService))
+
+	body := `{"title":"Go 2","content":"about go 2","category":"Programming","tags":["go"]}`
+	for _, id := range []string{"4294967297", "2147483648", "-1", "0"} {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			recorder := serve(e, method, "/posts/"+id, body, nil)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, method+" "+id)
+			assert.Contains(t, recorder.Body.String(), `"code":"invalid_id"`, method+" "+id)
+		}
+	}
+	// 4294967297 is 1 once truncated to 32 bits
+	recorder := serve(e, http.MethodGet, "/posts/1", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"title":"Go"`)
+}
+
+func TestBlogControllerDeleteThenFind(t *testing.T) {
+	e := newServer()
+	recorder := serve(e, http.MethodDelete, "/v1/posts/1", "", nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = serve(e, http.MethodGet, "/v1/posts/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = serve(e, http.MethodDelete, "/v1/posts/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestBlogControllerUnversionedAliases(t *testing.T) {
+	e := newServer()
+	versioned := serve(e, http.MethodGet, "/v1/posts/1", "", nil)
+	require.Equal(t, http.StatusOK, versioned.Code)
+	assert.Empty(t, versioned.Header().Get("Deprecation"))
+
+	alias := serve(e, http.MethodGet, "/posts/1", "", nil)
+	require.Equal(t, http.StatusOK, alias.Code)
+	assert.Equal(t, versioned.Body.String(), alias.Body.String())
+	assert.Equal(t, "@1792368000", alias.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", alias.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/posts/1>; rel="successor-version"`, alias.Header().Get("Link"))
+
+	alias = serve(e, http.MethodGet, "/posts/99", "", nil)
+	assert.Equal(t, http.StatusNotFound, alias.Code)
+	assert.Equal(t, "@1792368000", alias.Header().Get("Deprecation"), "error responses of an alias are marked too")
+}
@@ -0,0 +1,260 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/middlewares"
+	"blogging-platform-api/protos/blogpb"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGrpcClient serves the grpc BlogService the same way main does over an in memory listener
+func newGrpcClient(t *testing.T, blogRepository repositories.BlogRepository) *grpc.ClientConn {
+	validate, universalTranslator := utils.NewValidator()
+	blogService := services.NewBlogService(utils.NewFakePostgresUtil(), validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middlewares.GrpcUnaryContext(universalTranslator), middlewares.GrpcUnaryAccessLog()),
+		grpc.ChainStreamInterceptor(middlewares.GrpcStreamContext(universalTranslator), middlewares.GrpcStreamAccessLog()),
+	)
+	routes.BlogGrpcRoute(server, controllers.NewBlogGrpcController(blogService))
+	reflection.Register(server)
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	connection, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { connection.Close() })
+	return connection
+}
+
+func errorInfoOf(t *testing.T, err error) (*status.Status, *errdetails.ErrorInfo, *errdetails.BadRequest) {
+	t.Helper()
+	grpcStatus, ok := status.FromError(err)
+	require.True(t, ok, err)
+	var errorInfo *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range grpcStatus.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			errorInfo = detail
+		case *errdetails.BadRequest:
+			badRequest = detail
+		}
+	}
+	require.NotNil(t, errorInfo)
+	return grpcStatus, errorInfo, badRequest
+}
+
+func TestBlogGrpcController(t *testing.T) {
+	client := blogpb.NewBlogServiceClient(newGrpcClient(t, repositories.NewBlogMemoryRepository(newBlog("Go", "go, language"), newBlog("Rust", "rust, language"))))
+	ctx := context.Background()
+
+	var header metadata.MD
+	post, err := client.CreatePost(metadata.AppendToOutgoingContext(ctx, "x-request-id", "grpc-1"),
+		&blogpb.CreatePostRequest{Post: &blogpb.PostInput{Title: "Zig", Content: "about zig", Category: "Programming", Tags: []string{"zig"}}}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), post.Id)
+	assert.Equal(t, []string{"zig"}, post.Tags)
+	assert.NotNil(t, post.CreateTime)
+	assert.Equal(t, []string{"grpc-1"}, header.Get("x-request-id"))
+
+	post, err = client.UpdatePost(ctx, &blogpb.UpdatePostRequest{Id: 3, Post: &blogpb.PostInput{Title: "Zig 2", Content: "about zig", Category: "Programming", Tags: []string{"zig"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "Zig 2", post.Title)
+
+	post, err = client.GetPost(ctx, &blogpb.GetPostRequest{Id: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "Zig 2", post.Title)
+
+	_, err = client.DeletePost(ctx, &blogpb.DeletePostRequest{Id: 3})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		call       func() error
+		wantCode   codes.Code
+		wantReason string
+		wantFields []string
+	}{
+		{
+			name:       "get deleted post",
+			call:       func() error { _, err := client.GetPost(ctx, &blogpb.GetPostRequest{Id: 3}); return err },
+			wantCode:   codes.NotFound,
+			wantReason: "post_not_found",
+		},
+		{
+			name:       "delete deleted post",
+			call:       func() error { _, err := client.DeletePost(ctx, &blogpb.DeletePostRequest{Id: 3}); return err },
+			wantCode:   codes.NotFound,
+			wantReason: "post_not_found",
+		},
+		{
+			name:       "id out of range",
+			call:       func() error { _, err := client.GetPost(ctx, &blogpb.GetPostRequest{Id: 1 << 40}); return err },
+			wantCode:   codes.InvalidArgument,
+			wantReason: "invalid_id",
+		},
+		{
+			name: "invalid post",
+			call: func() error {
+				_, err := client.CreatePost(ctx, &blogpb.CreatePostRequest{Post: &blogpb.PostInput{Title: "Zig"}})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "validation_failed",
+			wantFields: []string{"content", "category", "tags"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grpcStatus, errorInfo, badRequest := errorInfoOf(t, test.call())
+			assert.Equal(t, test.wantCode, grpcStatus.Code())
+			assert.Equal(t, test.wantReason, errorInfo.Reason)
+			assert.Equal(t, "blogging-platform-api", errorInfo.Domain)
+			if test.wantFields == nil {
+				assert.Nil(t, badRequest)
+				return
+			}
+			require.NotNil(t, badRequest)
+			var fields []string
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+			assert.ElementsMatch(t, test.wantFields, fields)
+		})
+	}
+}
+
+func TestBlogGrpcControllerValidationLocale(t *testing.T) {
+	client := blogpb.NewBlogServiceClient(newGrpcClient(t, repositories.NewBlogMemoryRepository()))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "id")
+	_, err := client.CreatePost(ctx, &blogpb.CreatePostRequest{Post: &blogpb.PostInput{Title: "Zig", Category: "Programming", Tags: []string{"zig"}}})
+	_, _, badRequest := errorInfoOf(t, err)
+	require.NotNil(t, badRequest)
+	require.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "content wajib diisi", badRequest.FieldViolations[0].Description)
+}
+
+func TestBlogGrpcControllerListPosts(t *testing.T) {
+	// more posts than one page of ListPosts so the stream has to follow the cursor
+	blogRepository := repositories.NewBlogMemoryRepository()
+	for i := 1; i <= 250; i++ {
+		tags := "odd"
+		if i%2 == 0 {
+			tags = "even"
+		}
+		_, err := blogRepository.Create(nil, context.Background(), newBlog("Post "+strconv.Itoa(i), tags))
+		require.NoError(t, err)
+	}
+	client := blogpb.NewBlogServiceClient(newGrpcClient(t, blogRepository))
+
+	receive := func(request *blogpb.ListPostsRequest) (ids []int64) {
+		stream, err := client.ListPosts(context.Background(), request)
+		require.NoError(t, err)
+		for {
+			post, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			require.NoError(t, err)
+			ids = append(ids, post.Id)
+		}
+	}
+	ids := receive(&blogpb.ListPostsRequest{})
+	require.Len(t, ids, 250)
+	assert.Equal(t, int64(1), ids[0])
+	assert.Equal(t, int64(250), ids[249])
+
+	ids = receive(&blogpb.ListPostsRequest{Term: "even"})
+	assert.Len(t, ids, 125)
+
+	assert.Empty(t, receive(&blogpb.ListPostsRequest{Category: "Cooking"}))
+}
+
+// TestBlogGrpcControllerTags fails when an rpc returns the stored tags without trimming them like ListPosts does
+func TestBlogGrpcControllerTags(t *testing.T) {
+	client := blogpb.NewBlogServiceClient(newGrpcClient(t, repositories.NewBlogMemoryRepository(newBlog("Go", "go, language"))))
+	ctx := context.Background()
+	want := []string{"go", "language"}
+
+	post, err := client.GetPost(ctx, &blogpb.GetPostRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, want, post.Tags)
+	stream, err := client.ListPosts(ctx, &blogpb.ListPostsRequest{})
+	require.NoError(t, err)
+	listed, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, want, listed.Tags)
+
+	post, err = client.UpdatePost(ctx, &blogpb.UpdatePostRequest{Id: 1, Post: &blogpb.PostInput{Title: "Go", Content: "about go", Category: "Programming", Tags: []string{" go", "language "}}})
+	require.NoError(t, err)
+	assert.Equal(t, want, post.Tags)
+	post, err = client.CreatePost(ctx, &blogpb.CreatePostRequest{Post: &blogpb.PostInput{Title: "Zig", Content: "about zig", Category: "Programming", Tags: []string{" zig "}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zig"}, post.Tags)
+	post, err = client.GetPost(ctx, &blogpb.GetPostRequest{Id: post.Id})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zig"}, post.Tags)
+}
+
+func TestBlogGrpcReflection(t *testing.T) {
+	client := reflectionpb.NewServerReflectionClient(newGrpcClient(t, repositories.NewBlogMemoryRepository()))
+	stream, err := client.ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}))
+	response, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "blog.v1.BlogService")
+}
+
+// TestGrpcCode keeps the grpc codes in step with the http status codes of HttpCode
+func TestGrpcCode(t *testing.T) {
+	tests := []struct {
+		kind     exceptions.Kind
+		wantCode codes.Code
+		wantHttp int
+	}{
+		{kind: exceptions.KindValidation, wantCode: codes.InvalidArgument, wantHttp: http.StatusBadRequest},
+		{kind: exceptions.KindNotFound, wantCode: codes.NotFound, wantHttp: http.StatusNotFound},
+		{kind: exceptions.KindConflict, wantCode: codes.AlreadyExists, wantHttp: http.StatusConflict},
+		{kind: exceptions.KindAborted, wantCode: codes.Aborted, wantHttp: http.StatusConflict},
+		{kind: exceptions.KindUnavailable, wantCode: codes.Unavailable, wantHttp: http.StatusServiceUnavailable},
+		{kind: exceptions.KindInternal, wantCode: codes.Internal, wantHttp: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.kind.String(), func(t *testing.T) {
+			assert.Equal(t, test.wantCode, controllers.GrpcCode(test.kind))
+			assert.Equal(t, test.wantHttp, controllers.HttpCode(test.kind))
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts the calls of the repository methods the graphql loaders batch
+type countingRepository struct {
+	repositories.BlogRepository
+	mutex sync.Mutex
+	calls map[string]int
+}
+
+func (repository *countingRepository) count(method string) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.calls[method]++
+}
+
+func (repository *countingRepository) FindById(querier repositories.Querier, ctx context.Context, id int) (modelentities.Blog, error) {
+	repository.count("FindById")
+	return repository.BlogRepository.FindById(querier, ctx, id)
+}
+
+func (repository *countingRepository) FindByIds(querier repositories.Querier, ctx context.Context, ids []int) ([]modelentities.Blog, error) {
+	repository.count("FindByIds")
+	return repository.BlogRepository.FindByIds(querier, ctx, ids)
+}
+
+func (repository *countingRepository) FindCategories(querier repositories.Querier, ctx context.Context) ([]modelentities.Taxonomy, error) {
+	repository.count("FindCategories")
+	return repository.BlogRepository.FindCategories(querier, ctx)
+}
+
+func (repository *countingRepository) FindTags(querier repositories.Querier, ctx context.Context) ([]modelentities.Taxonomy, error) {
+	repository.count("FindTags")
+	return repository.BlogRepository.FindTags(querier, ctx)
+}
+
+func (repository *countingRepository) FindCategoriesByNames(querier repositories.Querier, ctx context.Context, names []string) ([]modelentities.Taxonomy, error) {
+	repository.count("FindCategoriesByNames")
+	return repository.BlogRepository.FindCategoriesByNames(querier, ctx, names)
+}
+
+func (repository *countingRepository) FindTagsByNames(querier repositories.Querier, ctx context.Context, names []string) ([]modelentities.Taxonomy, error) {
+	repository.count("FindTagsByNames")
+	return repository.BlogRepository.FindTagsByNames(querier, ctx, names)
+}
+
+func (repository *countingRepository) CountComments(querier repositories.Querier, ctx context.Context, postIds []int) (map[int]int, error) {
+	repository.count("CountComments")
+	return repository.BlogRepository.CountComments(querier, ctx, postIds)
+}
+
+func newGraphqlServer(t *testing.T, maxComplexity int) (*echo.Echo, *countingRepository) {
+	validate, universalTranslator := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.Locale(universalTranslator))
+	life := newBlog("Cooking", "food, life")
+	life.Category = pgtype.Text{Valid: true, String: "Life"}
+	life.Slug = pgtype.Text{Valid: true, String: "cooking"}
+	blogRepository := &countingRepository{
+		BlogRepository: repositories.NewBlogMemoryRepository(newBlog("Go", "go, language"), newBlog("Rust", "rust, language"), life),
+		calls:          map[string]int{},
+	}
+	comment := func(sourceId string) modelentities.BlogComment {
+		return modelentities.BlogComment{
+			PostId:    pgtype.Int4{Valid: true, Int32: 1},
+			SourceId:  pgtype.Text{Valid: true, String: sourceId},
+			Author:    pgtype.Text{Valid: true, String: "Grace"},
+			Content:   pgtype.Text{Valid: true, String: "comment " + sourceId},
+			CreatedAt: pgtype.Int8{Valid: true, Int64: 1700000000000},
+		}
+	}
+	_, err := blogRepository.UpsertComments(nil, context.Background(), []modelentities.BlogComment{comment("1"), comment("2")})
+	require.NoError(t, err)
+	blogService := services.NewBlogService(utils.NewFakePostgresUtil(), validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100)
+	graphqlService, err := services.NewGraphqlService(blogService, maxComplexity)
+	require.NoError(t, err)
+	routes.GraphqlRoute(e, controllers.NewGraphqlController(graphqlService), middlewares.BodyLimit(1<<10))
+	return e, blogRepository
+}
+
+func graphql(t *testing.T, e *echo.Echo, query string, variables map[string]any, headers map[string]string) (response modelresponses.GraphqlResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	recorder := serve(e, http.MethodPost, "/graphql", string(body), headers)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return
+}
+
+func dataOf(t *testing.T, response modelresponses.GraphqlResponse) string {
+	t.Helper()
+	data, err := json.Marshal(response.Data)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestGraphqlQueries(t *testing.T) {
+	e, _ := newGraphqlServer(t, 1000)
+
+	response := graphql(t, e, `{ byId: post(id: 1) { id title slug tags { name } } bySlug: post(slug: "cooking") { id category { name } } missing: post(id: 99) { id } }`, nil, nil)
+	assert.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"byId":{"id":"1","title":"Go","slug":null,"tags":[{"name":"go"},{"name":"language"}]},"bySlug":{"id":"3","category":{"name":"Life"}},"missing":null}`, dataOf(t, response))
+
+	response = graphql(t, e, `query($after: String) { posts(first: 1, after: $after, filter: {term: "language"}) { edges { cursor node { title } } pageInfo { hasNextPage endCursor } } }`, nil, nil)
+	require.Empty(t, response.Errors)
+	var page struct {
+		Posts struct {
+			Edges []struct {
+				Node struct{ Title string }
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   string
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(dataOf(t, response)), &page))
+	assert.Equal(t, "Go", page.Posts.Edges[0].Node.Title)
+	assert.True(t, page.Posts.PageInfo.HasNextPage)
+
+	response = graphql(t, e, `query($after: String) { posts(first: 1, after: $after, filter: {term: "language"}) { edges { node { title } } pageInfo { hasNextPage } } }`, map[string]any{"after": page.Posts.PageInfo.EndCursor}, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"posts":{"edges":[{"node":{"title":"Rust"}}],"pageInfo":{"hasNextPage":false}}}`, dataOf(t, response))
+
+	response = graphql(t, e, `{ posts(filter: {category: "Life"}) { edges { node { title } } } }`, nil, nil)
+	assert.JSONEq(t, `{"posts":{"edges":[{"node":{"title":"Cooking"}}]}}`, dataOf(t, response))
+
+	response = graphql(t, e, `{ posts(after: "nope") { edges { cursor } } }`, nil, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "invalid_cursor", response.Errors[0].Extensions.Code)
+	assert.Nil(t, response.Data)
+
+	response = graphql(t, e, `{ post(id: 1, slug: "go") { id } }`, nil, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "bad_request", response.Errors[0].Extensions.Code)
+
+	response = graphql(t, e, `{ post(id: 1) { author } }`, nil, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"post":{"author":null}}`, dataOf(t, response))
+
+	response = graphql(t, e, `{ post(id: 1) { likes } }`, nil, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Contains(t, response.Errors[0].Message, `Cannot query field "likes" on type "Post"`)
+	assert.Nil(t, response.Errors[0].Extensions)
+}
+
+func TestGraphqlMutations(t *testing.T) {
+	e, _ := newGraphqlServer(t, 1000)
+
+	response := graphql(t, e, `mutation($input: PostInput!) { createPost(input: $input) { id title tags { name postCount } } }`,
+		map[string]any{"input": map[string]any{"title": "Zig", "content": "about zig", "category": "Programming", "tags": []string{"zig", "language"}}}, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"createPost":{"id":"4","title":"Zig","tags":[{"name":"zig","postCount":1},{"name":"language","postCount":3}]}}`, dataOf(t, response))
+
+	response = graphql(t, e, `mutation { updatePost(id: 4, input: {title: "Zig 2", content: "about zig", category: "Programming", tags: ["zig"]}) { title } }`, nil, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"updatePost":{"title":"Zig 2"}}`, dataOf(t, response))
+
+	response = graphql(t, e, `mutation { updatePost(id: 4, input: {title: "Zig", content: "", category: "Programming", tags: []}) { title } }`, nil, map[string]string{"Accept-Language": "id"})
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "validation_failed", response.Errors[0].Extensions.Code)
+	require.Len(t, response.Errors[0].Extensions.Errors, 1)
+	assert.Equal(t, "content", response.Errors[0].Extensions.Errors[0].Field)
+	assert.Equal(t, "content wajib diisi", response.Errors[0].Extensions.Errors[0].Message)
+	assert.Equal(t, []any{"updatePost"}, response.Errors[0].Path)
+
+	response = graphql(t, e, `mutation { deletePost(id: 4) }`, nil, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"deletePost":"4"}`, dataOf(t, response))
+
+	response = graphql(t, e, `mutation { deletePost(id: 4) }`, nil, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "post_not_found", response.Errors[0].Extensions.Code)
+	assert.Equal(t, "post not found", response.Errors[0].Message)
+
+	for _, id := range []string{"abc", "0", "-1", "2147483648", "4294967297"} {
+		response = graphql(t, e, `mutation($id: ID!) { deletePost(id: $id) }`, map[string]any{"id": id}, nil)
+		require.Len(t, response.Errors, 1, id)
+		assert.Equal(t, "invalid_id", response.Errors[0].Extensions.Code, id)
+	}
+
+	// 4294967297 would be post 1 if it was truncated to 32 bits
+	response = graphql(t, e, `{ post(id: 4294967297) { id } }`, nil, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "invalid_id", response.Errors[0].Extensions.Code)
+}
+
+// TestGraphqlBatching fails when a resolver goes back to loading related data once per post
+func TestGraphqlBatching(t *testing.T) {
+	e, blogRepository := newGraphqlServer(t, 1000)
+	response := graphql(t, e, `{
+		posts { edges { node { commentCount category { name postCount } tags { name postCount } } } }
+		a: post(id: 1) { title commentCount category { postCount } }
+		b: post(id: 2) { title }
+		c: post(id: 3) { title }
+	}`, nil, nil)
+	require.Empty(t, response.Errors)
+	assert.Contains(t, dataOf(t, response), `"category":{"name":"Programming","postCount":2}`)
+	assert.Contains(t, dataOf(t, response), `{"name":"language","postCount":2}`)
+	assert.Contains(t, dataOf(t, response), `"a":{"category":{"postCount":2},"commentCount":2`)
+	assert.Contains(t, dataOf(t, response), `"category":{"name":"Life","postCount":1},"commentCount":0`)
+	assert.Equal(t, map[string]int{"FindByIds": 1, "FindCategoriesByNames": 1, "FindTagsByNames": 1, "CountComments": 1}, blogRepository.calls)
+
+	blogRepository.calls = map[string]int{}
+	response = graphql(t, e, `{ categories { name postCount } tags { name postCount } }`, nil, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"categories":[{"name":"Life","postCount":1},{"name":"Programming","postCount":2}],
+		"tags":[{"name":"food","postCount":1},{"name":"go","postCount":1},{"name":"language","postCount":2},{"name":"life","postCount":1},{"name":"rust","postCount":1}]}`, dataOf(t, response))
+	assert.Equal(t, map[string]int{"FindCategories": 1, "FindTags": 1}, blogRepository.calls)
+}
+
+func TestGraphqlComplexityLimit(t *testing.T) {
+	e, blogRepository := newGraphqlServer(t, 100)
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantError bool
+	}{
+		{name: "single post", query: `{ post(id: 1) { title } }`},
+		{name: "small page", query: `{ posts(first: 5) { edges { node { title category { name } } } } }`},
+		{name: "default page is counted 20 times", query: `{ posts { edges { node { title content category { name postCount } } } } }`, wantError: true},
+		{name: "first from a variable", query: `query($first: Int) { posts(first: $first) { edges { node { title content } } } }`, variables: map[string]any{"first": 50}, wantError: true},
+		{name: "first from a variable default", query: `query($first: Int = 50) { posts(first: $first) { edges { node { title content } } } }`, wantError: true},
+		{name: "fields of fragments", query: `{ posts(first: 40) { ...page } } fragment page on PostConnection { edges { node { title } } }`, wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogRepository.calls = map[string]int{}
+			response := graphql(t, e, test.query, test.variables, nil)
+			if !test.wantError {
+				assert.Empty(t, response.Errors)
+				return
+			}
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, "query_too_complex", response.Errors[0].Extensions.Code)
+			assert.Nil(t, response.Data)
+			assert.Empty(t, blogRepository.calls)
+		})
+	}
+}
+
+func TestGraphqlControllerBadRequest(t *testing.T) {
+	e, _ := newGraphqlServer(t, 1000)
+	recorder := serve(e, http.MethodPost, "/graphql", `{"variables":{}}`, nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"invalid_body"`)
+}
+
+func TestGraphqlReadYourWrites(t *testing.T) {
+	e, _ := newGraphqlServer(t, 1000)
+	e.Use(middlewares.ReadYourWrites(5*time.Second, true))
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		wantCookie    bool
+	}{
+		{name: "query", query: `{ post(id: 1) { title } }`},
+		{name: "invalid query", query: `{ post(id: 1) { likes } }`},
+		{name: "mutation", query: `mutation { updatePost(id: 1, input: {title: "Go", content: "about go", category: "Programming", tags: ["go"]}) { title } }`, wantCookie: true},
+		{name: "query of a document with a mutation", query: `query read { post(id: 1) { title } } mutation write { deletePost(id: 2) }`, operationName: "read"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{"query": test.query, "operationName": test.operationName})
+			require.NoError(t, err)
+			recorder := serve(e, http.MethodPost, "/graphql", string(body), nil)
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			cookies := recorder.Result().Cookies()
+			if !test.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.Equal(t, middlewares.LastWriteCookie, cookies[0].Name)
+		})
+	}
+}
+
+func TestGraphqlBodyLimit(t *testing.T) {
+	e, blogRepository := newGraphqlServer(t, 1000)
+	body, err := json.Marshal(map[string]any{"query": `{ post(id: 1) { title } }` + strings.Repeat(" ", 1<<10)})
+	require.NoError(t, err)
+	recorder := serve(e, http.MethodPost, "/graphql", string(body), nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	var problem modelresponses.ProblemResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	assert.Equal(t, "payload_too_large", problem.Code)
+	assert.Empty(t, blogRepository.calls)
+}
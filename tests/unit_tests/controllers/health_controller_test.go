@@ -0,0 +1,134 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingMigrationUtil only answers Pending, the health checks use nothing else
+type pendingMigrationUtil struct {
+	utils.MigrationUtil
+	pending int
+	err     error
+}
+
+func (util *pendingMigrationUtil) Pending(ctx context.Context) (int, error) {
+	return util.pending, util.err
+}
+
+func newHealthServer(postgresUtil *utils.FakePostgresUtilImplementation, migrationUtil *pendingMigrationUtil) (*echo.Echo, services.HealthService) {
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	healthService := services.NewHealthService(postgresUtil, migrationUtil, time.Second)
+	routes.HealthRoute(e, controllers.NewHealthController(healthService))
+	return e, healthService
+}
+
+func decodeHealth(t *testing.T, body []byte) (response modelresponses.HealthResponse) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(body, &response))
+	return
+}
+
+func TestHealthController(t *testing.T) {
+	tests := []struct {
+		name          string
+		pingError     error
+		pending       int
+		pendingError  error
+		target        string
+		wantStatus    int
+		wantHealth    string
+		wantDatabase  string
+		wantMigration string
+		wantDetail    string
+	}{
+		{name: "liveness", target: "/healthz", wantStatus: http.StatusOK, wantHealth: "up"},
+		{name: "liveness ignores the database", pingError: errors.New("connection refused"), target: "/healthz", wantStatus: http.StatusOK, wantHealth: "up"},
+		{name: "ready", target: "/readyz", wantStatus: http.StatusOK, wantHealth: "up", wantDatabase: "up", wantMigration: "up"},
+		{
+			name:          "not ready when the database is down",
+			pingError:     errors.New("connection refused"),
+			pendingError:  errors.New("connection refused"),
+			target:        "/readyz",
+			wantStatus:    http.StatusServiceUnavailable,
+			wantHealth:    "down",
+			wantDatabase:  "down",
+			wantMigration: "down",
+			// the driver error is logged, it may name the host and the user
+			wantDetail: "unavailable",
+		},
+		{
+			name:          "not ready with pending migrations",
+			pending:       2,
+			target:        "/readyz",
+			wantStatus:    http.StatusServiceUnavailable,
+			wantHealth:    "down",
+			wantDatabase:  "up",
+			wantMigration: "down",
+			wantDetail:    "2 migrations pending",
+		},
+		{
+			name:          "check that times out",
+			pendingError:  fmt.Errorf("count migrations: %w", context.DeadlineExceeded),
+			target:        "/health",
+			wantStatus:    http.StatusServiceUnavailable,
+			wantHealth:    "down",
+			wantDatabase:  "up",
+			wantMigration: "down",
+			wantDetail:    "timed out",
+		},
+		{name: "health", target: "/health", wantStatus: http.StatusOK, wantHealth: "up", wantDatabase: "up", wantMigration: "up"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postgresUtil := utils.NewFakePostgresUtil()
+			postgresUtil.PingError = test.pingError
+			e, _ := newHealthServer(postgresUtil, &pendingMigrationUtil{pending: test.pending, err: test.pendingError})
+			recorder := serve(e, http.MethodGet, test.target, "", nil)
+			assert.Equal(t, test.wantStatus, recorder.Code)
+			assert.Equal(t, "no-store", recorder.Header().Get(echo.HeaderCacheControl))
+			response := decodeHealth(t, recorder.Body.Bytes())
+			assert.Equal(t, test.wantHealth, response.Status)
+			assert.Equal(t, test.wantDatabase, response.Checks["database"].Status)
+			assert.Equal(t, test.wantMigration, response.Checks["migrations"].Status)
+			if test.wantDetail != "" {
+				assert.Equal(t, test.wantDetail, response.Checks["migrations"].Detail)
+			}
+		})
+	}
+}
+
+func TestHealthControllerDraining(t *testing.T) {
+	e, healthService := newHealthServer(utils.NewFakePostgresUtil(), &pendingMigrationUtil{})
+	healthService.Drain()
+
+	recorder := serve(e, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	response := decodeHealth(t, recorder.Body.Bytes())
+	assert.Equal(t, "draining", response.Status)
+	assert.Empty(t, response.Checks, "a draining instance does not check its dependencies")
+
+	recorder = serve(e, http.MethodGet, "/health", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	response = decodeHealth(t, recorder.Body.Bytes())
+	assert.Equal(t, "draining", response.Status)
+	assert.Equal(t, "up", response.Checks["database"].Status)
+
+	recorder = serve(e, http.MethodGet, "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, "a draining process is still alive")
+}
@@ -0,0 +1,116 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImportServer wires the import route the same way main does with an import limit of maxSize bytes
+func newImportServer(maxSize int64) *echo.Echo {
+	validate, _ := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	importService := services.NewImportService(utils.NewFakePostgresUtil(), validate, repositories.NewBlogMemoryRepository(), maxSize, maxSize)
+	routes.ImportRoute(e.Group("/v1"), controllers.NewImportController(importService), middlewares.BodyLimit(maxSize))
+	return e
+}
+
+func multipartBody(t *testing.T, name string, content string) (body *bytes.Buffer, contentType string) {
+	body = &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	file, err := writer.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = io.WriteString(file, content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestImportController(t *testing.T) {
+	post := `{"title":"Go","content":"about go","category":"Programming","tags":["go"]}` + "\n"
+	large := strings.Repeat(post, 100)
+	tests := []struct {
+		name        string
+		body        func() (io.Reader, string)
+		chunked     bool
+		wantStatus  int
+		wantCode    string
+		wantRecords int
+	}{
+		{
+			name:        "raw body",
+			body:        func() (io.Reader, string) { return strings.NewReader(post), "application/x-ndjson" },
+			wantStatus:  http.StatusOK,
+			wantRecords: 1,
+		},
+		{
+			name: "multipart upload",
+			body: func() (io.Reader, string) {
+				return multipartBody(t, "posts.jsonl", post)
+			},
+			wantStatus:  http.StatusOK,
+			wantRecords: 1,
+		},
+		{
+			name:       "raw body over the limit",
+			body:       func() (io.Reader, string) { return strings.NewReader(large), "application/x-ndjson" },
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "payload_too_large",
+		},
+		{
+			name:       "chunked body over the limit",
+			body:       func() (io.Reader, string) { return strings.NewReader(large), "application/x-ndjson" },
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "payload_too_large",
+		},
+		{
+			name: "chunked multipart upload over the limit",
+			body: func() (io.Reader, string) {
+				return multipartBody(t, "posts.jsonl", large)
+			},
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "payload_too_large",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, contentType := test.body()
+			request := httptest.NewRequest(http.MethodPost, "/v1/posts/import", body)
+			request.Header.Set(echo.HeaderContentType, contentType)
+			if test.chunked {
+				request.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			newImportServer(1000).ServeHTTP(recorder, request)
+			require.Equal(t, test.wantStatus, recorder.Code, recorder.Body.String())
+			if test.wantCode != "" {
+				var problem modelresponses.ProblemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				assert.Equal(t, test.wantCode, problem.Code)
+				return
+			}
+			var response modelresponses.ImportResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, test.wantRecords, response.Imported)
+		})
+	}
+}
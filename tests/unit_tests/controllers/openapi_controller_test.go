@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/docs"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var openApiPathParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// TestOpenApiMatchesBlogRoutes fails when a route is added to or removed from the v1 routes without describing it in the spec
+func TestOpenApiMatchesBlogRoutes(t *testing.T) {
+	e := newServer()
+	for _, router := range []routes.Router{e.Group("/v1"), e} {
+		routes.ImportRoute(router, controllers.NewImportController(nil))
+		routes.WordpressImportRoute(router, controllers.NewWordpressImportController(nil))
+		routes.ExportRoute(router, controllers.NewExportController(nil))
+	}
+	var registered []string
+	for _, route := range e.Routes() {
+		registered = append(registered, route.Method+" "+route.Path)
+	}
+
+	var described []string
+	for path, item := range services.NewOpenApiService("").Document().Paths {
+		for method := range item {
+			described = append(described, strings.ToUpper(method)+" "+openApiPathParam.ReplaceAllString(path, ":$1"))
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(described)
+	assert.Equal(t, registered, described)
+}
+
+func TestOpenApiController(t *testing.T) {
+	e := newServer()
+	routes.OpenApiRoute(e, controllers.NewOpenApiController(services.NewOpenApiService("https://blog.example.com")))
+
+	recorder := serve(e, http.MethodGet, "/openapi.json", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var document modelresponses.OpenApiResponse
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Equal(t, "3.1.0", document.Openapi)
+	assert.Equal(t, "https://blog.example.com", document.Servers[0].Url)
+
+	createRequest := document.Components.Schemas["CreateRequest"]
+	require.NotNil(t, createRequest)
+	assert.ElementsMatch(t, []string{"title", "content", "category", "tags"}, createRequest.Required)
+	assert.Equal(t, 50, *createRequest.Properties["title"].MaxLength)
+	assert.Equal(t, 10, *createRequest.Properties["tags"].MaxItems)
+	assert.Equal(t, 30, *createRequest.Properties["tags"].Items.MaxLength)
+
+	findPosts := document.Paths["/v1/posts"]["get"]
+	assert.False(t, findPosts.Deprecated)
+	assert.True(t, document.Paths["/posts"]["get"].Deprecated)
+	assert.Equal(t, "array", findPosts.Responses["200"].Content["application/json"].Schema.Type)
+	assert.Equal(t, "#/components/schemas/FindResponse", findPosts.Responses["200"].Content["application/json"].Schema.Items.Ref)
+	assert.Equal(t, "#/components/schemas/ProblemResponse", document.Paths["/posts/{id}"]["get"].Responses["404"].Content["application/problem+json"].Schema.Ref)
+
+	importPosts := document.Paths["/v1/posts/import"]["post"]
+	assert.Equal(t, "binary", importPosts.RequestBody.Content["application/zip"].Schema.Format)
+	assert.Equal(t, []string{"file"}, importPosts.RequestBody.Content["multipart/form-data"].Schema.Required)
+	assert.Equal(t, "#/components/schemas/ImportResponse", importPosts.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Contains(t, importPosts.Responses, "413")
+	assert.Equal(t, "#/components/schemas/WordpressImportResponse", document.Paths["/v1/posts/import/wordpress"]["post"].Responses["200"].Content["application/json"].Schema.Ref)
+	exportPosts := document.Paths["/v1/posts/export"]["get"]
+	assert.Equal(t, []string{"jsonl", "csv", "markdown-zip"}, exportPosts.Parameters[0].Schema.Enum)
+	assert.Contains(t, exportPosts.Responses["200"].Content, "text/csv")
+
+	recorder = serve(e, http.MethodGet, "/docs", "", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `spec-url="/openapi.json"`)
+	assert.Contains(t, recorder.Body.String(), `<script src="/docs/redoc.standalone.js">`)
+
+	recorder = serve(e, http.MethodGet, "/docs/redoc.standalone.js", "", nil)
+	if docs.Redoc == nil {
+		assert.Equal(t, http.StatusFound, recorder.Code)
+		assert.Equal(t, docs.RedocUrl, recorder.Header().Get(echo.HeaderLocation))
+	} else {
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, docs.Redoc, recorder.Body.Bytes())
+	}
+}
@@ -0,0 +1,186 @@
+package controllers_test
+
+import (
+	"blogging-platform-api/controllers"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEventServer serves the blog and post event routes over http because the stream is only read while it is written,
+// stop ends the event service like the shutdown of main does
+func newEventServer(t *testing.T, eventLogSize int) (server *httptest.Server, stop func()) {
+	validate, _ := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	postgresUtil := utils.NewFakePostgresUtil()
+	blogRepository := repositories.NewBlogMemoryRepository()
+	blogService := services.NewBlogService(postgresUtil, validate, blogRepository, utils.NewDatabaseRetryPolicy(3), eventLogSize)
+	postEventService := services.NewPostEventService(postgresUtil, blogRepository)
+	routes.BlogRoute(e.Group("/v1"), controllers.NewBlogController(blogService))
+	routes.PostEventRoute(e.Group("/v1"), controllers.NewPostEventController(postEventService, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		postEventService.Run(ctx)
+		close(done)
+	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	require.Eventually(t, func() bool {
+		subscription, err := postEventService.Subscribe(context.Background(), "")
+		if err != nil {
+			return false
+		}
+		postEventService.Unsubscribe(subscription)
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	server = httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server, stop
+}
+
+type sseEvent struct {
+	id        string
+	eventType string
+	data      modelresponses.PostEventResponse
+}
+
+// openStream returns the status of the stream and a function reading its next event, heartbeats are skipped
+func openStream(t *testing.T, server *httptest.Server, lastEventId string) (*http.Response, func() (sseEvent, bool)) {
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/v1/posts/events", nil)
+	require.NoError(t, err)
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+	scanner := bufio.NewScanner(response.Body)
+	next := func() (event sseEvent, ok bool) {
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "" && event.id != "":
+				return event, true
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.eventType = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data))
+			}
+		}
+		return event, false
+	}
+	return response, next
+}
+
+func request(t *testing.T, server *httptest.Server, method string, target string, body string) {
+	t.Helper()
+	request, err := http.NewRequest(method, server.URL+target, strings.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Less(t, response.StatusCode, 300)
+}
+
+func TestPostEventController(t *testing.T) {
+	server, stop := newEventServer(t, 100)
+	response, next := openStream(t, server, "")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, controllers.MIMETextEventStream, response.Header.Get(echo.HeaderContentType))
+	assert.Equal(t, "no-cache", response.Header.Get(echo.HeaderCacheControl))
+
+	post := `{"title":"Zig","content":"about zig","category":"Programming","tags":["zig"]}`
+	request(t, server, http.MethodPost, "/v1/posts", post)
+	request(t, server, http.MethodPut, "/v1/posts/1", strings.Replace(post, "Zig", "Zig 2", 1))
+	request(t, server, http.MethodDelete, "/v1/posts/1", "")
+
+	want := []struct {
+		id        string
+		eventType string
+		title     string
+	}{
+		{"1", "created", "Zig"},
+		{"2", "published", "Zig"},
+		{"3", "updated", "Zig 2"},
+		{"4", "deleted", "Zig 2"},
+	}
+	for _, want := range want {
+		event, ok := next()
+		require.True(t, ok)
+		assert.Equal(t, want.id, event.id)
+		assert.Equal(t, want.eventType, event.eventType)
+		assert.Equal(t, want.eventType, event.data.Type)
+		require.NotNil(t, event.data.Post)
+		assert.Equal(t, want.title, event.data.Post.Title)
+		assert.Equal(t, 1, event.data.Post.Id)
+	}
+
+	t.Run("resume after the last event id", func(t *testing.T) {
+		_, next := openStream(t, server, "2")
+		for _, wantId := range []string{"3", "4"} {
+			event, ok := next()
+			require.True(t, ok)
+			assert.Equal(t, wantId, event.id)
+		}
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		response, _ := openStream(t, server, "abc")
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, "invalid_last_event_id", body["code"])
+	})
+
+	t.Run("streams end when the service stops", func(t *testing.T) {
+		stop()
+		_, ok := next()
+		assert.False(t, ok)
+	})
+}
+
+func TestPostEventControllerTrimmedLog(t *testing.T) {
+	server, _ := newEventServer(t, 3)
+	post := `{"title":"Zig","content":"about zig","category":"Programming","tags":["zig"]}`
+	request(t, server, http.MethodPost, "/v1/posts", post)
+	request(t, server, http.MethodPut, "/v1/posts/1", post)
+	request(t, server, http.MethodDelete, "/v1/posts/1", "")
+
+	// the log keeps the events 2 to 4, a client that saw 2 resumes while one that saw 1 may have missed trimmed events and has to reload
+	_, next := openStream(t, server, "2")
+	event, ok := next()
+	require.True(t, ok)
+	assert.Equal(t, "3", event.id)
+	assert.Equal(t, "updated", event.eventType)
+
+	_, next = openStream(t, server, "1")
+	event, ok = next()
+	require.True(t, ok)
+	assert.Equal(t, "4", event.id)
+	assert.Equal(t, "reset", event.eventType)
+	assert.Nil(t, event.data.Post)
+}
@@ -0,0 +1,75 @@
+package exceptions_test
+
+import (
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/utils"
+	"errors"
+	"fmt"
+	"testing"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validationRequest struct {
+	Title string   `json:"title" validate:"required"`
+	Tags  []string `json:"tags" validate:"max=1"`
+}
+
+func TestNewValidationError(t *testing.T) {
+	validate, universalTranslator := utils.NewValidator()
+	english, _ := universalTranslator.GetTranslator("en")
+	indonesian, _ := universalTranslator.GetTranslator("id")
+	tests := []struct {
+		name       string
+		translator ut.Translator
+		want       []string
+	}{
+		{name: "english", translator: english, want: []string{"title is a required field", "tags must contain at maximum 1 item"}},
+		{name: "indonesian", translator: indonesian, want: []string{"title wajib diisi", "tags harus berisi maksimal 1 item"}},
+		{name: "no translator falls back to english", translator: nil, want: []string{"title is a required field", "tags must contain at maximum 1 item"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := exceptions.NewValidationError(validate.Struct(validationRequest{Tags: []string{"go", "rust"}}), test.translator)
+			appError := exceptions.AsAppError(err)
+			assert.Equal(t, exceptions.KindValidation, appError.Kind)
+			assert.Equal(t, exceptions.CodeValidation, appError.Code)
+			require.Len(t, appError.Fields, 2)
+			var messages []string
+			for _, field := range appError.Fields {
+				messages = append(messages, field.Message)
+			}
+			assert.Equal(t, test.want, messages)
+			assert.Equal(t, "title", appError.Fields[0].Field)
+			assert.Equal(t, "required", appError.Fields[0].Rule)
+			assert.Equal(t, "1", appError.Fields[1].Param)
+		})
+	}
+}
+
+func TestNewDatabaseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind exceptions.Kind
+	}{
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, wantKind: exceptions.KindConflict},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, wantKind: exceptions.KindAborted},
+		{name: "deadlock", err: fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}), wantKind: exceptions.KindAborted},
+		{name: "value too long", err: &pgconn.PgError{Code: "22001"}, wantKind: exceptions.KindValidation},
+		{name: "no rows", err: pgx.ErrNoRows, wantKind: exceptions.KindNotFound},
+		{name: "unknown error", err: errors.New("boom"), wantKind: exceptions.KindInternal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appError := exceptions.AsAppError(exceptions.NewDatabaseError(test.err))
+			assert.Equal(t, test.wantKind, appError.Kind)
+		})
+	}
+	// both kinds of conflict keep the code http clients already handle
+	assert.Equal(t, exceptions.CodeConflict, exceptions.AsAppError(exceptions.NewDatabaseError(&pgconn.PgError{Code: "40001"})).Code)
+}
@@ -0,0 +1,76 @@
+package middlewares_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMetricsServer wires the metrics the same way main does but on a memory repository
+func newMetricsServer() *echo.Echo {
+	metricsUtil := utils.NewMetricsUtil()
+	metricsUtil.RegisterPostgresPools(utils.NewFakePostgresUtil())
+	validate, universalTranslator := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.Metrics(metricsUtil))
+	e.Use(middlewares.Locale(universalTranslator))
+	blogRepository := repositories.NewInstrumentedBlogRepository(repositories.NewBlogMemoryRepository(modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: "Go"},
+		Content:   pgtype.Text{Valid: true, String: "about go"},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: "go"},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+	}), metricsUtil)
+	blogService := services.NewInstrumentedBlogService(services.NewBlogService(utils.NewFakePostgresUtil(), validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100), metricsUtil)
+	routes.BlogRoute(e, controllers.NewBlogController(blogService))
+	routes.MetricsRoute(e, controllers.NewMetricsController(metricsUtil))
+	return e
+}
+
+func get(e *echo.Echo, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
+
+func TestMetrics(t *testing.T) {
+	e := newMetricsServer()
+	assert.Equal(t, http.StatusOK, get(e, "/posts/1").Code)
+	assert.Equal(t, http.StatusNotFound, get(e, "/posts/2").Code)
+	assert.Equal(t, http.StatusBadRequest, get(e, "/posts/abc").Code)
+	assert.Equal(t, http.StatusNotFound, get(e, "/missing").Code)
+
+	recorder := get(e, "/metrics")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get(echo.HeaderContentType), "text/plain"))
+	body, _ := io.ReadAll(recorder.Body)
+	metrics := string(body)
+	for _, line := range []string{
+		`http_request_duration_seconds_count{method="GET",route="/posts/:id",status="200"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/posts/:id",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/posts/:id",status="400"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`blog_service_operations_total{operation="FindById",result="ok"} 1`,
+		`blog_service_operations_total{operation="FindById",result="not_found"} 1`,
+		`blog_repository_query_duration_seconds_count{method="FindById",result="ok"} 1`,
+		`blog_repository_query_duration_seconds_count{method="FindById",result="no_rows"} 1`,
+	} {
+		assert.Contains(t, metrics, line)
+	}
+	assert.NotContains(t, metrics, `route="/missing"`, "unknown paths must not create a series each")
+	assert.NotContains(t, metrics, "pgxpool_", "the fake database has no pool")
+}
@@ -0,0 +1,244 @@
+package middlewares_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOpenApiServer answers the blog routes with handler so the middleware can be checked without the blog service, bodies are limited to 1024 bytes
+func newOpenApiServer(validateResponses bool, handler echo.HandlerFunc) *echo.Echo {
+	_, universalTranslator := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.Locale(universalTranslator))
+	validation := middlewares.OpenApiValidation(services.NewOpenApiService(""), validateResponses, 1024)
+	e.POST("/posts", handler, validation)
+	e.GET("/posts/:id", handler, validation)
+	e.GET("/other", handler, validation)
+	return e
+}
+
+func TestOpenApiValidationRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		headers     map[string]string
+		wantStatus  int
+		wantCode    string
+		wantErrors  []modelresponses.FieldErrorResponse
+	}{
+		{
+			name:       "valid post",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":"Go","content":"about go","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "too long title and too many tags",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":"` + strings.Repeat("a", 51) + `","content":"about go","category":"Programming","tags":["1","2","3","4","5","6","7","8","9","10","11"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantErrors: []modelresponses.FieldErrorResponse{
+				{Field: "tags", Rule: "max", Param: "10", Message: "tags must contain at maximum 10 items"},
+				{Field: "title", Rule: "max", Param: "50", Message: "title must be a maximum of 50 characters in length"},
+			},
+		},
+		{
+			name:       "wrong types",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":1,"content":"about go","category":"Programming","tags":["go",""]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantErrors: []modelresponses.FieldErrorResponse{
+				{Field: "tags[1]", Rule: "required", Message: "tags[1] is a required field"},
+				{Field: "title", Rule: "type", Param: "string", Message: "title must be of type string"},
+			},
+		},
+		{
+			name:       "missing fields in indonesian",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":"Go","content":"about go","category":"Programming"}`,
+			headers:    map[string]string{"Accept-Language": "id"},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantErrors: []modelresponses.FieldErrorResponse{
+				{Field: "tags", Rule: "required", Message: "tags wajib diisi"},
+			},
+		},
+		{
+			name:       "required fields sent as null are missing",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":null,"content":"about go","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantErrors: []modelresponses.FieldErrorResponse{
+				{Field: "title", Rule: "required", Message: "title is a required field"},
+			},
+		},
+		{
+			name:       "body larger than the limit",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `{"title":"Go","content":"` + strings.Repeat("a", 1024) + `","category":"Programming","tags":["go"]}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "payload_too_large",
+		},
+		{
+			name:       "body that is not an object",
+			method:     http.MethodPost,
+			target:     "/posts",
+			body:       `["Go"]`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_body",
+		},
+		{
+			name:        "body that is not json",
+			method:      http.MethodPost,
+			target:      "/posts",
+			contentType: echo.MIMEApplicationForm,
+			body:        `title=Go`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    "unsupported_media_type",
+		},
+		{
+			name:       "path parameter that is not an integer",
+			method:     http.MethodGet,
+			target:     "/posts/abc",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "path parameter above the int4 range",
+			method:     http.MethodGet,
+			target:     "/posts/4294967297",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "negative path parameter",
+			method:     http.MethodGet,
+			target:     "/posts/-1",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "path parameter of 0",
+			method:     http.MethodGet,
+			target:     "/posts/0",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_id",
+		},
+		{
+			name:       "route without operation",
+			method:     http.MethodGet,
+			target:     "/other",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newOpenApiServer(false, func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			request := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			if test.body != "" {
+				contentType := test.contentType
+				if contentType == "" {
+					contentType = echo.MIMEApplicationJSON
+				}
+				request.Header.Set(echo.HeaderContentType, contentType)
+			}
+			for name, value := range test.headers {
+				request.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.wantStatus, recorder.Code, recorder.Body.String())
+			if test.wantCode == "" {
+				return
+			}
+			var problem modelresponses.ProblemResponse
+			require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			assert.Equal(t, test.wantCode, problem.Code)
+			assert.Equal(t, test.wantErrors, problem.Errors)
+		})
+	}
+}
+
+func TestOpenApiValidationResponses(t *testing.T) {
+	validPost := modelresponses.FindByIdResponse{Id: 1, Title: "Go", Content: "about go", Category: "Programming", Tags: []string{"go"}, CreatedAt: "2024-10-22T23:48:05Z", UpdatedAt: "2024-10-22T23:48:05Z"}
+	tests := []struct {
+		name              string
+		validateResponses bool
+		handler           echo.HandlerFunc
+		wantStatus        int
+	}{
+		{
+			name:              "documented response",
+			validateResponses: true,
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusOK, validPost)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:              "response of the wrong shape",
+			validateResponses: true,
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusOK, map[string]any{"id": "1", "title": "Go"})
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:              "undocumented status",
+			validateResponses: true,
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusConflict, validPost)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:              "response of the wrong shape without response validation",
+			validateResponses: false,
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusOK, map[string]any{"id": "1", "title": "Go"})
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newOpenApiServer(test.validateResponses, test.handler)
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+			assert.Equal(t, test.wantStatus, recorder.Code, recorder.Body.String())
+			if test.wantStatus == http.StatusOK && test.validateResponses {
+				var post modelresponses.FindByIdResponse
+				require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &post))
+				assert.Equal(t, validPost, post)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package middlewares_test
+
+import (
+	"blogging-platform-api/middlewares"
+	"blogging-platform-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	now := time.Now().UnixMilli()
+	tests := []struct {
+		name             string
+		method           string
+		status           int
+		cookie           string
+		wantPrimaryReads bool
+		wantCookie       bool
+	}{
+		{name: "read without cookie", method: http.MethodGet, status: http.StatusOK},
+		{name: "read after recent write", method: http.MethodGet, status: http.StatusOK, cookie: strconv.FormatInt(now-1000, 10), wantPrimaryReads: true},
+		{name: "read after old write", method: http.MethodGet, status: http.StatusOK, cookie: strconv.FormatInt(now-10000, 10)},
+		{name: "read with invalid cookie", method: http.MethodGet, status: http.StatusOK, cookie: "abc"},
+		{name: "successful create", method: http.MethodPost, status: http.StatusCreated, wantCookie: true},
+		{name: "successful update", method: http.MethodPut, status: http.StatusOK, wantCookie: true},
+		{name: "successful delete", method: http.MethodDelete, status: http.StatusNoContent, wantCookie: true},
+		{name: "failed update", method: http.MethodPut, status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(middlewares.ReadYourWrites(5*time.Second, true))
+			var primaryReads bool
+			e.Any("/posts", func(c echo.Context) error {
+				primaryReads = utils.PrimaryReadsFromContext(c.Request().Context())
+				return c.NoContent(test.status)
+			})
+
+			request := httptest.NewRequest(test.method, "/posts", nil)
+			if test.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: middlewares.LastWriteCookie, Value: test.cookie})
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			require.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.wantPrimaryReads, primaryReads)
+			cookies := recorder.Result().Cookies()
+			if !test.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.Equal(t, middlewares.LastWriteCookie, cookies[0].Name)
+			assert.Equal(t, 5, cookies[0].MaxAge)
+			assert.True(t, cookies[0].HttpOnly)
+			assert.True(t, cookies[0].Secure)
+			lastWrite, err := strconv.ParseInt(cookies[0].Value, 10, 64)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), time.UnixMilli(lastWrite), time.Second)
+		})
+	}
+}
+
+func TestReadYourWritesMarkWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		write      bool
+		wantCookie bool
+	}{
+		{name: "post marked as a read", method: http.MethodPost},
+		{name: "post marked as a write", method: http.MethodPost, write: true, wantCookie: true},
+		{name: "get marked as a write", method: http.MethodGet, write: true, wantCookie: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(middlewares.ReadYourWrites(5*time.Second, true))
+			e.Any("/graphql", func(c echo.Context) error {
+				middlewares.MarkWrite(c, test.write)
+				return c.NoContent(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(test.method, "/graphql", nil))
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			cookies := recorder.Result().Cookies()
+			if !test.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.Equal(t, middlewares.LastWriteCookie, cookies[0].Name)
+		})
+	}
+}
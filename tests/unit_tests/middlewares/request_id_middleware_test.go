@@ -0,0 +1,126 @@
+package middlewares_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	"blogging-platform-api/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoggingServer writes every log line into the returned buffer, /fail logs from the handler and fails with an internal error
+func newLoggingServer(t *testing.T) (*echo.Echo, *bytes.Buffer) {
+	logs := &bytes.Buffer{}
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(utils.NewLogHandler(logs, slog.LevelDebug)))
+	t.Cleanup(func() {
+		slog.SetDefault(previousLogger)
+	})
+
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.RequestId())
+	e.Use(middlewares.AccessLog())
+	e.GET("/posts/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "post")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		slog.WarnContext(c.Request().Context(), "about to fail")
+		return errors.New("database is gone")
+	})
+	return e, logs
+}
+
+func logLines(t *testing.T, logs *bytes.Buffer) (lines []map[string]any) {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		lines = append(lines, record)
+	}
+	return
+}
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestId     string
+		wantRequestId string
+	}{
+		{name: "keeps the id of the client", requestId: "client-id_1.2", wantRequestId: "client-id_1.2"},
+		{name: "generates a missing id"},
+		{name: "replaces an id with unsafe characters", requestId: "bad id\nsecond line"},
+		{name: "replaces a too long id", requestId: strings.Repeat("a", 129)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, logs := newLoggingServer(t)
+			request := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+			if test.requestId != "" {
+				request.Header.Set(echo.HeaderXRequestID, test.requestId)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+
+			requestId := recorder.Header().Get(echo.HeaderXRequestID)
+			if test.wantRequestId != "" {
+				assert.Equal(t, test.wantRequestId, requestId)
+			} else {
+				assert.Regexp(t, "^[0-9a-f]{32}$", requestId)
+			}
+			lines := logLines(t, logs)
+			require.Len(t, lines, 1)
+			assert.Equal(t, requestId, lines[0]["request_id"])
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	e, logs := newLoggingServer(t)
+	request := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	request.Header.Set("User-Agent", "test-agent")
+	e.ServeHTTP(httptest.NewRecorder(), request)
+
+	lines := logLines(t, logs)
+	require.Len(t, lines, 1)
+	line := lines[0]
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "GET", line["method"])
+	assert.Equal(t, "/posts/:id", line["route"])
+	assert.Equal(t, "/posts/1", line["path"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+	assert.Equal(t, float64(len("post")), line["bytes"])
+	assert.Equal(t, "test-agent", line["user_agent"])
+	assert.Contains(t, line, "latency_ms")
+}
+
+func TestAccessLogOfServerErrorSharesTheRequestId(t *testing.T) {
+	e, logs := newLoggingServer(t)
+	request := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	request.Header.Set(echo.HeaderXRequestID, "failing-request")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	lines := logLines(t, logs)
+	require.Len(t, lines, 3, "the handler log, the error handler log and the access log")
+	assert.Equal(t, "about to fail", lines[0]["msg"])
+	assert.Equal(t, "request failed", lines[1]["msg"])
+	assert.Equal(t, "database is gone", lines[1]["error"])
+	assert.Equal(t, "ERROR", lines[2]["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[2]["status"])
+	for _, line := range lines {
+		assert.Equal(t, "failing-request", line["request_id"])
+	}
+}
@@ -0,0 +1,86 @@
+package middlewares_test
+
+import (
+	"blogging-platform-api/controllers"
+	"blogging-platform-api/middlewares"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/routes"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// newTracingServer records every span in memory instead of exporting it
+func newTracingServer(t *testing.T) (*echo.Echo, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	validate, universalTranslator := utils.NewValidator()
+	e := echo.New()
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	e.Use(middlewares.Tracing())
+	e.Use(middlewares.Locale(universalTranslator))
+	blogRepository := repositories.NewBlogMemoryRepository(modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: "Go"},
+		Content:   pgtype.Text{Valid: true, String: "about go"},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: "go"},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+	})
+	blogService := services.NewTracedBlogService(services.NewBlogService(utils.NewFakePostgresUtil(), validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100))
+	routes.BlogRoute(e, controllers.NewBlogController(blogService))
+	return e, recorder
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	e, recorder := newTracingServer(t)
+	request := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	serviceSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, "GET /posts/:id", serverSpan.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.Contains(t, serverSpan.Attributes(), semconv.HTTPResponseStatusCode(http.StatusOK))
+
+	assert.Equal(t, "BlogService.FindById", serviceSpan.Name())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+	assert.Equal(t, codes.Unset, serviceSpan.Status().Code)
+}
+
+func TestTracingStartsNewTraceAndKeepsClientErrorsOk(t *testing.T) {
+	e, recorder := newTracingServer(t)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/2", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	serviceSpan, serverSpan := spans[0], spans[1]
+	assert.False(t, serverSpan.Parent().IsValid())
+	assert.Contains(t, serverSpan.Attributes(), semconv.HTTPResponseStatusCode(http.StatusNotFound))
+	assert.Equal(t, codes.Unset, serverSpan.Status().Code, "a not found post is not a server error")
+	assert.Equal(t, codes.Unset, serviceSpan.Status().Code)
+}
@@ -0,0 +1,220 @@
+package repositories_test
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyBlogRepository fails the first calls of Create and FindById with the queued errors, StreamAll fails after it streamed every row
+type flakyBlogRepository struct {
+	repositories.BlogRepository
+	errors []error
+	calls  int
+}
+
+func (repository *flakyBlogRepository) fail() error {
+	repository.calls++
+	if len(repository.errors) == 0 {
+		return nil
+	}
+	err := repository.errors[0]
+	repository.errors = repository.errors[1:]
+	return err
+}
+
+func (repository *flakyBlogRepository) Create(querier repositories.Querier, ctx context.Context, blog modelentities.Blog) (int, error) {
+	if err := repository.fail(); err != nil {
+		return 0, err
+	}
+	return repository.BlogRepository.Create(querier, ctx, blog)
+}
+
+func (repository *flakyBlogRepository) FindById(querier repositories.Querier, ctx context.Context, id int) (modelentities.Blog, error) {
+	if err := repository.fail(); err != nil {
+		return modelentities.Blog{}, err
+	}
+	return repository.BlogRepository.FindById(querier, ctx, id)
+}
+
+func (repository *flakyBlogRepository) StreamAll(querier repositories.Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) error {
+	err := repository.BlogRepository.StreamAll(querier, ctx, term, limit, offset, callback)
+	if err != nil {
+		return err
+	}
+	return repository.fail()
+}
+
+var (
+	errSerialization = &pgconn.PgError{Code: "40001"}
+	errReset         = syscall.ECONNRESET
+)
+
+func newRepository(errs ...error) (*flakyBlogRepository, repositories.BlogRepository) {
+	blog := modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: "Go"},
+		Content:   pgtype.Text{Valid: true, String: "about go"},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: "go"},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+	}
+	flaky := &flakyBlogRepository{BlogRepository: repositories.NewBlogMemoryRepository(blog), errors: errs}
+	policy := utils.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	return flaky, repositories.NewResilientBlogRepository(flaky, policy, utils.NewCircuitBreaker(5, time.Minute))
+}
+
+func TestResilientBlogRepositoryRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation func(repository repositories.BlogRepository, querier repositories.Querier) error
+		querier   repositories.Querier
+		errors    []error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "read is retried after a serialization failure",
+			operation: findById,
+			errors:    []error{errSerialization},
+			wantCalls: 2,
+		},
+		{
+			name:      "read is retried after a connection reset",
+			operation: findById,
+			errors:    []error{errReset, errReset},
+			wantCalls: 3,
+		},
+		{
+			name:      "read gives up after the attempts",
+			operation: findById,
+			errors:    []error{errReset, errReset, errReset},
+			wantCalls: 3,
+			wantErr:   errReset,
+		},
+		{
+			name:      "create is retried after a serialization failure",
+			operation: create,
+			errors:    []error{errSerialization},
+			wantCalls: 2,
+		},
+		{
+			name:      "create is not retried after a connection reset",
+			operation: create,
+			errors:    []error{errReset},
+			wantCalls: 1,
+			wantErr:   errReset,
+		},
+		{
+			name: "not found is not retried",
+			operation: func(repository repositories.BlogRepository, querier repositories.Querier) error {
+				_, err := repository.FindById(querier, context.Background(), 99)
+				return err
+			},
+			wantCalls: 1,
+			wantErr:   pgx.ErrNoRows,
+		},
+		{
+			name:      "calls in a transaction are not retried",
+			operation: findById,
+			querier:   beginTx(),
+			errors:    []error{errSerialization},
+			wantCalls: 1,
+			wantErr:   errSerialization,
+		},
+		{
+			name: "stream is not retried after a row was streamed",
+			operation: func(repository repositories.BlogRepository, querier repositories.Querier) error {
+				return repository.StreamAll(querier, context.Background(), "", 0, 0, func(blog modelentities.Blog) error {
+					return nil
+				})
+			},
+			errors:    []error{errReset},
+			wantCalls: 1,
+			wantErr:   errReset,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flaky, repository := newRepository(test.errors...)
+			err := test.operation(repository, test.querier)
+			assert.Equal(t, test.wantCalls, flaky.calls)
+			if test.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestResilientBlogRepositoryStreamCallbackErrorIsNotADatabaseFailure(t *testing.T) {
+	flaky, repository := newRepository()
+	errClient := errors.Join(errors.New("client went away"), syscall.EPIPE)
+	for i := 0; i < 10; i++ {
+		err := repository.StreamAll(nil, context.Background(), "", 0, 0, func(blog modelentities.Blog) error {
+			return errClient
+		})
+		assert.Equal(t, errClient, err)
+	}
+	assert.NoError(t, findById(repository, nil), "the circuit must stay closed")
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestResilientBlogRepositoryCircuitBreaker(t *testing.T) {
+	errs := []error{}
+	for i := 0; i < 15; i++ {
+		errs = append(errs, errReset)
+	}
+	flaky, repository := newRepository(errs...)
+	assert.ErrorIs(t, findById(repository, nil), errReset)
+	assert.Equal(t, 3, flaky.calls)
+	assert.ErrorIs(t, findById(repository, nil), utils.ErrCircuitOpen, "the circuit opens during the retries of the second call")
+	assert.Equal(t, 5, flaky.calls)
+
+	err := findById(repository, nil)
+	assert.ErrorIs(t, err, utils.ErrCircuitOpen)
+	assert.Equal(t, 5, flaky.calls, "no call reaches the database while the circuit is open")
+	appError := exceptions.AsAppError(exceptions.NewDatabaseError(err))
+	assert.Equal(t, exceptions.KindUnavailable, appError.Kind)
+	assert.Equal(t, exceptions.CodeUnavailable, appError.Code)
+}
+
+func findById(repository repositories.BlogRepository, querier repositories.Querier) error {
+	_, err := repository.FindById(querier, context.Background(), 1)
+	return err
+}
+
+func create(repository repositories.BlogRepository, querier repositories.Querier) error {
+	_, err := repository.Create(querier, context.Background(), modelentities.Blog{Title: pgtype.Text{Valid: true, String: "Rust"}})
+	return err
+}
+
+func beginTx() repositories.Querier {
+	tx, err := utils.NewFakePostgresUtil().BeginTx(context.Background(), pgx.TxOptions{})
+	if err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+func TestResilientBlogRepositoryPassesResultsThrough(t *testing.T) {
+	_, repository := newRepository()
+	blog, err := repository.FindById(nil, context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Go", blog.Title.String)
+	id, err := repository.Create(nil, context.Background(), blog)
+	require.NoError(t, err)
+	assert.Equal(t, 2, id)
+}
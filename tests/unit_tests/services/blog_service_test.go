@@ -0,0 +1,247 @@
+package services_test
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	modelrequests "blogging-platform-api/models/requests"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlog(title string, category string, tags ...string) modelentities.Blog {
+	return modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: title},
+		Content:   pgtype.Text{Valid: true, String: "content of " + title},
+		Category:  pgtype.Text{Valid: true, String: category},
+		Tags:      pgtype.Text{Valid: true, String: strings.Join(tags, ", ")},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1700000000000},
+		UpdatedAt: pgtype.Int8{Valid: true, Int64: 1700000000000},
+	}
+}
+
+func newBlogService(blogs ...modelentities.Blog) (services.BlogService, *utils.FakePostgresUtilImplementation, repositories.BlogRepository) {
+	validate, _ := utils.NewValidator()
+	postgresUtil := utils.NewFakePostgresUtil()
+	blogRepository := repositories.NewBlogMemoryRepository(blogs...)
+	return services.NewBlogService(postgresUtil, validate, blogRepository, utils.NewDatabaseRetryPolicy(3), 100), postgresUtil, blogRepository
+}
+
+func assertAppError(t *testing.T, err error, kind exceptions.Kind, code string) *exceptions.AppError {
+	t.Helper()
+	var appError *exceptions.AppError
+	require.ErrorAs(t, err, &appError)
+	assert.Equal(t, kind, appError.Kind)
+	assert.Equal(t, code, appError.Code)
+	return appError
+}
+
+func TestBlogServiceCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     modelrequests.CreateRequest
+		wantKind    exceptions.Kind
+		wantCode    string
+		wantFields  []string
+		wantCreated bool
+	}{
+		{
+			name:        "valid request",
+			request:     modelrequests.CreateRequest{Title: "Go", Content: "about go", Category: "Programming", Tags: []string{"go"}},
+			wantCreated: true,
+		},
+		{
+			name:       "missing fields",
+			request:    modelrequests.CreateRequest{},
+			wantKind:   exceptions.KindValidation,
+			wantCode:   exceptions.CodeValidation,
+			wantFields: []string{"title", "content", "category", "tags"},
+		},
+		{
+			name:       "title too long",
+			request:    modelrequests.CreateRequest{Title: strings.Repeat("a", 51), Content: "about go", Category: "Programming", Tags: []string{"go"}},
+			wantKind:   exceptions.KindValidation,
+			wantCode:   exceptions.CodeValidation,
+			wantFields: []string{"title"},
+		},
+		{
+			name:       "empty tag",
+			request:    modelrequests.CreateRequest{Title: "Go", Content: "about go", Category: "Programming", Tags: []string{"go", ""}},
+			wantKind:   exceptions.KindValidation,
+			wantCode:   exceptions.CodeValidation,
+			wantFields: []string{"tags[1]"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogService, _, blogRepository := newBlogService()
+			response, err := blogService.Create(context.Background(), test.request)
+			count, errCount := blogRepository.CountAll(nil, context.Background())
+			require.NoError(t, errCount)
+			if !test.wantCreated {
+				appError := assertAppError(t, err, test.wantKind, test.wantCode)
+				fields := []string{}
+				for _, field := range appError.Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.Equal(t, test.wantFields, fields)
+				assert.Equal(t, 0, count)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, response.Id)
+			assert.Equal(t, test.request.Title, response.Title)
+			assert.Equal(t, test.request.Tags, response.Tags)
+			assert.NotEmpty(t, response.CreatedAt)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestBlogServiceUpdate(t *testing.T) {
+	validRequest := modelrequests.UpdateRequest{Title: "Rust", Content: "about rust", Category: "Programming", Tags: []string{"rust"}}
+	tests := []struct {
+		name          string
+		id            int
+		request       modelrequests.UpdateRequest
+		wantKind      exceptions.Kind
+		wantCode      string
+		wantBegins    int
+		wantRollbacks int
+	}{
+		{name: "existing post", id: 1, request: validRequest, wantBegins: 1},
+		{name: "missing post", id: 99, request: validRequest, wantKind: exceptions.KindNotFound, wantCode: exceptions.CodePostNotFound, wantBegins: 1, wantRollbacks: 1},
+		{name: "invalid request", id: 1, request: modelrequests.UpdateRequest{}, wantKind: exceptions.KindValidation, wantCode: exceptions.CodeValidation},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogService, postgresUtil, _ := newBlogService(newBlog("Go", "Programming", "go"))
+			response, err := blogService.Update(context.Background(), test.id, test.request)
+			begins, commits, rollbacks := postgresUtil.Counts()
+			assert.Equal(t, test.wantBegins, begins)
+			assert.Equal(t, test.wantRollbacks, rollbacks)
+			if test.wantCode != "" {
+				assertAppError(t, err, test.wantKind, test.wantCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, commits)
+			assert.Equal(t, test.id, response.Id)
+			assert.Equal(t, "Rust", response.Title)
+			assert.Equal(t, []string{"rust"}, response.Tags)
+			assert.Equal(t, "2023-11-14T22:13:20Z", response.CreatedAt)
+			assert.NotEqual(t, response.CreatedAt, response.UpdatedAt)
+		})
+	}
+}
+
+func TestBlogServiceUpdateBeginTxError(t *testing.T) {
+	blogService, postgresUtil, _ := newBlogService(newBlog("Go", "Programming", "go"))
+	postgresUtil.BeginTxError = errors.New("connection refused")
+	_, err := blogService.Update(context.Background(), 1, modelrequests.UpdateRequest{Title: "Rust", Content: "about rust", Category: "Programming", Tags: []string{"rust"}})
+	assertAppError(t, err, exceptions.KindInternal, exceptions.CodeInternal)
+}
+
+func TestBlogServiceDelete(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            int
+		wantKind      exceptions.Kind
+		wantCode      string
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{name: "existing post", id: 1, wantCommits: 1},
+		{name: "missing post", id: 99, wantKind: exceptions.KindNotFound, wantCode: exceptions.CodePostNotFound, wantRollbacks: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogService, postgresUtil, blogRepository := newBlogService(newBlog("Go", "Programming", "go"))
+			err := blogService.Delete(context.Background(), test.id)
+			_, commits, rollbacks := postgresUtil.Counts()
+			assert.Equal(t, test.wantCommits, commits)
+			assert.Equal(t, test.wantRollbacks, rollbacks)
+			count, errCount := blogRepository.CountAll(nil, context.Background())
+			require.NoError(t, errCount)
+			if test.wantCode != "" {
+				assertAppError(t, err, test.wantKind, test.wantCode)
+				assert.Equal(t, 1, count)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+	}
+}
+
+func TestBlogServiceFindById(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        int
+		wantTitle string
+		wantCode  string
+	}{
+		{name: "existing post", id: 2, wantTitle: "Rust"},
+		{name: "missing post", id: 3, wantCode: exceptions.CodePostNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogService, _, _ := newBlogService(newBlog("Go", "Programming", "go"), newBlog("Rust", "Programming", "rust"))
+			response, err := blogService.FindById(context.Background(), test.id)
+			if test.wantCode != "" {
+				assertAppError(t, err, exceptions.KindNotFound, test.wantCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.id, response.Id)
+			assert.Equal(t, test.wantTitle, response.Title)
+			assert.Equal(t, "content of "+test.wantTitle, response.Content)
+		})
+	}
+}
+
+func TestBlogServiceFindAllPosts(t *testing.T) {
+	tests := []struct {
+		name       string
+		term       string
+		wantTitles []string
+	}{
+		{name: "without term", term: "", wantTitles: []string{"Go", "Rust", "Cooking"}},
+		{name: "term matches tags", term: "lang", wantTitles: []string{"Go", "Rust"}},
+		{name: "term is case insensitive", term: "FOOD", wantTitles: []string{"Cooking"}},
+		{name: "term matches part of a tag", term: "us", wantTitles: []string{"Rust"}},
+		{name: "term does not match title", term: "Cooking", wantTitles: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blogService, _, _ := newBlogService(
+				newBlog("Go", "Programming", "go", "language"),
+				newBlog("Rust", "Programming", "rust", "language"),
+				newBlog("Cooking", "Life", "food"),
+			)
+			response, err := blogService.FindAllPosts(context.Background(), test.term)
+			require.NoError(t, err)
+			var titles []string
+			for _, findResponse := range response {
+				titles = append(titles, findResponse.Title)
+			}
+			assert.Equal(t, test.wantTitles, titles)
+		})
+	}
+}
+
+func TestBlogServiceCanceledContext(t *testing.T) {
+	blogService, _, _ := newBlogService(newBlog("Go", "Programming", "go"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := blogService.FindById(ctx, 1)
+	assertAppError(t, err, exceptions.KindInternal, exceptions.CodeInternal)
+}
@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportBlog(title string, content string, tags string) modelentities.Blog {
+	return modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: title},
+		Content:   pgtype.Text{Valid: true, String: content},
+		Category:  pgtype.Text{Valid: true, String: "Programming"},
+		Tags:      pgtype.Text{Valid: true, String: tags},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1704164645000},
+		UpdatedAt: pgtype.Int8{Valid: true, Int64: 1706745600000},
+	}
+}
+
+// unavailableRepository fails every stream like the resilient repository does while the circuit is open
+type unavailableRepository struct {
+	repositories.BlogRepository
+}
+
+func (repository *unavailableRepository) StreamAll(querier repositories.Querier, ctx context.Context, term string, limit int, offset int, callback func(blog modelentities.Blog) error) error {
+	return utils.ErrCircuitOpen
+}
+
+func TestExportServiceMarkdownZipRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	exportRepository := repositories.NewBlogMemoryRepository(
+		exportBlog("Go", "# Go\n\nGo is *fast*\n", "go, language"),
+		exportBlog("Front matter", "---\nnot front matter\n---\n", "yaml"),
+	)
+	exportService := services.NewExportService(utils.NewFakePostgresUtil(), exportRepository)
+	var archive bytes.Buffer
+	require.NoError(t, exportService.Export(ctx, services.ExportFormatMarkdownZip, "", &archive))
+
+	importService, importRepository := newImportService(1<<20, 1<<20)
+	response, err := importService.Import(ctx, services.ImportFormatZip, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Imported, response.Records)
+	assert.Equal(t, 0, response.Failed)
+
+	exported, err := exportRepository.FindAll(nil, ctx, "")
+	require.NoError(t, err)
+	imported, err := importRepository.FindAll(nil, ctx, "")
+	require.NoError(t, err)
+	require.Len(t, imported, len(exported))
+	for i := range exported {
+		assert.Equal(t, exported[i].Title, imported[i].Title)
+		assert.Equal(t, exported[i].Content, imported[i].Content)
+		assert.Equal(t, exported[i].Category, imported[i].Category)
+		assert.Equal(t, exported[i].Tags, imported[i].Tags)
+		assert.Equal(t, exported[i].CreatedAt, imported[i].CreatedAt)
+	}
+}
+
+func TestExportServiceCsv(t *testing.T) {
+	exportService := services.NewExportService(utils.NewFakePostgresUtil(), repositories.NewBlogMemoryRepository(
+		exportBlog(`Say "hi", again`, "line one\nline two, with a comma", "go, language"),
+	))
+	var buffer bytes.Buffer
+	require.NoError(t, exportService.Export(context.Background(), services.ExportFormatCsv, "", &buffer))
+	assert.Contains(t, buffer.String(), `"Say ""hi"", again"`)
+
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "title", "content", "category", "tags", "createdAt", "updatedAt"},
+		{"1", `Say "hi", again`, "line one\nline two, with a comma", "Programming", "go,language", "2024-01-02T03:04:05Z", "2024-02-01T00:00:00Z"},
+	}, rows)
+}
+
+func TestExportServiceUnsupportedFormat(t *testing.T) {
+	exportService := services.NewExportService(utils.NewFakePostgresUtil(), repositories.NewBlogMemoryRepository(exportBlog("Go", "about go", "go")))
+	var buffer bytes.Buffer
+	err := exportService.Export(context.Background(), "pdf", "", &buffer)
+	assertAppError(t, err, exceptions.KindBadRequest, exceptions.CodeUnsupportedFormat)
+	assert.Zero(t, buffer.Len())
+}
+
+func TestExportServiceUnavailable(t *testing.T) {
+	exportService := services.NewExportService(utils.NewFakePostgresUtil(), &unavailableRepository{})
+	for _, format := range []string{services.ExportFormatJsonLines, services.ExportFormatCsv, services.ExportFormatMarkdownZip} {
+		err := exportService.Export(context.Background(), format, "", &bytes.Buffer{})
+		assertAppError(t, err, exceptions.KindUnavailable, exceptions.CodeUnavailable)
+	}
+}
@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"blogging-platform-api/exceptions"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImportService(maxSize int64, maxEntrySize int64) (services.ImportService, repositories.BlogRepository) {
+	validate, _ := utils.NewValidator()
+	blogRepository := repositories.NewBlogMemoryRepository()
+	return services.NewImportService(utils.NewFakePostgresUtil(), validate, blogRepository, maxSize, maxEntrySize), blogRepository
+}
+
+func markdownPost(title string) string {
+	return "---\ntitle: " + title + "\ncategory: Programming\ntags: [go]\n---\ncontent of " + title + "\n"
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(file, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func tarOf(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := io.WriteString(writer, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func gzipOf(t *testing.T, data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestImportServiceFormats(t *testing.T) {
+	files := map[string]string{"posts/go.md": markdownPost("Go"), "posts/rust.markdown": markdownPost("Rust"), "posts/readme.txt": "not a post"}
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{
+			name:   "json lines",
+			format: services.ImportFormatJsonLines,
+			data: []byte(`{"title":"Go","content":"about go","category":"Programming","tags":["go"]}` + "\n\n" +
+				`{"title":"Rust","content":"about rust","category":"Programming","tags":["rust"],"createdAt":"2024-01-02T03:04:05Z"}` + "\n"),
+		},
+		{name: "zip", format: services.ImportFormatZip, data: zipOf(t, files)},
+		{name: "tar", format: services.ImportFormatTar, data: tarOf(t, files)},
+		{name: "tar gzip", format: services.ImportFormatTarGzip, data: gzipOf(t, tarOf(t, files))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			importService, blogRepository := newImportService(1<<20, 1<<10)
+			// a plain reader is spooled like a request body, the other readers are read in place like an uploaded file
+			for _, reader := range []io.Reader{io.MultiReader(bytes.NewReader(test.data)), bytes.NewReader(test.data)} {
+				response, err := importService.Import(context.Background(), test.format, reader)
+				require.NoError(t, err)
+				assert.Equal(t, 2, response.Total)
+				assert.Equal(t, 2, response.Imported)
+				assert.Equal(t, 0, response.Failed)
+			}
+			blogs, err := blogRepository.FindAll(nil, context.Background(), "")
+			require.NoError(t, err)
+			var titles []string
+			for _, blog := range blogs {
+				titles = append(titles, blog.Title.String)
+			}
+			assert.ElementsMatch(t, []string{"Go", "Rust", "Go", "Rust"}, titles)
+		})
+	}
+}
+
+func TestImportServiceInvalidRecords(t *testing.T) {
+	importService, blogRepository := newImportService(1<<20, 1<<10)
+	data := `{"title":"Go","content":"about go","category":"Programming","tags":["go"]}` + "\n" +
+		`{"title":"Rust","category":"Programming","tags":["rust"]}` + "\n" +
+		`not json` + "\n" +
+		`{"title":"Zig","content":"about zig","category":"Programming","tags":["zig"],"createdAt":"yesterday"}` + "\n"
+	response, err := importService.Import(context.Background(), services.ImportFormatJsonLines, strings.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.Total)
+	assert.Equal(t, 1, response.Imported)
+	assert.Equal(t, 3, response.Failed)
+	assert.True(t, response.Records[0].Success)
+	require.Len(t, response.Records[1].Errors, 1)
+	assert.Equal(t, "content", response.Records[1].Errors[0].Field)
+	assert.Equal(t, "content is a required field", response.Records[1].Errors[0].Message)
+	assert.Equal(t, "line 3", response.Records[2].Source)
+	assert.False(t, response.Records[2].Success)
+	assert.NotEmpty(t, response.Records[2].Message)
+	assert.Equal(t, "createdAt", response.Records[3].Errors[0].Field)
+
+	count, err := blogRepository.CountAll(nil, context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = importService.Import(context.Background(), "rar", strings.NewReader(data))
+	assertAppError(t, err, exceptions.KindBadRequest, exceptions.CodeUnsupportedFormat)
+	_, err = importService.Import(context.Background(), services.ImportFormatZip, strings.NewReader("not a zip"))
+	assertAppError(t, err, exceptions.KindBadRequest, exceptions.CodeInvalidBody)
+}
+
+func TestImportServiceLimits(t *testing.T) {
+	large := markdownPost("Large") + strings.Repeat("a", 2000)
+	// highly compressible entries that expand well past the import limit
+	bomb := map[string]string{}
+	for _, name := range []string{"a.md", "b.md", "c.md", "d.md", "e.md"} {
+		bomb[name] = markdownPost("Bomb") + strings.Repeat("a", 900)
+	}
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{name: "long json line", format: services.ImportFormatJsonLines, data: []byte(`{"title":"` + strings.Repeat("a", 2000) + `"}` + "\n")},
+		{name: "large json lines", format: services.ImportFormatJsonLines, data: []byte(strings.Repeat(`{"title":"Go"}`+"\n", 400))},
+		{name: "large zip entry", format: services.ImportFormatZip, data: zipOf(t, map[string]string{"large.md": large})},
+		{name: "large tar entry", format: services.ImportFormatTarGzip, data: gzipOf(t, tarOf(t, map[string]string{"large.md": large}))},
+		{name: "zip expanding past the limit", format: services.ImportFormatZip, data: zipOf(t, bomb)},
+		{name: "tar expanding past the limit", format: services.ImportFormatTarGzip, data: gzipOf(t, tarOf(t, bomb))},
+		{name: "large zip upload", format: services.ImportFormatZip, data: bytes.Repeat([]byte{0}, 5000)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			importService, blogRepository := newImportService(4000, 1000)
+			_, err := importService.Import(context.Background(), test.format, io.MultiReader(bytes.NewReader(test.data)))
+			assertAppError(t, err, exceptions.KindTooLarge, exceptions.CodeTooLarge)
+			count, err := blogRepository.CountAll(nil, context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package services_test
+
+import (
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/services"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenApiServiceIdSchema(t *testing.T) {
+	openApiService := services.NewOpenApiService("")
+	operation, ok := openApiService.Operation(http.MethodGet, "/v1/posts/:id")
+	require.True(t, ok)
+	require.Len(t, operation.Parameters, 1)
+	schema := operation.Parameters[0].Schema
+	assert.Equal(t, "integer", schema.Type)
+	assert.Equal(t, "int32", schema.Format)
+	assert.Equal(t, float64(1), *schema.Minimum)
+	assert.Equal(t, float64(math.MaxInt32), *schema.Maximum)
+
+	tests := []struct {
+		value    string
+		wantRule string
+	}{
+		{value: "1"},
+		{value: "2147483647"},
+		{value: "0", wantRule: "min"},
+		{value: "-1", wantRule: "min"},
+		{value: "2147483648", wantRule: "type"},
+		{value: "4294967297", wantRule: "type"},
+		{value: "1.5", wantRule: "type"},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			fields := openApiService.Validate(context.Background(), schema, json.Number(test.value), "id")
+			if test.wantRule == "" {
+				assert.Empty(t, fields)
+				return
+			}
+			require.Len(t, fields, 1)
+			assert.Equal(t, test.wantRule, fields[0].Rule)
+		})
+	}
+
+	// the schema of the document is the one the middleware checks
+	var document struct {
+		Paths map[string]map[string]modelresponses.OpenApiOperationResponse
+	}
+	require.NoError(t, json.Unmarshal(openApiService.Json(), &document))
+	assert.Equal(t, "int32", document.Paths["/v1/posts/{id}"]["get"].Parameters[0].Schema.Format)
+}
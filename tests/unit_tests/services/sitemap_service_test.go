@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"blogging-platform-api/exceptions"
+	modelentities "blogging-platform-api/models/entities"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sitemapDocument struct {
+	XMLName xml.Name
+	Urls    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func sitemapBlog(title string, category string, tags string, updatedAt int64) modelentities.Blog {
+	return modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: title},
+		Content:   pgtype.Text{Valid: true, String: "content of " + title},
+		Category:  pgtype.Text{Valid: true, String: category},
+		Tags:      pgtype.Text{Valid: true, String: tags},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: 1000},
+		UpdatedAt: pgtype.Int8{Valid: true, Int64: updatedAt},
+	}
+}
+
+// newSitemapService has at most maxUrls urls per sitemap file so paging is tested without 50000 posts
+func newSitemapService(maxUrls int) services.SitemapService {
+	blogRepository := repositories.NewBlogMemoryRepository(
+		sitemapBlog("Go", "Programming", "go, Web Dev", 1704067200000),
+		sitemapBlog("Rust", "Programming", "rust, web-dev", 1706745600000),
+		sitemapBlog("Symbols", "Life", "+++", 1000),
+	)
+	return &services.SitemapServiceImplementation{
+		PostgresUtil:   utils.NewFakePostgresUtil(),
+		BlogRepository: blogRepository,
+		BaseUrl:        "https://blog.example.com",
+		MaxUrls:        maxUrls,
+	}
+}
+
+func readSitemap(t *testing.T, write func(buffer *bytes.Buffer) error) (document sitemapDocument) {
+	t.Helper()
+	var buffer bytes.Buffer
+	require.NoError(t, write(&buffer))
+	require.NoError(t, xml.Unmarshal(buffer.Bytes(), &document))
+	return
+}
+
+func locs(document sitemapDocument) (locs []string) {
+	for _, url := range document.Urls {
+		locs = append(locs, url.Loc)
+	}
+	for _, sitemap := range document.Sitemaps {
+		locs = append(locs, sitemap.Loc)
+	}
+	return
+}
+
+func TestSitemapService(t *testing.T) {
+	ctx := context.Background()
+	sitemapService := newSitemapService(services.MaxSitemapUrls)
+	document := readSitemap(t, func(buffer *bytes.Buffer) error { return sitemapService.WriteSitemap(ctx, buffer) })
+	assert.Equal(t, "urlset", document.XMLName.Local)
+	// posts use the canonical route, taxonomies the pages of the static site and names with the same slug share one page
+	assert.Equal(t, []string{
+		"https://blog.example.com/v1/posts/1",
+		"https://blog.example.com/v1/posts/2",
+		"https://blog.example.com/v1/posts/3",
+		"https://blog.example.com/categories/life/",
+		"https://blog.example.com/categories/programming/",
+		"https://blog.example.com/tags/web-dev/",
+		"https://blog.example.com/tags/go/",
+		"https://blog.example.com/tags/rust/",
+	}, locs(document))
+	assert.Equal(t, "2024-01-01T00:00:00Z", document.Urls[0].LastMod)
+	assert.Equal(t, "2024-02-01T00:00:00Z", document.Urls[5].LastMod)
+}
+
+func TestSitemapServicePages(t *testing.T) {
+	ctx := context.Background()
+	sitemapService := newSitemapService(2)
+	document := readSitemap(t, func(buffer *bytes.Buffer) error { return sitemapService.WriteSitemap(ctx, buffer) })
+	assert.Equal(t, "sitemapindex", document.XMLName.Local)
+	assert.Equal(t, []string{
+		"https://blog.example.com/sitemap-posts-1.xml",
+		"https://blog.example.com/sitemap-posts-2.xml",
+		"https://blog.example.com/sitemap-taxonomies-1.xml",
+		"https://blog.example.com/sitemap-taxonomies-2.xml",
+		"https://blog.example.com/sitemap-taxonomies-3.xml",
+	}, locs(document))
+
+	document = readSitemap(t, func(buffer *bytes.Buffer) error { return sitemapService.WritePostsSitemap(ctx, buffer, 2) })
+	assert.Equal(t, []string{"https://blog.example.com/v1/posts/3"}, locs(document))
+	document = readSitemap(t, func(buffer *bytes.Buffer) error { return sitemapService.WriteTaxonomiesSitemap(ctx, buffer, 2) })
+	assert.Equal(t, []string{"https://blog.example.com/tags/web-dev/", "https://blog.example.com/tags/go/"}, locs(document))
+	document = readSitemap(t, func(buffer *bytes.Buffer) error { return sitemapService.WriteTaxonomiesSitemap(ctx, buffer, 3) })
+	assert.Equal(t, []string{"https://blog.example.com/tags/rust/"}, locs(document))
+
+	for _, page := range []int{0, 4} {
+		err := sitemapService.WriteTaxonomiesSitemap(ctx, &bytes.Buffer{}, page)
+		assertAppError(t, err, exceptions.KindNotFound, exceptions.CodeNotFound)
+	}
+	err := sitemapService.WritePostsSitemap(ctx, &bytes.Buffer{}, 3)
+	assertAppError(t, err, exceptions.KindNotFound, exceptions.CodeNotFound)
+}
@@ -0,0 +1,172 @@
+package services_test
+
+import (
+	"blogging-platform-api/commands"
+	modelentities "blogging-platform-api/models/entities"
+	modelresponses "blogging-platform-api/models/responses"
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// siteTheme is a theme that renders the page data as plain lines so the tests can compare whole pages
+var siteTheme = map[string]string{
+	"layout.html":      `{{define "layout"}}title={{.Title}}` + "\n" + `{{template "content" .}}{{end}}`,
+	"index.html":       `{{define "content"}}{{template "listing" .}}{{end}}`,
+	"taxonomy.html":    `{{define "content"}}taxonomy={{.Taxonomy.Name}} count={{.Taxonomy.Count}} feed={{.FeedUrl}}` + "\n" + `{{template "listing" .}}{{end}}`,
+	"post.html":        `{{define "content"}}{{.Post.Content}}{{range .Post.Tags}}tag={{.Url}}` + "\n" + `{{end}}{{end}}`,
+	"static/style.css": "body {}",
+}
+
+const siteListing = `{{define "listing"}}page={{.Pagination.Page}}/{{.Pagination.TotalPages}} previous={{.Pagination.PreviousUrl}} next={{.Pagination.NextUrl}}` + "\n" +
+	`{{range .Posts}}{{.Title}}` + "\n" + `{{end}}{{end}}`
+
+func writeTheme(t *testing.T, files map[string]string) string {
+	t.Helper()
+	themeDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(themeDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return themeDir
+}
+
+func siteBlog(number int, category string, tags string) modelentities.Blog {
+	return modelentities.Blog{
+		Title:     pgtype.Text{Valid: true, String: "Post " + strconv.Itoa(number)},
+		Content:   pgtype.Text{Valid: true, String: "post *number* " + strconv.Itoa(number)},
+		Category:  pgtype.Text{Valid: true, String: category},
+		Tags:      pgtype.Text{Valid: true, String: tags},
+		CreatedAt: pgtype.Int8{Valid: true, Int64: int64(number) * 86400000},
+	}
+}
+
+// newSiteService renders five posts two per page, posts 1, 3 and 5 are in Programming and tagged go, every post is tagged Web Dev
+func newSiteService(themeDir string) services.StaticSiteService {
+	blogRepository := repositories.NewBlogMemoryRepository(
+		siteBlog(1, "Programming", "go, Web Dev"),
+		siteBlog(2, "Life", "Web Dev"),
+		siteBlog(3, "Programming", "go, Web Dev"),
+		siteBlog(4, "Life", "Web Dev"),
+		siteBlog(5, "Programming", "go,web dev"),
+	)
+	return services.NewStaticSiteService(utils.NewFakePostgresUtil(), blogRepository, themeDir, "https://blog.example.com", "Blog", 2)
+}
+
+func readSiteFile(t *testing.T, outputDir string, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestStaticSiteService(t *testing.T) {
+	theme := map[string]string{}
+	for name, content := range siteTheme {
+		theme[name] = content
+	}
+	for _, name := range []string{"index.html", "taxonomy.html"} {
+		theme[name] += siteListing
+	}
+	outputDir := t.TempDir()
+	require.NoError(t, newSiteService(writeTheme(t, theme)).Generate(context.Background(), outputDir))
+
+	t.Run("index pages list the newest posts first", func(t *testing.T) {
+		assert.Equal(t, "title=Blog\npage=1/3 previous= next=https://blog.example.com/page/2/\nPost 5\nPost 4\n", readSiteFile(t, outputDir, "index.html"))
+		assert.Equal(t, "title=Blog\npage=2/3 previous=https://blog.example.com/ next=https://blog.example.com/page/3/\nPost 3\nPost 2\n", readSiteFile(t, outputDir, "page/2/index.html"))
+		assert.Equal(t, "title=Blog\npage=3/3 previous=https://blog.example.com/page/2/ next=\nPost 1\n", readSiteFile(t, outputDir, "page/3/index.html"))
+		assert.NoFileExists(t, filepath.Join(outputDir, "page", "1", "index.html"))
+	})
+
+	t.Run("post pages render the markdown", func(t *testing.T) {
+		assert.Equal(t, "title=Post 1\n<p>post <em>number</em> 1</p>\ntag=https://blog.example.com/tags/go/\ntag=https://blog.example.com/tags/web-dev/\n", readSiteFile(t, outputDir, "posts/1/index.html"))
+	})
+
+	t.Run("category pages", func(t *testing.T) {
+		assert.Equal(t, "title=Programming\ntaxonomy=Programming count=3 feed=https://blog.example.com/categories/programming/feed.xml\n"+
+			"page=1/2 previous= next=https://blog.example.com/categories/programming/page/2/\nPost 5\nPost 3\n", readSiteFile(t, outputDir, "categories/programming/index.html"))
+		assert.Equal(t, "title=Programming\ntaxonomy=Programming count=3 feed=https://blog.example.com/categories/programming/feed.xml\n"+
+			"page=2/2 previous=https://blog.example.com/categories/programming/ next=\nPost 1\n", readSiteFile(t, outputDir, "categories/programming/page/2/index.html"))
+		assert.Contains(t, readSiteFile(t, outputDir, "categories/life/index.html"), "count=2")
+	})
+
+	t.Run("tags with the same slug share a page", func(t *testing.T) {
+		assert.Contains(t, readSiteFile(t, outputDir, "tags/go/index.html"), "count=3")
+		webDev := readSiteFile(t, outputDir, "tags/web-dev/index.html")
+		assert.Contains(t, webDev, "count=5")
+		assert.Contains(t, webDev, "page=1/3")
+	})
+
+	t.Run("feeds", func(t *testing.T) {
+		var feed modelresponses.SiteFeed
+		require.NoError(t, xml.Unmarshal([]byte(readSiteFile(t, outputDir, "feed.xml")), &feed))
+		assert.Equal(t, "Blog", feed.Channel.Title)
+		assert.Equal(t, "https://blog.example.com/", feed.Channel.Link)
+		require.Len(t, feed.Channel.Items, 5)
+		assert.Equal(t, modelresponses.SiteFeedItem{
+			Title:       "Post 5",
+			Link:        "https://blog.example.com/posts/5/",
+			Guid:        "https://blog.example.com/posts/5/",
+			PubDate:     "Tue, 06 Jan 1970 00:00:00 +0000",
+			Category:    "Programming",
+			Description: "post *number* 5",
+		}, feed.Channel.Items[0])
+
+		var tagFeed modelresponses.SiteFeed
+		require.NoError(t, xml.Unmarshal([]byte(readSiteFile(t, outputDir, "tags/go/feed.xml")), &tagFeed))
+		assert.Equal(t, "Blog - go", tagFeed.Channel.Title)
+		assert.Len(t, tagFeed.Channel.Items, 3)
+	})
+
+	t.Run("static files are copied", func(t *testing.T) {
+		assert.Equal(t, "body {}", readSiteFile(t, outputDir, "static/style.css"))
+	})
+}
+
+func TestStaticSiteServiceDefaultTheme(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, newSiteService(filepath.Join("..", "..", "..", "themes", "default")).Generate(context.Background(), outputDir))
+	assert.Contains(t, readSiteFile(t, outputDir, "posts/1/index.html"), "<em>number</em>")
+	assert.FileExists(t, filepath.Join(outputDir, "static", "style.css"))
+}
+
+func TestStaticSiteServiceInvalidTheme(t *testing.T) {
+	broken := map[string]string{}
+	for name, content := range siteTheme {
+		broken[name] = content
+	}
+	broken["post.html"] = `{{define "content"}}{{.Post.Title}`
+	tests := []struct {
+		name     string
+		themeDir string
+	}{
+		{name: "missing theme directory", themeDir: filepath.Join(t.TempDir(), "missing")},
+		{name: "theme without a page template", themeDir: writeTheme(t, map[string]string{"layout.html": siteTheme["layout.html"]})},
+		{name: "template that does not parse", themeDir: writeTheme(t, broken)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			require.Error(t, newSiteService(test.themeDir).Generate(context.Background(), outputDir))
+			entries, err := os.ReadDir(outputDir)
+			require.NoError(t, err)
+			assert.Empty(t, entries, "nothing is written before the theme is parsed")
+		})
+	}
+
+	// the site command reports the error with exit code 1
+	blogRepository := repositories.NewBlogMemoryRepository(siteBlog(1, "Programming", "go"))
+	exitCode := commands.GenerateSite(utils.NewFakePostgresUtil(), blogRepository, "", []string{"-out", t.TempDir(), "-theme", filepath.Join(t.TempDir(), "missing")})
+	assert.Equal(t, 1, exitCode)
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"blogging-platform-api/repositories"
+	"blogging-platform-api/services"
+	"blogging-platform-api/utils"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const wordpressExport = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author><wp:author_login>ada</wp:author_login><wp:author_display_name><![CDATA[Ada Lovelace]]></wp:author_display_name></wp:author>
+	<item>
+		<title>Hello Go</title>
+		<dc:creator>ada</dc:creator>
+		<content:encoded><![CDATA[<p>Go is <strong>fast</strong></p>]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_date_gmt>2024-01-02 03:04:05</wp:post_date_gmt>
+		<wp:post_name>hello-go</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="programming"><![CDATA[Programming]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[go]]></category>
+		<wp:comment>
+			<wp:comment_id>10</wp:comment_id>
+			<wp:comment_author>Grace</wp:comment_author>
+			<wp:comment_content>Nice post</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+			<wp:comment_date_gmt>2024-01-03 00:00:00</wp:comment_date_gmt>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>11</wp:comment_id>
+			<wp:comment_author>spammer</wp:comment_author>
+			<wp:comment_content>buy now</wp:comment_content>
+			<wp:comment_approved>spam</wp:comment_approved>
+		</wp:comment>
+	</item>
+	<item>
+		<title>No Name</title>
+		<dc:creator>linus</dc:creator>
+		<content:encoded>first body</content:encoded>
+		<wp:post_date_gmt>2024-02-01 00:00:00</wp:post_date_gmt>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>No Name</title>
+		<content:encoded>second body</content:encoded>
+		<wp:post_date_gmt>2024-03-01 00:00:00</wp:post_date_gmt>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>Draft</title>
+		<content:encoded>draft</content:encoded>
+		<wp:post_id>4</wp:post_id>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestWordpressImportService(t *testing.T) {
+	validate, _ := utils.NewValidator()
+	blogRepository := repositories.NewBlogMemoryRepository()
+	importService := services.NewWordpressImportService(utils.NewFakePostgresUtil(), validate, blogRepository)
+	ctx := context.Background()
+
+	response, err := importService.Import(ctx, strings.NewReader(wordpressExport))
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.Total)
+	assert.Equal(t, 3, response.Created)
+	assert.Equal(t, 1, response.Skipped)
+	assert.Equal(t, 0, response.Failed)
+	assert.Equal(t, 1, response.Comments)
+	assert.Equal(t, 1, response.SkippedComments)
+
+	// posts without a name or id get distinct slugs so they do not overwrite each other
+	require.Len(t, response.Records, 4)
+	assert.Equal(t, "hello-go", response.Records[0].Slug)
+	assert.True(t, strings.HasPrefix(response.Records[1].Slug, "no-name-"))
+	assert.True(t, strings.HasPrefix(response.Records[2].Slug, "no-name-"))
+	assert.NotEqual(t, response.Records[1].Slug, response.Records[2].Slug)
+
+	blog, err := blogRepository.FindById(nil, ctx, response.Records[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", blog.Author.String)
+	assert.Equal(t, "Go is **fast**\n", blog.Content.String)
+	assert.Equal(t, "Programming", blog.Category.String)
+	unnamed, err := blogRepository.FindById(nil, ctx, response.Records[1].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "linus", unnamed.Author.String)
+
+	t.Run("importing again updates the same posts and comments", func(t *testing.T) {
+		response, err := importService.Import(ctx, strings.NewReader(strings.Replace(wordpressExport, "Nice post", "Nice post, edited", 1)))
+		require.NoError(t, err)
+		assert.Equal(t, 0, response.Created)
+		assert.Equal(t, 3, response.Updated)
+		assert.Equal(t, 1, response.Comments)
+
+		count, err := blogRepository.CountAll(nil, ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		counts, err := blogRepository.CountComments(nil, ctx, []int{int(blog.Id.Int32)})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{int(blog.Id.Int32): 1}, counts)
+	})
+}
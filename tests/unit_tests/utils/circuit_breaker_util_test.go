@@ -0,0 +1,101 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCircuitBreaker(now *time.Time) *utils.CircuitBreaker {
+	breaker := utils.NewCircuitBreaker(3, 10*time.Second)
+	breaker.Now = func() time.Time {
+		return *now
+	}
+	return breaker
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newTestCircuitBreaker(&now)
+	errDown := syscall.ECONNREFUSED
+	calls := 0
+	fail := func() error {
+		calls++
+		return errDown
+	}
+	succeed := func() error {
+		calls++
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, errDown, breaker.Execute(fail))
+	}
+	assert.Equal(t, utils.CircuitClosed, breaker.State())
+	assert.NoError(t, breaker.Execute(succeed), "a success resets the failures")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, errDown, breaker.Execute(fail))
+	}
+	assert.Equal(t, utils.CircuitOpen, breaker.State())
+
+	calls = 0
+	assert.ErrorIs(t, breaker.Execute(succeed), utils.ErrCircuitOpen)
+	assert.Equal(t, 0, calls, "an open circuit fails fast")
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, errDown, breaker.Execute(fail), "the probe after the cooldown runs")
+	assert.Equal(t, utils.CircuitOpen, breaker.State(), "a failed probe opens the circuit again")
+	assert.ErrorIs(t, breaker.Execute(succeed), utils.ErrCircuitOpen)
+
+	now = now.Add(10 * time.Second)
+	assert.NoError(t, breaker.Execute(succeed))
+	assert.Equal(t, utils.CircuitClosed, breaker.State(), "a successful probe closes the circuit")
+}
+
+func TestCircuitBreakerLetsOneProbeThrough(t *testing.T) {
+	now := time.Now()
+	breaker := newTestCircuitBreaker(&now)
+	for i := 0; i < 3; i++ {
+		breaker.Execute(func() error { return syscall.ECONNREFUSED })
+	}
+	now = now.Add(10 * time.Second)
+
+	probeStarted := make(chan struct{})
+	probeDone := make(chan error)
+	release := make(chan struct{})
+	go func() {
+		probeDone <- breaker.Execute(func() error {
+			close(probeStarted)
+			<-release
+			return nil
+		})
+	}()
+	<-probeStarted
+	assert.Equal(t, utils.CircuitHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Execute(func() error { return nil }), utils.ErrCircuitOpen)
+	close(release)
+	assert.NoError(t, <-probeDone)
+	assert.Equal(t, utils.CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresQueryErrors(t *testing.T) {
+	now := time.Now()
+	breaker := newTestCircuitBreaker(&now)
+	for i := 0; i < 10; i++ {
+		breaker.Execute(func() error { return &pgconn.PgError{Code: "23505"} })
+		breaker.Execute(func() error { return errors.New("no rows") })
+	}
+	assert.Equal(t, utils.CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	breaker := utils.NewCircuitBreaker(0, time.Second)
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, breaker.Execute(func() error { return syscall.ECONNREFUSED }), syscall.ECONNREFUSED)
+	}
+}
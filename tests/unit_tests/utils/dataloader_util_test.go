@@ -0,0 +1,67 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataLoader(t *testing.T) {
+	var batches [][]int
+	loader := utils.NewDataLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		values := map[int]string{}
+		for _, key := range keys {
+			if key != 3 {
+				values[key] = "post " + strconv.Itoa(key)
+			}
+		}
+		return values, nil
+	})
+	ctx := context.Background()
+	loader.Prime(4, "primed")
+	first := loader.Load(ctx, 1)
+	second := loader.Load(ctx, 2)
+	missing := loader.Load(ctx, 3)
+	primed := loader.Load(ctx, 4)
+	again := loader.Load(ctx, 1)
+
+	value, found, err := second()
+	assert.Equal(t, "post 2", value)
+	assert.True(t, found)
+	assert.Nil(t, err)
+	value, _, _ = first()
+	assert.Equal(t, "post 1", value)
+	value, _, _ = again()
+	assert.Equal(t, "post 1", value)
+	_, found, err = missing()
+	assert.False(t, found)
+	assert.Nil(t, err)
+	value, _, _ = primed()
+	assert.Equal(t, "primed", value)
+	assert.Equal(t, [][]int{{1, 2, 3}}, batches)
+
+	// keys queued after the batch ran go into the next batch, loaded keys come from the cache
+	value, _, _ = loader.Load(ctx, 5)()
+	assert.Equal(t, "post 5", value)
+	loader.Load(ctx, 2)()
+	assert.Equal(t, [][]int{{1, 2, 3}, {5}}, batches)
+}
+
+func TestDataLoaderError(t *testing.T) {
+	errDatabase := errors.New("database is down")
+	loader := utils.NewDataLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+		return nil, errDatabase
+	})
+	first := loader.Load(context.Background(), "go")
+	second := loader.Load(context.Background(), "rust")
+	_, found, err := first()
+	assert.False(t, found)
+	assert.ErrorIs(t, err, errDatabase)
+	_, _, err = second()
+	assert.ErrorIs(t, err, errDatabase)
+}
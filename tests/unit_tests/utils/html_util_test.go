@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHtmlToMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "paragraphs", source: "<p>first</p><p>second</p>", want: "first\n\nsecond\n"},
+		{name: "blank lines without paragraphs", source: "first\n\nsecond\r\n\r\nthird", want: "first\n\nsecond\n\nthird\n"},
+		{name: "line break", source: "<p>one<br>two</p>", want: "one  \ntwo\n"},
+		{name: "headings", source: "<h1>Title</h1><h3>Section</h3><p>text</p>", want: "# Title\n\n### Section\n\ntext\n"},
+		{name: "emphasis", source: "<p><strong>bold</strong> <b>b</b> <em>italic</em> <i>i</i></p>", want: "**bold** **b** _italic_ _i_\n"},
+		{name: "inline code keeps its markup as text", source: "<p>run <code>go <b>test</b></code></p>", want: "run `go test`\n"},
+		{name: "preformatted block", source: "<pre>\nfunc main() {\n}\n</pre>", want: "```\nfunc main() {\n}\n```\n"},
+		{name: "quote", source: "<blockquote><p>one</p><p>two</p></blockquote>", want: "> one\n>\n> two\n"},
+		{name: "link and image", source: `<p><a href="https://go.dev">Go</a> <img src="/gopher.png" alt="gopher"></p>`, want: "[Go](https://go.dev) ![gopher](/gopher.png)\n"},
+		{name: "rule", source: "<p>above</p><hr><p>below</p>", want: "above\n\n---\n\nbelow\n"},
+		{name: "unordered list", source: "<ul><li>go</li><li>rust</li></ul>", want: "- go\n- rust\n"},
+		{name: "ordered list", source: "<ol><li>one</li><li>two</li></ol>", want: "1. one\n2. two\n"},
+		{name: "nested list", source: "<ul><li>go<ul><li>echo</li></ul></li></ul>", want: "- go\n  - echo\n"},
+		{name: "script and style are dropped", source: "<p>text</p><script>alert(1)</script><style>p{}</style>", want: "text\n"},
+		{name: "unknown tags keep their text", source: "<p><span>kept</span> <mark>too</mark></p>", want: "kept too\n"},
+		{name: "comments are dropped", source: "<p>text<!-- more --></p>", want: "text\n"},
+		{name: "entities are decoded", source: "<p>fish &amp; chips</p>", want: "fish & chips\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			markdown, err := utils.HtmlToMarkdown(test.source)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, markdown)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSignalSource hands out the channel Run listens on so the test can send signals without signalling the process
+type fakeSignalSource struct {
+	mutex   sync.Mutex
+	signals chan<- os.Signal
+	ready   chan struct{}
+}
+
+func newFakeSignalSource() *fakeSignalSource {
+	return &fakeSignalSource{ready: make(chan struct{})}
+}
+
+func (source *fakeSignalSource) Notify(signals chan<- os.Signal) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	source.signals = signals
+	close(source.ready)
+}
+
+func (source *fakeSignalSource) Stop(signals chan<- os.Signal) {
+}
+
+func (source *fakeSignalSource) send(signal os.Signal) {
+	<-source.ready
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	source.signals <- signal
+}
+
+// recorder keeps the order components were stopped in
+type recorder struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (recorder *recorder) record(event string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.events = append(recorder.events, event)
+}
+
+func (recorder *recorder) list() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]string{}, recorder.events...)
+}
+
+// addWorker adds a component whose run blocks until it is stopped like a server or a background worker
+func addWorker(lifecycleUtil utils.LifecycleUtil, recorder *recorder, name string) {
+	stop := make(chan struct{})
+	lifecycleUtil.Add(name, func() error {
+		<-stop
+		recorder.record(name + " returned")
+		return nil
+	}, func(ctx context.Context) error {
+		recorder.record(name + " stopping")
+		close(stop)
+		return nil
+	})
+}
+
+func TestLifecycleStopsInReverseOrderOnSignal(t *testing.T) {
+	for _, signal := range []os.Signal{os.Interrupt, syscall.SIGTERM} {
+		t.Run(signal.String(), func(t *testing.T) {
+			source := newFakeSignalSource()
+			lifecycleUtil := utils.NewLifecycleUtil(source, time.Second)
+			recorder := &recorder{}
+			lifecycleUtil.Add("database", nil, func(ctx context.Context) error {
+				recorder.record("database stopping")
+				return nil
+			})
+			addWorker(lifecycleUtil, recorder, "worker")
+			addWorker(lifecycleUtil, recorder, "server")
+			go source.send(signal)
+
+			exitCode := lifecycleUtil.Run(context.Background())
+			assert.Equal(t, utils.ExitCodeOk, exitCode)
+			assert.Equal(t, []string{"server stopping", "server returned", "worker stopping", "worker returned", "database stopping"}, recorder.list())
+		})
+	}
+}
+
+func TestLifecycleShutsDownWhenAComponentFails(t *testing.T) {
+	lifecycleUtil := utils.NewLifecycleUtil(newFakeSignalSource(), time.Second)
+	recorder := &recorder{}
+	lifecycleUtil.Add("database", nil, func(ctx context.Context) error {
+		recorder.record("database stopping")
+		return nil
+	})
+	lifecycleUtil.Add("server", func() error {
+		return errors.New("address already in use")
+	}, func(ctx context.Context) error {
+		recorder.record("server stopping")
+		return nil
+	})
+
+	exitCode := lifecycleUtil.Run(context.Background())
+	assert.Equal(t, utils.ExitCodeFailure, exitCode)
+	assert.Equal(t, []string{"server stopping", "database stopping"}, recorder.list())
+}
+
+func TestLifecycleShutdownTimeout(t *testing.T) {
+	source := newFakeSignalSource()
+	lifecycleUtil := utils.NewLifecycleUtil(source, 20*time.Millisecond)
+	recorder := &recorder{}
+	lifecycleUtil.Add("database", nil, func(ctx context.Context) error {
+		recorder.record("database stopping")
+		return nil
+	})
+	lifecycleUtil.Add("server", nil, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	go source.send(syscall.SIGTERM)
+
+	exitCode := lifecycleUtil.Run(context.Background())
+	assert.Equal(t, utils.ExitCodeShutdownTimeout, exitCode)
+	assert.Equal(t, []string{"database stopping"}, recorder.list(), "the database is still closed after the server timed out")
+}
+
+func TestLifecycleStopError(t *testing.T) {
+	lifecycleUtil := utils.NewLifecycleUtil(newFakeSignalSource(), time.Second)
+	lifecycleUtil.Add("tracing", nil, func(ctx context.Context) error {
+		return errors.New("collector unreachable")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	exitCode := lifecycleUtil.Run(ctx)
+	assert.Equal(t, utils.ExitCodeShutdownFailed, exitCode)
+}
+
+func TestLifecycleSecondSignalForcesExit(t *testing.T) {
+	source := newFakeSignalSource()
+	lifecycleUtil := utils.NewLifecycleUtil(source, time.Minute)
+	stopping := make(chan struct{})
+	lifecycleUtil.Add("server", nil, func(ctx context.Context) error {
+		close(stopping)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	go func() {
+		source.send(syscall.SIGTERM)
+		<-stopping
+		source.send(os.Interrupt)
+	}()
+
+	exitCode := lifecycleUtil.Run(context.Background())
+	assert.Equal(t, utils.ExitCodeForced, exitCode)
+}
@@ -0,0 +1,135 @@
+package utils_test
+
+import (
+	"blogging-platform-api/databases"
+	"blogging-platform-api/utils"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrationDatabase keeps the applied versions in memory so the migration util can be run without a database
+type fakeMigrationDatabase struct {
+	applied map[int64]int64
+}
+
+func (database *fakeMigrationDatabase) WithSession(ctx context.Context, lock bool, callback func(session utils.MigrationSession) error) error {
+	return callback(database)
+}
+
+func (database *fakeMigrationDatabase) CreateTable(ctx context.Context) error {
+	return nil
+}
+
+func (database *fakeMigrationDatabase) AppliedVersions(ctx context.Context) (map[int64]int64, error) {
+	return database.applied, nil
+}
+
+func (database *fakeMigrationDatabase) Apply(ctx context.Context, migration utils.Migration) error {
+	database.applied[migration.Version] = 1000
+	return nil
+}
+
+func (database *fakeMigrationDatabase) Revert(ctx context.Context, migration utils.Migration) error {
+	delete(database.applied, migration.Version)
+	return nil
+}
+
+func readMigrations(t *testing.T, files fstest.MapFS) (migrations []utils.Migration, err error) {
+	t.Helper()
+	migrationUtil, err := utils.NewMigrationUtil(utils.NewFakePostgresUtil(), files)
+	if err != nil {
+		return
+	}
+	return migrationUtil.(*utils.MigrationUtilImplementation).Migrations, nil
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestReadMigrations(t *testing.T) {
+	migrations, err := readMigrations(t, fstest.MapFS{
+		"migrations/0010_add_index.up.sql":      file("up 10"),
+		"migrations/0010_add_index.down.sql":    file("down 10"),
+		"migrations/0002_add_slug.down.sql":     file("down 2"),
+		"migrations/0002_add_slug.up.sql":       file("up 2"),
+		"migrations/0001_create_blogs.up.sql":   file("up 1"),
+		"migrations/0001_create_blogs.down.sql": file("down 1"),
+		"migrations/README.md":                  file("not a migration"),
+	})
+	require.NoError(t, err)
+	// versions are ordered as numbers, not as file names
+	assert.Equal(t, []utils.Migration{
+		{Version: 1, Name: "create_blogs", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "add_slug", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "add_index", Up: "up 10", Down: "down 10"},
+	}, migrations)
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{name: "missing up file", files: fstest.MapFS{"migrations/0001_create_blogs.down.sql": file("down 1")}, wantErr: "migration 1_create_blogs has no up file"},
+		{name: "version without a number", files: fstest.MapFS{"migrations/first_create_blogs.up.sql": file("up")}, wantErr: "must start with a numeric version"},
+		{name: "file without a direction", files: fstest.MapFS{"migrations/0001_create_blogs.sql": file("up")}, wantErr: "must end with .up.sql or .down.sql"},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"migrations/0001_create_blogs.up.sql": file("up 1"),
+				"migrations/0001_create_posts.up.sql": file("up 1"),
+			},
+			wantErr: "migration version 0001 is used by",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readMigrations(t, test.files)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.wantErr)
+		})
+	}
+}
+
+func TestMigrationWithoutDownFile(t *testing.T) {
+	migrations, err := readMigrations(t, fstest.MapFS{
+		"migrations/0001_create_blogs.up.sql":   file("up 1"),
+		"migrations/0001_create_blogs.down.sql": file("down 1"),
+		"migrations/0002_add_slug.up.sql":       file("up 2"),
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Empty(t, migrations[1].Down)
+
+	// a migration without a down file is applied but cannot be reverted
+	database := &fakeMigrationDatabase{applied: map[int64]int64{}}
+	migrationUtil := &utils.MigrationUtilImplementation{Database: database, Migrations: migrations}
+	ctx := context.Background()
+	applied, err := migrationUtil.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	_, err = migrationUtil.Down(ctx, 1)
+	assert.EqualError(t, err, "migration 2_add_slug has no down file")
+	assert.Len(t, database.applied, 2)
+}
+
+// TestEmbeddedMigrations fails when a migration is added without its down file or to only one of the dialects
+func TestEmbeddedMigrations(t *testing.T) {
+	migrationUtil, err := utils.NewMigrationUtil(utils.NewFakePostgresUtil(), databases.Migrations)
+	require.NoError(t, err)
+	sqliteMigrationUtil, err := utils.NewMigrationUtil(utils.NewFakePostgresUtil(), databases.SqliteMigrations)
+	require.NoError(t, err)
+	migrations := migrationUtil.(*utils.MigrationUtilImplementation).Migrations
+	sqliteMigrations := sqliteMigrationUtil.(*utils.MigrationUtilImplementation).Migrations
+	require.Len(t, sqliteMigrations, len(migrations))
+	for i, migration := range migrations {
+		assert.NotEmpty(t, migration.Down, migration.Name)
+		assert.NotEmpty(t, sqliteMigrations[i].Down, sqliteMigrations[i].Name)
+		assert.Equal(t, migration.Version, sqliteMigrations[i].Version)
+		assert.Equal(t, migration.Name, sqliteMigrations[i].Name)
+	}
+}
@@ -0,0 +1,62 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTx fails its commit with errCommit, the other methods of pgx.Tx are not used by CommitOrRollback
+type stubTx struct {
+	pgx.Tx
+	errCommit  error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *stubTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return tx.errCommit
+}
+
+func (tx *stubTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	if tx.committed {
+		return pgx.ErrTxClosed
+	}
+	return nil
+}
+
+func TestCommitOrRollback(t *testing.T) {
+	errSerialization := &pgconn.PgError{Code: "40001"}
+	errCallback := errors.New("callback")
+	tests := []struct {
+		name           string
+		err            error
+		errCommit      error
+		wantErr        error
+		wantCommitted  bool
+		wantRolledBack bool
+	}{
+		{name: "commit", wantCommitted: true},
+		{name: "failed commit is returned", errCommit: errSerialization, wantErr: errSerialization, wantCommitted: true, wantRolledBack: true},
+		{name: "rollback after an error", err: errCallback, wantRolledBack: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := &stubTx{errCommit: test.errCommit}
+			err := (&utils.PostgresUtilImplementation{}).CommitOrRollback(tx, context.Background(), test.err)
+			assert.ErrorIs(t, err, test.wantErr)
+			if test.wantErr == nil {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantCommitted, tx.committed)
+			assert.Equal(t, test.wantRolledBack, tx.rolledBack)
+		})
+	}
+}
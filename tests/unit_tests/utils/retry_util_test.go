@@ -0,0 +1,101 @@
+package utils_test
+
+import (
+	"blogging-platform-api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	errRetryable := errors.New("retryable")
+	errFatal := errors.New("fatal")
+	tests := []struct {
+		name      string
+		attempts  int
+		errors    []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success on first call", attempts: 3, errors: []error{nil}, wantCalls: 1},
+		{name: "success after retries", attempts: 3, errors: []error{errRetryable, errRetryable, nil}, wantCalls: 3},
+		{name: "attempts used up", attempts: 3, errors: []error{errRetryable, errRetryable, errRetryable, nil}, wantCalls: 3, wantErr: errRetryable},
+		{name: "error that is not retryable", attempts: 3, errors: []error{errFatal, nil}, wantCalls: 1, wantErr: errFatal},
+		{name: "zero attempts still calls once", attempts: 0, errors: []error{errRetryable, nil}, wantCalls: 1, wantErr: errRetryable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := utils.RetryPolicy{Attempts: test.attempts, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+			calls := 0
+			err := utils.Retry(context.Background(), policy, func(err error) bool {
+				return errors.Is(err, errRetryable)
+			}, func() error {
+				err := test.errors[calls]
+				calls++
+				return err
+			})
+			assert.Equal(t, test.wantCalls, calls)
+			assert.Equal(t, test.wantErr, err)
+		})
+	}
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := utils.RetryPolicy{Attempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	calls := 0
+	errRetryable := errors.New("retryable")
+	err := utils.Retry(ctx, policy, func(err error) bool { return true }, func() error {
+		calls++
+		cancel()
+		return errRetryable
+	})
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, errRetryable, err)
+}
+
+func TestBackoff(t *testing.T) {
+	policy := utils.RetryPolicy{Attempts: 10, BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+	for attempt, limit := range []time.Duration{10, 20, 40, 80, 100, 100, 100} {
+		for i := 0; i < 100; i++ {
+			delay := policy.Backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, limit*time.Millisecond)
+		}
+	}
+	assert.LessOrEqual(t, policy.Backoff(100), 100*time.Millisecond)
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryable  bool
+		wantConnection bool
+	}{
+		{name: "nil", err: nil},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, wantRetryable: true},
+		{name: "deadlock", err: fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}), wantRetryable: true},
+		{name: "database starting", err: &pgconn.PgError{Code: "57P03"}, wantRetryable: true, wantConnection: true},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: "57P01"}, wantConnection: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, wantConnection: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), wantConnection: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, wantConnection: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "other error", err: errors.New("other")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantRetryable, utils.IsRetryableError(test.err))
+			assert.Equal(t, test.wantConnection, utils.IsConnectionError(test.err))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open, the database is unavailable")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// CircuitBreaker opens after Threshold consecutive failures and fails fast with ErrCircuitOpen for Cooldown,
+// one probe call is then let through and its result closes or opens the circuit again
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	// IsFailure decides which errors count as failures, other errors count as successes because the database answered
+	IsFailure func(err error) bool
+	Now       func() time.Time
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		IsFailure: IsConnectionError,
+		Now:       time.Now,
+	}
+}
+
+// Execute runs operation unless the circuit is open and records its result
+func (breaker *CircuitBreaker) Execute(operation func() error) error {
+	if breaker == nil || breaker.Threshold == 0 {
+		return operation()
+	}
+	probe, err := breaker.allow()
+	if err != nil {
+		return err
+	}
+	err = operation()
+	breaker.record(probe, breaker.IsFailure(err))
+	return err
+}
+
+func (breaker *CircuitBreaker) State() CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	return breaker.state
+}
+
+func (breaker *CircuitBreaker) allow() (probe bool, err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	switch breaker.state {
+	case CircuitOpen:
+		if breaker.Now().Sub(breaker.openedAt) < breaker.Cooldown {
+			return false, ErrCircuitOpen
+		}
+		breaker.state = CircuitHalfOpen
+		breaker.probing = true
+		return true, nil
+	case CircuitHalfOpen:
+		if breaker.probing {
+			return false, ErrCircuitOpen
+		}
+		breaker.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+func (breaker *CircuitBreaker) record(probe bool, failed bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if probe {
+		breaker.probing = false
+	}
+	if !failed {
+		if breaker.state != CircuitClosed {
+			slog.Info("database: circuit breaker closed")
+		}
+		breaker.state = CircuitClosed
+		breaker.failures = 0
+		return
+	}
+	breaker.failures++
+	if breaker.state == CircuitHalfOpen || (breaker.state == CircuitClosed && breaker.failures >= breaker.Threshold) {
+		if breaker.state == CircuitClosed {
+			slog.Warn("database: circuit breaker opened", "failures", breaker.failures)
+		}
+		breaker.state = CircuitOpen
+		breaker.openedAt = breaker.Now()
+	}
+}
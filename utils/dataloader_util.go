@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// DataLoader collects the keys loaded while a graphql query resolves one level of fields and fetches them with one call of Batch,
+// Load only queues the key and returns a thunk, the first thunk that is called runs Batch for every queued key,
+// results are cached so a key is fetched once per loader, a loader is meant to live for one request
+type DataLoader[K comparable, V any] struct {
+	// Batch returns the values of keys that exist, a key missing from values is not found
+	Batch func(ctx context.Context, keys []K) (values map[K]V, err error)
+
+	mutex   sync.Mutex
+	queued  []K
+	results map[K]*dataLoaderResult[V]
+}
+
+type dataLoaderResult[V any] struct {
+	value V
+	found bool
+	err   error
+	done  bool
+}
+
+func NewDataLoader[K comparable, V any](batch func(ctx context.Context, keys []K) (values map[K]V, err error)) *DataLoader[K, V] {
+	return &DataLoader[K, V]{
+		Batch:   batch,
+		results: map[K]*dataLoaderResult[V]{},
+	}
+}
+
+// Load queues key unless it is already queued or loaded, the thunk returns found false for a key Batch did not return
+func (loader *DataLoader[K, V]) Load(ctx context.Context, key K) func() (value V, found bool, err error) {
+	loader.mutex.Lock()
+	if _, ok := loader.results[key]; !ok {
+		loader.results[key] = &dataLoaderResult[V]{}
+		loader.queued = append(loader.queued, key)
+	}
+	loader.mutex.Unlock()
+	return func() (value V, found bool, err error) {
+		loader.mutex.Lock()
+		defer loader.mutex.Unlock()
+		result := loader.results[key]
+		if !result.done {
+			loader.dispatch(ctx)
+		}
+		return result.value, result.found, result.err
+	}
+}
+
+// Prime stores value for key so loading it does not call Batch, a key that is already loaded keeps its value
+func (loader *DataLoader[K, V]) Prime(key K, value V) {
+	loader.mutex.Lock()
+	defer loader.mutex.Unlock()
+	if result, ok := loader.results[key]; ok && result.done {
+		return
+	}
+	if _, ok := loader.results[key]; !ok {
+		loader.results[key] = &dataLoaderResult[V]{}
+	}
+	*loader.results[key] = dataLoaderResult[V]{value: value, found: true, done: true}
+}
+
+// dispatch runs Batch for the queued keys that are not primed meanwhile, an error of Batch is the error of every key of the batch,
+// it must be called with the lock held
+func (loader *DataLoader[K, V]) dispatch(ctx context.Context) {
+	var keys []K
+	for _, key := range loader.queued {
+		if !loader.results[key].done {
+			keys = append(keys, key)
+		}
+	}
+	loader.queued = nil
+	if len(keys) == 0 {
+		return
+	}
+	values, err := loader.Batch(ctx, keys)
+	for _, key := range keys {
+		value, found := values[key]
+		*loader.results[key] = dataLoaderResult[V]{value: value, found: found, err: err, done: true}
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var blankLinesRegexp = regexp.MustCompile(`\n([ \t]*\n)+`)
+
+// HtmlToMarkdown converts the html used by blog bodies (paragraphs, headings, lists, links, images, emphasis, code and quotes) to markdown, unknown tags keep only their text
+func HtmlToMarkdown(source string) (string, error) {
+	// bodies exported from wordpress separate paragraphs with blank lines instead of <p> tags
+	if !strings.Contains(source, "<p") {
+		paragraphs := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n\n")
+		source = "<p>" + strings.Join(paragraphs, "</p><p>") + "</p>"
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(source), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	for _, node := range nodes {
+		writeMarkdown(&builder, node, "")
+	}
+	markdown := blankLinesRegexp.ReplaceAllString(builder.String(), "\n\n")
+	return strings.TrimSpace(markdown) + "\n", nil
+}
+
+func writeMarkdown(builder *strings.Builder, node *html.Node, listPrefix string) {
+	switch node.Type {
+	case html.TextNode:
+		text := strings.ReplaceAll(node.Data, "\n", " ")
+		builder.WriteString(text)
+		return
+	case html.CommentNode:
+		return
+	case html.ElementNode:
+	default:
+		writeChildren(builder, node, listPrefix)
+		return
+	}
+
+	switch node.Data {
+	case "p", "div", "figure":
+		builder.WriteString("\n\n")
+		writeChildren(builder, node, listPrefix)
+		builder.WriteString("\n\n")
+	case "br":
+		builder.WriteString("  \n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(node.Data[1:])
+		builder.WriteString("\n\n" + strings.Repeat("#", level) + " ")
+		writeChildren(builder, node, listPrefix)
+		builder.WriteString("\n\n")
+	case "strong", "b":
+		builder.WriteString("**")
+		writeChildren(builder, node, listPrefix)
+		builder.WriteString("**")
+	case "em", "i":
+		builder.WriteString("_")
+		writeChildren(builder, node, listPrefix)
+		builder.WriteString("_")
+	case "code":
+		builder.WriteString("`")
+		builder.WriteString(textContent(node))
+		builder.WriteString("`")
+	case "pre":
+		builder.WriteString("\n\n```\n")
+		builder.WriteString(strings.Trim(textContent(node), "\n"))
+		builder.WriteString("\n```\n\n")
+	case "blockquote":
+		var quote strings.Builder
+		writeChildren(&quote, node, listPrefix)
+		builder.WriteString("\n\n")
+		for _, line := range strings.Split(strings.TrimSpace(blankLinesRegexp.ReplaceAllString(quote.String(), "\n\n")), "\n") {
+			builder.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		builder.WriteString("\n")
+	case "a":
+		builder.WriteString("[")
+		writeChildren(builder, node, listPrefix)
+		builder.WriteString("](" + attribute(node, "href") + ")")
+	case "img":
+		builder.WriteString("![" + attribute(node, "alt") + "](" + attribute(node, "src") + ")")
+	case "hr":
+		builder.WriteString("\n\n---\n\n")
+	case "ul", "ol":
+		builder.WriteString("\n\n")
+		number := 0
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || child.Data != "li" {
+				continue
+			}
+			number++
+			bullet := "- "
+			if node.Data == "ol" {
+				bullet = strconv.Itoa(number) + ". "
+			}
+			builder.WriteString(listPrefix + bullet)
+			var item strings.Builder
+			writeChildren(&item, child, listPrefix+"  ")
+			builder.WriteString(strings.TrimSpace(blankLinesRegexp.ReplaceAllString(item.String(), "\n")) + "\n")
+		}
+		builder.WriteString("\n")
+	case "script", "style":
+	default:
+		writeChildren(builder, node, listPrefix)
+	}
+}
+
+func writeChildren(builder *strings.Builder, node *html.Node, listPrefix string) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeMarkdown(builder, child, listPrefix)
+	}
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(textContent(child))
+	}
+	return builder.String()
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exit codes of LifecycleUtil.Run, a failed start ends with ExitCodeFailure through Fatal
+const (
+	ExitCodeOk              = 0
+	ExitCodeFailure         = 1
+	ExitCodeShutdownFailed  = 3
+	ExitCodeShutdownTimeout = 4
+	ExitCodeForced          = 130
+)
+
+// SignalSource delivers the signals that stop the process, tests use a fake one
+type SignalSource interface {
+	Notify(signals chan<- os.Signal)
+	Stop(signals chan<- os.Signal)
+}
+
+type OsSignalSourceImplementation struct {
+}
+
+func NewOsSignalSource() SignalSource {
+	return &OsSignalSourceImplementation{}
+}
+
+func (source *OsSignalSourceImplementation) Notify(signals chan<- os.Signal) {
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+}
+
+func (source *OsSignalSourceImplementation) Stop(signals chan<- os.Signal) {
+	signal.Stop(signals)
+}
+
+type LifecycleUtil interface {
+	// Add registers a component, run is started in its own goroutine by Run and may be nil for components that only need to be stopped,
+	// stop is called on shutdown in the reverse order of Add and run must return after it
+	Add(name string, run func() error, stop func(ctx context.Context) error)
+	// Run starts every component and blocks until a signal arrives, ctx is done or a run returns an error, it then stops every component
+	// and returns the exit code of the process
+	Run(ctx context.Context) (exitCode int)
+}
+
+type lifecycleComponent struct {
+	name string
+	run  func() error
+	stop func(ctx context.Context) error
+	done chan struct{}
+}
+
+type LifecycleUtilImplementation struct {
+	SignalSource    SignalSource
+	ShutdownTimeout time.Duration
+
+	mutex      sync.Mutex
+	components []*lifecycleComponent
+}
+
+func NewLifecycleUtil(signalSource SignalSource, shutdownTimeout time.Duration) LifecycleUtil {
+	return &LifecycleUtilImplementation{
+		SignalSource:    signalSource,
+		ShutdownTimeout: shutdownTimeout,
+	}
+}
+
+func (util *LifecycleUtilImplementation) Add(name string, run func() error, stop func(ctx context.Context) error) {
+	util.mutex.Lock()
+	defer util.mutex.Unlock()
+	util.components = append(util.components, &lifecycleComponent{name: name, run: run, stop: stop, done: make(chan struct{})})
+}
+
+func (util *LifecycleUtilImplementation) Run(ctx context.Context) (exitCode int) {
+	signals := make(chan os.Signal, 2)
+	util.SignalSource.Notify(signals)
+	defer util.SignalSource.Stop(signals)
+
+	util.mutex.Lock()
+	components := util.components
+	util.mutex.Unlock()
+
+	failures := make(chan error, len(components))
+	for _, component := range components {
+		if component.run == nil {
+			close(component.done)
+			continue
+		}
+		go func(component *lifecycleComponent) {
+			defer close(component.done)
+			if err := component.run(); err != nil {
+				failures <- errors.New(component.name + ": " + err.Error())
+			}
+		}(component)
+	}
+
+	exitCode = ExitCodeOk
+	select {
+	case received := <-signals:
+		slog.Info("lifecycle: shutting down", "signal", received.String())
+	case <-ctx.Done():
+		slog.Info("lifecycle: shutting down", "reason", ctx.Err().Error())
+	case err := <-failures:
+		slog.Error("lifecycle: shutting down after a failure", "error", err)
+		exitCode = ExitCodeFailure
+	}
+
+	// a second signal gives up on a clean shutdown, the remaining components are not stopped
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), util.ShutdownTimeout)
+	defer cancel()
+	stopped := make(chan int, 1)
+	go func() {
+		stopped <- util.stop(shutdownCtx, components)
+	}()
+	select {
+	case stopExitCode := <-stopped:
+		if exitCode == ExitCodeOk {
+			exitCode = stopExitCode
+		}
+	case received := <-signals:
+		slog.Error("lifecycle: forced to exit", "signal", received.String())
+		cancel()
+		return ExitCodeForced
+	}
+	slog.Info("lifecycle: stopped", "exit_code", exitCode)
+	return exitCode
+}
+
+// stop stops the components in the reverse order of Add and waits for their run to return, a component that fails to stop
+// does not keep the others from stopping
+func (util *LifecycleUtilImplementation) stop(ctx context.Context, components []*lifecycleComponent) (exitCode int) {
+	exitCode = ExitCodeOk
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		start := time.Now()
+		var err error
+		if component.stop != nil {
+			err = component.stop(ctx)
+		}
+		if err == nil {
+			select {
+			case <-component.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			slog.Error("lifecycle: error when stopping", "component", component.name, "error", err)
+			if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				exitCode = ExitCodeShutdownTimeout
+			} else if exitCode == ExitCodeOk {
+				exitCode = ExitCodeShutdownFailed
+			}
+			continue
+		}
+		slog.Info("lifecycle: stopped", "component", component.name, "latency_ms", float64(time.Since(start).Microseconds())/1000)
+	}
+	return
+}
+
+// Sleep waits for duration or until ctx is done, it lets a shutdown step wait without outliving the shutdown timeout
+func Sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIdContextKey struct{}
+
+// SetupLogger makes slog write json lines to stderr at level (debug, info, warn or error, anything else is info),
+// stderr keeps stdout free for the output of commands, records logged with a context get the request id and the trace id of that context
+func SetupLogger(level string) {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		logLevel = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(NewLogHandler(os.Stderr, logLevel)))
+}
+
+// NewLogHandler writes json lines to writer and adds the ids of the context of every record
+func NewLogHandler(writer io.Writer, level slog.Leveler) slog.Handler {
+	return &contextHandler{Handler: slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: level})}
+}
+
+// Fatal logs msg at error level and exits with status 1, it is used for configuration errors on start
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey{}).(string)
+	return requestId
+}
+
+// contextHandler adds request_id, trace_id and span_id of the context of a record so every log line of a request can be found
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestIdFromContext(ctx); requestId != "" {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: handler.Handler.WithGroup(name)}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// ParseFrontMatter decodes the yaml front matter between the leading --- lines into out and returns the markdown body after it
+func ParseFrontMatter(data []byte, out interface{}) (body string, err error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte(frontMatterDelimiter+"\n")) {
+		err = errors.New("front matter not found")
+		return
+	}
+	rest := data[len(frontMatterDelimiter)+1:]
+	end := bytes.Index(rest, []byte("\n"+frontMatterDelimiter))
+	if end < 0 {
+		err = errors.New("front matter is not closed")
+		return
+	}
+	err = yaml.Unmarshal(rest[:end], out)
+	if err != nil {
+		return
+	}
+	rest = rest[end+len(frontMatterDelimiter)+1:]
+	body = string(bytes.TrimLeft(rest, "\n"))
+	return
+}
+
+// WriteFrontMatter writes frontMatter as yaml between --- lines followed by the markdown body, the output can be read back with ParseFrontMatter
+func WriteFrontMatter(writer io.Writer, frontMatter interface{}, body string) error {
+	data, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, frontMatterDelimiter+"\n"+string(data)+frontMatterDelimiter+"\n"+body)
+	return err
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsUtil keeps every prometheus metric of the api in its own registry so tests can create as many as they need
+type MetricsUtil interface {
+	ObserveHttpRequest(method string, route string, status int, duration time.Duration)
+	CountServiceOperation(operation string, result string)
+	ObserveQuery(method string, result string, duration time.Duration)
+	RegisterPostgresPools(postgresUtil PostgresUtil)
+	Handler() http.Handler
+}
+
+type MetricsUtilImplementation struct {
+	Registry            *prometheus.Registry
+	HttpRequestDuration *prometheus.HistogramVec
+	ServiceOperations   *prometheus.CounterVec
+	QueryDuration       *prometheus.HistogramVec
+}
+
+func NewMetricsUtil() MetricsUtil {
+	util := &MetricsUtilImplementation{
+		Registry: prometheus.NewRegistry(),
+		HttpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of http requests by method, route template and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		ServiceOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "blog_service_operations_total",
+			Help: "Blog service operations by operation and result, result is ok or the kind of the error.",
+		}, []string{"operation", "result"}),
+		QueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "blog_repository_query_duration_seconds",
+			Help:    "Duration of every blog repository call by method and result, retried calls are observed once per attempt.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method", "result"}),
+	}
+	util.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		util.HttpRequestDuration,
+		util.ServiceOperations,
+		util.QueryDuration,
+	)
+	return util
+}
+
+func (util *MetricsUtilImplementation) ObserveHttpRequest(method string, route string, status int, duration time.Duration) {
+	util.HttpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (util *MetricsUtilImplementation) CountServiceOperation(operation string, result string) {
+	util.ServiceOperations.WithLabelValues(operation, result).Inc()
+}
+
+func (util *MetricsUtilImplementation) ObserveQuery(method string, result string, duration time.Duration) {
+	util.QueryDuration.WithLabelValues(method, result).Observe(duration.Seconds())
+}
+
+// RegisterPostgresPools exports pool.Stat() of the primary and of every replica, nothing is exported for sqlite which has no pool
+func (util *MetricsUtilImplementation) RegisterPostgresPools(postgresUtil PostgresUtil) {
+	pools := map[string]*pgxpool.Pool{}
+	if postgresUtil.GetPool() != nil {
+		pools["primary"] = postgresUtil.GetPool()
+	}
+	if implementation, ok := postgresUtil.(*PostgresUtilImplementation); ok {
+		for _, replica := range implementation.replicas {
+			pools[replica.host] = replica.pool
+		}
+	}
+	if len(pools) > 0 {
+		util.Registry.MustRegister(&poolCollector{pools: pools})
+	}
+}
+
+// Handler serves the registry in the prometheus text exposition format
+func (util *MetricsUtilImplementation) Handler() http.Handler {
+	return promhttp.HandlerFor(util.Registry, promhttp.HandlerOpts{})
+}
+
+var (
+	poolAcquiredDescription     = prometheus.NewDesc("pgxpool_acquired_connections", "Connections currently in use.", []string{"pool"}, nil)
+	poolIdleDescription         = prometheus.NewDesc("pgxpool_idle_connections", "Idle connections in the pool.", []string{"pool"}, nil)
+	poolTotalDescription        = prometheus.NewDesc("pgxpool_total_connections", "Open connections in the pool.", []string{"pool"}, nil)
+	poolMaxDescription          = prometheus.NewDesc("pgxpool_max_connections", "Maximum size of the pool.", []string{"pool"}, nil)
+	poolAcquireDescription      = prometheus.NewDesc("pgxpool_acquires_total", "Successful acquires from the pool.", []string{"pool"}, nil)
+	poolEmptyAcquireDescription = prometheus.NewDesc("pgxpool_empty_acquires_total", "Acquires that had to wait because the pool was empty.", []string{"pool"}, nil)
+	poolAcquireWaitDescription  = prometheus.NewDesc("pgxpool_acquire_wait_seconds_total", "Total time spent waiting for a connection.", []string{"pool"}, nil)
+)
+
+// poolCollector reads the pool statistics on every scrape instead of copying them on a timer
+type poolCollector struct {
+	pools map[string]*pgxpool.Pool
+}
+
+func (collector *poolCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- poolAcquiredDescription
+	descriptions <- poolIdleDescription
+	descriptions <- poolTotalDescription
+	descriptions <- poolMaxDescription
+	descriptions <- poolAcquireDescription
+	descriptions <- poolEmptyAcquireDescription
+	descriptions <- poolAcquireWaitDescription
+}
+
+func (collector *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	for name, pool := range collector.pools {
+		stat := pool.Stat()
+		metrics <- prometheus.MustNewConstMetric(poolAcquiredDescription, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+		metrics <- prometheus.MustNewConstMetric(poolIdleDescription, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+		metrics <- prometheus.MustNewConstMetric(poolTotalDescription, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+		metrics <- prometheus.MustNewConstMetric(poolMaxDescription, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+		metrics <- prometheus.MustNewConstMetric(poolAcquireDescription, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+		metrics <- prometheus.MustNewConstMetric(poolEmptyAcquireDescription, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+		metrics <- prometheus.MustNewConstMetric(poolAcquireWaitDescription, prometheus.CounterValue, stat.AcquireDuration().Seconds(), name)
+	}
+}
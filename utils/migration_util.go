@@ -0,0 +1,425 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockKey is the postgres advisory lock held while migrations run so concurrent instances wait for each other
+const migrationLockKey = 7202410220001
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type MigrationUtil interface {
+	Up(ctx context.Context) (applied []Migration, err error)
+	Down(ctx context.Context, steps int) (reverted []Migration, err error)
+	To(ctx context.Context, version int64) (changed []Migration, err error)
+	Status(ctx context.Context) (statuses []MigrationStatus, err error)
+	Pending(ctx context.Context) (pending int, err error)
+}
+
+// MigrationDatabase gives the migration util a session on one connection, the session holds the migration lock when lock is true
+type MigrationDatabase interface {
+	WithSession(ctx context.Context, lock bool, callback func(session MigrationSession) error) error
+}
+
+// MigrationSession runs the statements of one database dialect, Apply and Revert run a migration and its bookkeeping in one transaction
+type MigrationSession interface {
+	CreateTable(ctx context.Context) error
+	AppliedVersions(ctx context.Context) (map[int64]int64, error)
+	Apply(ctx context.Context, migration Migration) error
+	Revert(ctx context.Context, migration Migration) error
+}
+
+type MigrationUtilImplementation struct {
+	Database   MigrationDatabase
+	Migrations []Migration
+}
+
+// NewMigrationUtil reads every {version}_{name}.up.sql and {version}_{name}.down.sql file of the migrations directory in files
+func NewMigrationUtil(postgresUtil PostgresUtil, files fs.FS) (MigrationUtil, error) {
+	migrations, err := readMigrations(files)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationUtilImplementation{
+		Database:   &postgresMigrationDatabase{postgresUtil: postgresUtil},
+		Migrations: migrations,
+	}, nil
+}
+
+// NewSqliteMigrationUtil is NewMigrationUtil for a sqlite database, files must hold sqlite migrations
+func NewSqliteMigrationUtil(sqliteUtil SqliteUtil, files fs.FS) (MigrationUtil, error) {
+	migrations, err := readMigrations(files)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationUtilImplementation{
+		Database:   &sqliteMigrationDatabase{db: sqliteUtil.GetDB()},
+		Migrations: migrations,
+	}, nil
+}
+
+func readMigrations(files fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrationsByVersion := map[int64]*Migration{}
+	for _, filePath := range paths {
+		fileName := path.Base(filePath)
+		var direction string
+		var found bool
+		fileName, direction, found = cutDirection(fileName)
+		if !found {
+			return nil, errors.New("migration " + filePath + " must end with .up.sql or .down.sql")
+		}
+		versionText, name, _ := strings.Cut(fileName, "_")
+		version, err := strconv.ParseInt(versionText, 10, 64)
+		if err != nil {
+			return nil, errors.New("migration " + filePath + " must start with a numeric version")
+		}
+		content, err := fs.ReadFile(files, filePath)
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			migrationsByVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, errors.New("migration version " + versionText + " is used by " + migration.Name + " and " + name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, errors.New("migration " + strconv.FormatInt(migration.Version, 10) + "_" + migration.Name + " has no up file")
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func cutDirection(fileName string) (string, string, bool) {
+	if name, found := strings.CutSuffix(fileName, ".up.sql"); found {
+		return name, "up", true
+	}
+	if name, found := strings.CutSuffix(fileName, ".down.sql"); found {
+		return name, "down", true
+	}
+	return fileName, "", false
+}
+
+// Up applies every pending migration in version order
+func (util *MigrationUtilImplementation) Up(ctx context.Context) (applied []Migration, err error) {
+	return util.To(ctx, util.latestVersion())
+}
+
+// Down reverts the last steps applied migrations
+func (util *MigrationUtilImplementation) Down(ctx context.Context, steps int) (reverted []Migration, err error) {
+	err = util.Database.WithSession(ctx, true, func(session MigrationSession) error {
+		appliedVersions, err := session.AppliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(util.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := util.Migrations[i]
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+			err = revert(ctx, session, migration)
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// To applies pending migrations up to version and reverts applied migrations above it
+func (util *MigrationUtilImplementation) To(ctx context.Context, version int64) (changed []Migration, err error) {
+	err = util.Database.WithSession(ctx, true, func(session MigrationSession) error {
+		appliedVersions, err := session.AppliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(util.Migrations) - 1; i >= 0; i-- {
+			migration := util.Migrations[i]
+			if _, ok := appliedVersions[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			err = revert(ctx, session, migration)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, migration)
+		}
+		for _, migration := range util.Migrations {
+			if _, ok := appliedVersions[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			err = session.Apply(ctx, migration)
+			if err != nil {
+				return errors.New("error when applying migration " + migrationName(migration) + ": " + err.Error())
+			}
+			changed = append(changed, migration)
+		}
+		return nil
+	})
+	return
+}
+
+func (util *MigrationUtilImplementation) Status(ctx context.Context) (statuses []MigrationStatus, err error) {
+	err = util.Database.WithSession(ctx, false, func(session MigrationSession) error {
+		appliedVersions, err := session.AppliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range util.Migrations {
+			appliedAt, applied := appliedVersions[migration.Version]
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name, Applied: applied}
+			if applied {
+				status.AppliedAt = time.UnixMilli(appliedAt).UTC()
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return
+}
+
+// Pending returns how many embedded migrations are not applied yet
+func (util *MigrationUtilImplementation) Pending(ctx context.Context) (pending int, err error) {
+	statuses, err := util.Status(ctx)
+	if err != nil {
+		return
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	return
+}
+
+func (util *MigrationUtilImplementation) latestVersion() int64 {
+	if len(util.Migrations) == 0 {
+		return 0
+	}
+	return util.Migrations[len(util.Migrations)-1].Version
+}
+
+func revert(ctx context.Context, session MigrationSession, migration Migration) error {
+	if migration.Down == "" {
+		return errors.New("migration " + migrationName(migration) + " has no down file")
+	}
+	err := session.Revert(ctx, migration)
+	if err != nil {
+		return errors.New("error when reverting migration " + migrationName(migration) + ": " + err.Error())
+	}
+	return nil
+}
+
+func migrationName(migration Migration) string {
+	return strconv.FormatInt(migration.Version, 10) + "_" + migration.Name
+}
+
+type postgresMigrationDatabase struct {
+	postgresUtil PostgresUtil
+}
+
+// WithSession runs callback on one acquired connection, advisory locks belong to a session so the same connection is used for every statement
+func (database *postgresMigrationDatabase) WithSession(ctx context.Context, lock bool, callback func(session MigrationSession) error) error {
+	conn, err := database.postgresUtil.GetPool().Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if lock {
+		_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, migrationLockKey)
+		if err != nil {
+			return err
+		}
+		defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1);`, migrationLockKey)
+	}
+
+	session := &postgresMigrationSession{conn: conn}
+	err = session.CreateTable(ctx)
+	if err != nil {
+		return err
+	}
+	return callback(session)
+}
+
+type postgresMigrationSession struct {
+	conn *pgxpool.Conn
+}
+
+func (session *postgresMigrationSession) CreateTable(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at bigint NOT NULL
+	);`
+	_, err := session.conn.Exec(ctx, query)
+	return err
+}
+
+func (session *postgresMigrationSession) AppliedVersions(ctx context.Context) (map[int64]int64, error) {
+	rows, err := session.conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	appliedVersions := map[int64]int64{}
+	var version, appliedAt int64
+	_, err = pgx.ForEachRow(rows, []any{&version, &appliedAt}, func() error {
+		appliedVersions[version] = appliedAt
+		return nil
+	})
+	return appliedVersions, err
+}
+
+func (session *postgresMigrationSession) Apply(ctx context.Context, migration Migration) error {
+	return pgx.BeginFunc(ctx, session.conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, migration.Up)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version,name,applied_at) VALUES ($1,$2,$3);`, migration.Version, migration.Name, time.Now().UnixMilli())
+		return err
+	})
+}
+
+func (session *postgresMigrationSession) Revert(ctx context.Context, migration Migration) error {
+	return pgx.BeginFunc(ctx, session.conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, migration.Down)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1;`, migration.Version)
+		return err
+	})
+}
+
+type sqliteMigrationDatabase struct {
+	db *sql.DB
+}
+
+// WithSession ignores lock, sqlite allows one writer at a time and ddl is transactional so a migration applied twice by concurrent processes fails on the schema_migrations primary key and rolls back
+func (database *sqliteMigrationDatabase) WithSession(ctx context.Context, lock bool, callback func(session MigrationSession) error) error {
+	conn, err := database.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	session := &sqliteMigrationSession{conn: conn}
+	err = session.CreateTable(ctx)
+	if err != nil {
+		return err
+	}
+	return callback(session)
+}
+
+type sqliteMigrationSession struct {
+	conn *sql.Conn
+}
+
+func (session *sqliteMigrationSession) CreateTable(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at integer NOT NULL
+	);`
+	_, err := session.conn.ExecContext(ctx, query)
+	return err
+}
+
+func (session *sqliteMigrationSession) AppliedVersions(ctx context.Context) (map[int64]int64, error) {
+	rows, err := session.conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	appliedVersions := map[int64]int64{}
+	for rows.Next() {
+		var version, appliedAt int64
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		appliedVersions[version] = appliedAt
+	}
+	return appliedVersions, rows.Err()
+}
+
+func (session *sqliteMigrationSession) Apply(ctx context.Context, migration Migration) error {
+	return session.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, migration.Up)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version,name,applied_at) VALUES (?,?,?);`, migration.Version, migration.Name, time.Now().UnixMilli())
+		return err
+	})
+}
+
+func (session *sqliteMigrationSession) Revert(ctx context.Context, migration Migration) error {
+	return session.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, migration.Down)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?;`, migration.Version)
+		return err
+	})
+}
+
+func (session *sqliteMigrationSession) inTransaction(ctx context.Context, callback func(tx *sql.Tx) error) error {
+	tx, err := session.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = callback(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
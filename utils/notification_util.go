@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// notification is a payload sent to a channel, it is queued on a transaction and delivered when the transaction commits
+type notification struct {
+	channel string
+	payload string
+}
+
+// localNotifier delivers notifications to the listeners of the same process, it stands in for LISTEN/NOTIFY of postgres
+// with sqlite and the fake where there is only one process writing
+type localNotifier struct {
+	mutex     sync.Mutex
+	listeners map[string]map[*localListener]struct{}
+}
+
+// localListener queues payloads so a slow listener never blocks the committing writer and never loses a payload
+type localListener struct {
+	mutex    sync.Mutex
+	payloads []string
+	wake     chan struct{}
+}
+
+func (notifier *localNotifier) notify(notifications []notification) {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	for _, notification := range notifications {
+		for listener := range notifier.listeners[notification.channel] {
+			listener.mutex.Lock()
+			listener.payloads = append(listener.payloads, notification.payload)
+			listener.mutex.Unlock()
+			select {
+			case listener.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// listen behaves like Listen of PostgresUtil, notify is called once with an empty payload as soon as channel is listened to
+func (notifier *localNotifier) listen(ctx context.Context, channel string, notify func(payload string)) error {
+	listener := &localListener{wake: make(chan struct{}, 1)}
+	notifier.mutex.Lock()
+	if notifier.listeners == nil {
+		notifier.listeners = map[string]map[*localListener]struct{}{}
+	}
+	if notifier.listeners[channel] == nil {
+		notifier.listeners[channel] = map[*localListener]struct{}{}
+	}
+	notifier.listeners[channel][listener] = struct{}{}
+	notifier.mutex.Unlock()
+	defer func() {
+		notifier.mutex.Lock()
+		delete(notifier.listeners[channel], listener)
+		notifier.mutex.Unlock()
+	}()
+
+	notify("")
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-listener.wake:
+		}
+		listener.mutex.Lock()
+		payloads := listener.payloads
+		listener.payloads = nil
+		listener.mutex.Unlock()
+		for _, payload := range payloads {
+			notify(payload)
+		}
+	}
+}
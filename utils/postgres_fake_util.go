@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errFakeTx = errors.New("fake transaction can not run queries, use a memory repository")
+
+// FakePostgresUtilImplementation hands out transactions that only count commits and rollbacks so services can run against a memory repository,
+// BeginTxError and PingError are returned by BeginTx and Ping when set
+type FakePostgresUtilImplementation struct {
+	BeginTxError error
+	PingError    error
+	mutex        sync.Mutex
+	begins       int
+	commits      int
+	rollbacks    int
+	notifier     localNotifier
+}
+
+func NewFakePostgresUtil() *FakePostgresUtilImplementation {
+	return &FakePostgresUtilImplementation{}
+}
+
+// GetPool returns nil, repositories used with the fake must not touch the querier
+func (util *FakePostgresUtilImplementation) GetPool() *pgxpool.Pool {
+	return nil
+}
+
+func (util *FakePostgresUtilImplementation) GetReadPool(ctx context.Context) *pgxpool.Pool {
+	return nil
+}
+
+func (util *FakePostgresUtilImplementation) Ping(ctx context.Context) error {
+	if util.PingError != nil {
+		return util.PingError
+	}
+	return ctx.Err()
+}
+
+func (util *FakePostgresUtilImplementation) BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error) {
+	if util.BeginTxError != nil {
+		return nil, util.BeginTxError
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	util.mutex.Lock()
+	defer util.mutex.Unlock()
+	util.begins++
+	return &fakeTx{util: util}, nil
+}
+
+func (util *FakePostgresUtilImplementation) Close() {
+}
+
+func (util *FakePostgresUtilImplementation) CommitOrRollback(tx pgx.Tx, ctx context.Context, err error) error {
+	if err == nil {
+		return tx.Commit(ctx)
+	}
+	return tx.Rollback(ctx)
+}
+
+// Notify delivers payload to the listeners of the fake when tx commits like NOTIFY in a postgres transaction
+func (util *FakePostgresUtilImplementation) Notify(tx pgx.Tx, ctx context.Context, channel string, payload string) error {
+	fake, ok := tx.(*fakeTx)
+	if !ok {
+		return errFakeTx
+	}
+	fake.notifications = append(fake.notifications, notification{channel: channel, payload: payload})
+	return nil
+}
+
+func (util *FakePostgresUtilImplementation) Listen(ctx context.Context, channel string, notify func(payload string)) error {
+	return util.notifier.listen(ctx, channel, notify)
+}
+
+// Counts returns how many transactions were started, committed and rolled back
+func (util *FakePostgresUtilImplementation) Counts() (begins int, commits int, rollbacks int) {
+	util.mutex.Lock()
+	defer util.mutex.Unlock()
+	return util.begins, util.commits, util.rollbacks
+}
+
+type fakeTx struct {
+	util          *FakePostgresUtilImplementation
+	parent        *fakeTx
+	closed        bool
+	notifications []notification
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx.closed {
+		return nil, pgx.ErrTxClosed
+	}
+	return &fakeTx{util: tx.util, parent: tx}, nil
+}
+
+// Commit of a savepoint is not counted, only the outer transaction is, notifications of a savepoint move to its parent
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	if tx.parent != nil {
+		tx.parent.notifications = append(tx.parent.notifications, tx.notifications...)
+		return nil
+	}
+	tx.util.mutex.Lock()
+	tx.util.commits++
+	tx.util.mutex.Unlock()
+	tx.util.notifier.notify(tx.notifications)
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	if tx.parent == nil {
+		tx.util.mutex.Lock()
+		tx.util.rollbacks++
+		tx.util.mutex.Unlock()
+	}
+	return nil
+}
+
+func (tx *fakeTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, errFakeTx
+}
+
+func (tx *fakeTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func (tx *fakeTx) LargeObjects() pgx.LargeObjects {
+	return pgx.LargeObjects{}
+}
+
+func (tx *fakeTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, errFakeTx
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, arguments ...any) (commandTag pgconn.CommandTag, err error) {
+	return pgconn.CommandTag{}, errFakeTx
+}
+
+func (tx *fakeTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, errFakeTx
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return errorRow{err: errFakeTx}
+}
+
+func (tx *fakeTx) Conn() *pgx.Conn {
+	return nil
+}
+
+// errorRow is the pgx.Row of transactions that can not run queries
+type errorRow struct {
+	err error
+}
+
+func (row errorRow) Scan(dest ...any) error {
+	return row.err
+}
@@ -1,11 +1,11 @@
 package utils
 
 import (
+	"blogging-platform-api/configs"
 	"context"
 	"errors"
-	"log"
-	"os"
-	"strconv"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,73 +14,199 @@ import (
 
 type PostgresUtil interface {
 	GetPool() *pgxpool.Pool
+	GetReadPool(ctx context.Context) *pgxpool.Pool
+	Ping(ctx context.Context) error
 	BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error)
 	Close()
 	CommitOrRollback(tx pgx.Tx, ctx context.Context, err error) error
+	// Notify sends payload to the listeners of channel in every process when tx commits, nothing is sent when tx rolls back
+	Notify(tx pgx.Tx, ctx context.Context, channel string, payload string) error
+	// Listen calls notify for every payload sent to channel until ctx is done or the connection fails, notify is called once with an empty payload
+	// as soon as channel is listened to so the caller can catch up on what was sent while it was not listening
+	Listen(ctx context.Context, channel string, notify func(payload string)) error
 }
 
 type PostgresUtilImplementation struct {
-	pool *pgxpool.Pool
+	pool        *pgxpool.Pool
+	replicas    []*postgresReplica
+	nextReplica atomic.Uint64
+	stopHealth  chan struct{}
 }
 
-func NewPostgresConnection() PostgresUtil {
-	println(time.Now().String(), "postgres: connecting to", os.Getenv("POSTGRES_HOST"))
+type postgresReplica struct {
+	host    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+type primaryReadsContextKey struct{}
+
+func NewPostgresConnection(config configs.PostgresConfig) PostgresUtil {
+	slog.Info("postgres: connecting", "host", config.PrimaryHost())
 	ctx := context.Background()
-	connectionString := "postgres://" + os.Getenv("POSTGRES_USERNAME") + ":" + os.Getenv("POSTGRES_PASSWORD") + "@" + os.Getenv("POSTGRES_HOST") + "/" + os.Getenv("POSTGRES_DATABASE")
-	config, err := pgxpool.ParseConfig(connectionString)
+	pool := newPostgresPool(config, "")
+	// postgres often starts after the api in containers, connection errors are retried with backoff and anything else like a wrong password fails right away
+	startupPolicy := RetryPolicy{Attempts: config.ConnectAttempts, BaseDelay: 500 * time.Millisecond, MaxDelay: 15 * time.Second}
+	attempt := 0
+	err := Retry(ctx, startupPolicy, func(err error) bool {
+		return IsConnectionError(err) || IsRetryableError(err)
+	}, func() error {
+		attempt++
+		err := pool.Ping(ctx)
+		if err != nil {
+			slog.Warn("postgres: connection attempt failed", "attempt", attempt, "error", err)
+		}
+		return err
+	})
 	if err != nil {
-		log.Fatalln("error when parse config: " + err.Error())
+		Fatal("error when pinging connection", "error", err)
 	}
+	slog.Info("postgres: connected", "host", config.PrimaryHost())
 
-	maxConnection, err := strconv.Atoi(os.Getenv("POSTGRES_MAX_CONNECTION"))
-	if err != nil {
-		log.Fatalln("error when converting max connection: " + err.Error())
+	util := &PostgresUtilImplementation{
+		pool:       pool,
+		stopHealth: make(chan struct{}),
 	}
-	config.MaxConns = int32(maxConnection)
-
-	maxConnectionIdletime, err := strconv.Atoi(os.Getenv("POSTGRES_MAX_IDLETIME"))
-	if err != nil {
-		log.Fatalln("error when converting max connection idletime: " + err.Error())
+	for _, host := range config.ReplicaHosts {
+		util.replicas = append(util.replicas, &postgresReplica{host: host, pool: newPostgresPool(config, host)})
 	}
-	config.MaxConnIdleTime = time.Second * time.Duration(maxConnectionIdletime)
-
-	maxConnectionLifetime, err := strconv.Atoi(os.Getenv("POSTGRES_MAX_LIFETIME"))
-	if err != nil {
-		log.Fatalln("error when converting max connection lifetime: " + err.Error())
+	if len(util.replicas) > 0 {
+		util.checkReplicas(ctx)
+		go util.watchReplicas(config.ReplicaHealthInterval)
 	}
-	config.MaxConnLifetime = time.Minute * time.Duration(maxConnectionLifetime)
+	return util
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+// newPostgresPool creates a pool for host with the credentials and limits of the primary, replicas are expected to share them, an empty host is the primary
+func newPostgresPool(config configs.PostgresConfig, host string) *pgxpool.Pool {
+	poolConfig, err := pgxpool.ParseConfig(config.ConnectionString(host))
 	if err != nil {
-		log.Fatalln("error when connecting to " + os.Getenv("POSTGRES_HOST") + ", error:" + err.Error())
+		Fatal("error when parse config", "error", err)
 	}
+	poolConfig.MaxConns = int32(config.MaxConnection)
+	poolConfig.MaxConnIdleTime = config.MaxIdletime
+	poolConfig.MaxConnLifetime = config.MaxLifetime
+	poolConfig.ConnConfig.ConnectTimeout = config.ConnectTimeout
+	poolConfig.ConnConfig.Tracer = PostgresTracer{}
 
-	err = pool.Ping(ctx)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatalln("error when pinging connection: " + err.Error())
+		Fatal("error when connecting", "host", poolConfig.ConnConfig.Host, "error", err)
 	}
+	return pool
+}
 
-	println(time.Now().String(), "postgres: connected to", os.Getenv("POSTGRES_HOST"))
+// ContextWithPrimaryReads makes GetReadPool return the primary for ctx, it is used for clients that wrote recently so they read their own writes
+func ContextWithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsContextKey{}, true)
+}
 
-	return &PostgresUtilImplementation{
-		pool: pool,
-	}
+func PrimaryReadsFromContext(ctx context.Context) bool {
+	primaryReads, _ := ctx.Value(primaryReadsContextKey{}).(bool)
+	return primaryReads
 }
 
 func (util *PostgresUtilImplementation) GetPool() *pgxpool.Pool {
 	return util.pool
 }
 
+// GetReadPool returns the next healthy replica in round robin order, the primary is returned when no replica is healthy or ctx asks for primary reads
+func (util *PostgresUtilImplementation) GetReadPool(ctx context.Context) *pgxpool.Pool {
+	if len(util.replicas) == 0 || PrimaryReadsFromContext(ctx) {
+		return util.pool
+	}
+	start := util.nextReplica.Add(1)
+	for i := range util.replicas {
+		replica := util.replicas[(start+uint64(i))%uint64(len(util.replicas))]
+		if replica.healthy.Load() {
+			return replica.pool
+		}
+	}
+	return util.pool
+}
+
+func (util *PostgresUtilImplementation) watchReplicas(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-util.stopHealth:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			util.checkReplicas(ctx)
+			cancel()
+		}
+	}
+}
+
+// checkReplicas pings every replica and logs when a replica changes between healthy and unhealthy
+func (util *PostgresUtilImplementation) checkReplicas(ctx context.Context) {
+	for _, replica := range util.replicas {
+		err := replica.pool.Ping(ctx)
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			slog.Info("postgres: replica is healthy", "host", replica.host)
+		} else {
+			slog.Warn("postgres: replica is unhealthy", "host", replica.host, "error", err)
+		}
+	}
+}
+
+func (util *PostgresUtilImplementation) Ping(ctx context.Context) error {
+	return util.pool.Ping(ctx)
+}
+
 func (util *PostgresUtilImplementation) BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error) {
 	return util.pool.BeginTx(ctx, options)
 }
 
 func (util *PostgresUtilImplementation) Close() {
+	close(util.stopHealth)
+	for _, replica := range util.replicas {
+		replica.pool.Close()
+	}
 	util.pool.Close()
-	println(time.Now().String(), "postgres closed properly")
+	slog.Info("postgres closed properly")
 }
 
+// CommitOrRollback returns the error of a failed commit, postgres reports serialization failures and deferred constraint violations
+// only at commit and the caller has to see them to retry or report them instead of taking a rolled back write for a success
 func (util *PostgresUtilImplementation) CommitOrRollback(tx pgx.Tx, ctx context.Context, err error) error {
+	return commitOrRollback(tx, ctx, err)
+}
+
+func (util *PostgresUtilImplementation) Notify(tx pgx.Tx, ctx context.Context, channel string, payload string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_notify($1, $2);`, channel, payload)
+	return err
+}
+
+// Listen takes a connection out of the pool for as long as it listens, the connection is closed afterwards so no other query runs on a listening connection
+func (util *PostgresUtilImplementation) Listen(ctx context.Context, channel string, notify func(payload string)) error {
+	pooledConn, err := util.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pooledConn.Hijack()
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return err
+	}
+	notify("")
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		notify(notification.Payload)
+	}
+}
+
+func commitOrRollback(tx pgx.Tx, ctx context.Context, err error) error {
 	if err == nil {
 		errCommit := tx.Commit(ctx)
 		if errCommit != nil && !errors.Is(errCommit, pgx.ErrTxClosed) {
@@ -88,7 +214,7 @@ func (util *PostgresUtilImplementation) CommitOrRollback(tx pgx.Tx, ctx context.
 			if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
 				return errRollback
 			}
-			return nil
+			return errCommit
 		}
 		return nil
 	} else {
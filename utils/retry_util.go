@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy retries an operation at most Attempts times in total, the delay before retry n is a random duration up to BaseDelay * 2^n capped at MaxDelay
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// NewDatabaseRetryPolicy is the policy of database calls, it waits 50ms before the first retry and never more than a second
+func NewDatabaseRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		Attempts:  attempts,
+		BaseDelay: 50 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+}
+
+// Backoff returns the full jitter delay before retry number attempt, the first retry is attempt 0
+func (policy RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := policy.MaxDelay
+	if attempt < 30 && policy.BaseDelay<<attempt < policy.MaxDelay {
+		delay = policy.BaseDelay << attempt
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+// Retry calls operation until it succeeds, returns an error retryable does not accept, the attempts are used up or ctx is done, the last error is returned
+func Retry(ctx context.Context, policy RetryPolicy, retryable func(err error) bool, operation func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = operation()
+		if err == nil || attempt+1 >= policy.Attempts || !retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(policy.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsRetryableError reports errors after which running the same statement again is safe, the statement was rolled back by a serialization failure or deadlock
+// or it never reached the database
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		return pgError.Code == "40001" || pgError.Code == "40P01" || pgError.Code == "57P03"
+	}
+	return pgconn.SafeToRetry(err)
+}
+
+// IsConnectionError reports errors caused by the database being unreachable or dropping the connection, the statement may or may not have run
+func IsConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		// class 08 is connection exception, 57P01 to 57P03 are shutdowns and a database that is starting
+		return pgError.Code[:2] == "08" || pgError.Code == "57P01" || pgError.Code == "57P02" || pgError.Code == "57P03"
+	}
+	var connectError *pgconn.ConnectError
+	var netError net.Error
+	return errors.As(err, &connectError) || errors.As(err, &netError) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) || pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"blogging-platform-api/configs"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "modernc.org/sqlite"
+)
+
+var errSqliteTx = errors.New("sqlite transaction can not run pgx queries, use a sqlite repository")
+
+// SqliteUtil lets services written against PostgresUtil run on sqlite, BeginTx returns a *SqliteTx that sqlite repositories unwrap
+type SqliteUtil interface {
+	PostgresUtil
+	GetDB() *sql.DB
+}
+
+type SqliteUtilImplementation struct {
+	db       *sql.DB
+	notifier localNotifier
+}
+
+func NewSqliteConnection(config configs.SqliteConfig) SqliteUtil {
+	path := config.Path
+	slog.Info("sqlite: opening", "path", path)
+	sqliteUtil, err := OpenSqlite(path)
+	if err != nil {
+		Fatal("error when opening sqlite", "path", path, "error", err)
+	}
+	slog.Info("sqlite: opened", "path", path)
+	return sqliteUtil
+}
+
+// OpenSqlite opens the database file at path in wal mode, writers wait up to 5 seconds for the write lock instead of failing right away
+func OpenSqlite(path string) (SqliteUtil, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SqliteUtilImplementation{
+		db: db,
+	}, nil
+}
+
+func (util *SqliteUtilImplementation) GetDB() *sql.DB {
+	return util.db
+}
+
+// GetPool returns nil, sqlite repositories use the database of GetDB when the querier is not a *SqliteTx
+func (util *SqliteUtilImplementation) GetPool() *pgxpool.Pool {
+	return nil
+}
+
+// GetReadPool returns nil, sqlite has no replicas
+func (util *SqliteUtilImplementation) GetReadPool(ctx context.Context) *pgxpool.Pool {
+	return nil
+}
+
+func (util *SqliteUtilImplementation) Ping(ctx context.Context) error {
+	return util.db.PingContext(ctx)
+}
+
+// BeginTx ignores options, sqlite transactions are always serializable
+func (util *SqliteUtilImplementation) BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := util.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SqliteTx{Tx: tx, savepoints: new(int), notifier: &util.notifier}, nil
+}
+
+func (util *SqliteUtilImplementation) Close() {
+	util.db.Close()
+	slog.Info("sqlite closed properly")
+}
+
+func (util *SqliteUtilImplementation) CommitOrRollback(tx pgx.Tx, ctx context.Context, err error) error {
+	return commitOrRollback(tx, ctx, err)
+}
+
+// Notify only reaches listeners of this process, sqlite has no LISTEN/NOTIFY and is not shared between api instances
+func (util *SqliteUtilImplementation) Notify(tx pgx.Tx, ctx context.Context, channel string, payload string) error {
+	sqliteTx, ok := tx.(*SqliteTx)
+	if !ok {
+		return errSqliteTx
+	}
+	sqliteTx.notifications = append(sqliteTx.notifications, notification{channel: channel, payload: payload})
+	return nil
+}
+
+func (util *SqliteUtilImplementation) Listen(ctx context.Context, channel string, notify func(payload string)) error {
+	return util.notifier.listen(ctx, channel, notify)
+}
+
+// SqliteTx adapts a *sql.Tx to pgx.Tx so it can be passed where services expect a transaction, Begin creates a savepoint,
+// notifications of a savepoint move to its parent when it is released and are delivered when the outer transaction commits
+type SqliteTx struct {
+	Tx            *sql.Tx
+	savepoint     string
+	savepoints    *int
+	closed        bool
+	parent        *SqliteTx
+	notifier      *localNotifier
+	notifications []notification
+}
+
+func (tx *SqliteTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx.closed {
+		return nil, pgx.ErrTxClosed
+	}
+	*tx.savepoints++
+	savepoint := "sp_" + strconv.Itoa(*tx.savepoints)
+	_, err := tx.Tx.ExecContext(ctx, "SAVEPOINT "+savepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &SqliteTx{Tx: tx.Tx, savepoint: savepoint, savepoints: tx.savepoints, parent: tx, notifier: tx.notifier}, nil
+}
+
+func (tx *SqliteTx) Commit(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	if tx.savepoint != "" {
+		_, err := tx.Tx.ExecContext(ctx, "RELEASE "+tx.savepoint)
+		if err == nil {
+			tx.parent.notifications = append(tx.parent.notifications, tx.notifications...)
+		}
+		return err
+	}
+	err := sqliteTxError(tx.Tx.Commit())
+	if err == nil {
+		tx.notifier.notify(tx.notifications)
+	}
+	return err
+}
+
+func (tx *SqliteTx) Rollback(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	if tx.savepoint != "" {
+		_, err := tx.Tx.ExecContext(ctx, "ROLLBACK TO "+tx.savepoint+"; RELEASE "+tx.savepoint)
+		return err
+	}
+	return sqliteTxError(tx.Tx.Rollback())
+}
+
+func (tx *SqliteTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, errSqliteTx
+}
+
+func (tx *SqliteTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func (tx *SqliteTx) LargeObjects() pgx.LargeObjects {
+	return pgx.LargeObjects{}
+}
+
+func (tx *SqliteTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, errSqliteTx
+}
+
+func (tx *SqliteTx) Exec(ctx context.Context, sql string, arguments ...any) (commandTag pgconn.CommandTag, err error) {
+	return pgconn.CommandTag{}, errSqliteTx
+}
+
+func (tx *SqliteTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, errSqliteTx
+}
+
+func (tx *SqliteTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return errorRow{err: errSqliteTx}
+}
+
+func (tx *SqliteTx) Conn() *pgx.Conn {
+	return nil
+}
+
+// sqliteTxError reports a finished transaction the way pgx does so CommitOrRollback treats both drivers alike
+func sqliteTxError(err error) error {
+	if errors.Is(err, sql.ErrTxDone) {
+		return pgx.ErrTxClosed
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"blogging-platform-api/configs"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "blogging-platform-api"
+
+// Tracer is the tracer every layer starts its spans with, it does nothing until SetupTracing installed an exporter
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// SetupTracing installs the exporter of config (otlp, stdout or none) and the w3c traceparent propagator,
+// the otlp exporter sends over http to the endpoint of config (http://localhost:4318 when empty), shutdown flushes the spans not exported yet
+func SetupTracing(ctx context.Context, config configs.TracingConfig) (shutdown func(ctx context.Context) error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case "otlp":
+		var options []otlptracehttp.Option
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return func(ctx context.Context) error { return nil }
+	}
+	if err != nil {
+		Fatal("error when creating trace exporter", "error", err)
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES adds attributes, the service name of config wins over it
+	serviceResource, err := resource.Merge(resource.Default(), resource.Environment())
+	if err != nil {
+		Fatal("error when creating trace resource", "error", err)
+	}
+	serviceResource, err = resource.Merge(serviceResource, resource.NewSchemaless(semconv.ServiceName(config.ServiceName)))
+	if err != nil {
+		Fatal("error when creating trace resource", "error", err)
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(serviceResource),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	slog.Info("tracing: exporting spans", "exporter", config.Exporter)
+	return tracerProvider.Shutdown
+}
+
+// EndSpan records err on span before ending it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// PostgresTracer is a pgx tracer that creates a span for every query, batch and copy sent through the pool,
+// the sql is recorded without arguments so no blog content ends up in the traces
+type PostgresTracer struct{}
+
+type postgresSpanContextKey struct{}
+
+func (tracer PostgresTracer) start(ctx context.Context, name string, attributes ...otelattribute.KeyValue) context.Context {
+	attributes = append(attributes, semconv.DBSystemPostgreSQL)
+	ctx, span := Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return context.WithValue(ctx, postgresSpanContextKey{}, span)
+}
+
+func (tracer PostgresTracer) end(ctx context.Context, err error, attributes ...otelattribute.KeyValue) {
+	span, ok := ctx.Value(postgresSpanContextKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(attributes...)
+	EndSpan(span, err)
+}
+
+func (tracer PostgresTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return tracer.start(ctx, "postgres query", semconv.DBQueryText(data.SQL))
+}
+
+func (tracer PostgresTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	tracer.end(ctx, data.Err, otelattribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
+
+func (tracer PostgresTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return tracer.start(ctx, "postgres batch", otelattribute.Int("db.batch.size", data.Batch.Len()))
+}
+
+func (tracer PostgresTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+}
+
+func (tracer PostgresTracer) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+	tracer.end(ctx, data.Err)
+}
+
+func (tracer PostgresTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return tracer.start(ctx, "postgres copy", semconv.DBCollectionName(data.TableName.Sanitize()))
+}
+
+func (tracer PostgresTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	tracer.end(ctx, data.Err, otelattribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	idtranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const DefaultLocale = "en"
+
+type translatorContextKey struct{}
+
+var (
+	validatorOnce             sync.Once
+	sharedValidate            *validator.Validate
+	sharedUniversalTranslator *ut.UniversalTranslator
+)
+
+// NewValidator returns a validator reporting json field names and a universal translator holding the messages of every supported locale, english is the fallback,
+// both are built once and shared because a translation only applies to errors of the validator it was registered with
+func NewValidator() (*validator.Validate, *ut.UniversalTranslator) {
+	validatorOnce.Do(func() {
+		sharedValidate, sharedUniversalTranslator = newValidator()
+	})
+	return sharedValidate, sharedUniversalTranslator
+}
+
+// DefaultTranslator returns the english translator of the shared validator
+func DefaultTranslator() ut.Translator {
+	_, universalTranslator := NewValidator()
+	translator, _ := universalTranslator.GetTranslator(DefaultLocale)
+	return translator
+}
+
+func newValidator() (*validator.Validate, *ut.UniversalTranslator) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	english := en.New()
+	universalTranslator := ut.New(english, english, id.New())
+	translator, _ := universalTranslator.GetTranslator("en")
+	err := entranslations.RegisterDefaultTranslations(validate, translator)
+	if err != nil {
+		Fatal("error when registering en translations", "error", err)
+	}
+	translator, _ = universalTranslator.GetTranslator("id")
+	err = idtranslations.RegisterDefaultTranslations(validate, translator)
+	if err == nil {
+		err = registerTranslation(validate, translator, "datetime", "{0} tidak sesuai dengan format {1}")
+	}
+	if err != nil {
+		Fatal("error when registering id translations", "error", err)
+	}
+	for locale, messages := range schemaMessages {
+		translator, _ = universalTranslator.GetTranslator(locale)
+		for key, text := range messages {
+			err = translator.Add("schema-"+key, text, false)
+			if err != nil {
+				Fatal("error when registering "+locale+" schema translations", "error", err)
+			}
+		}
+	}
+	return validate, universalTranslator
+}
+
+// schemaMessages are the messages of the rules of the openapi schema keyed by locale and rule, they follow the wording of the validator messages
+// so a client gets the same message whether the middleware or the service rejects a field, {0} is the field and {1} the parameter of the rule
+var schemaMessages = map[string]map[string]string{
+	"en": {
+		"required":   "{0} is a required field",
+		"type":       "{0} must be of type {1}",
+		"min-string": "{0} must be at least {1} characters in length",
+		"max-string": "{0} must be a maximum of {1} characters in length",
+		"min-items":  "{0} must contain at least {1} items",
+		"max-items":  "{0} must contain at maximum {1} items",
+		"min-number": "{0} must be {1} or greater",
+		"max-number": "{0} must be {1} or less",
+		"oneof":      "{0} must be one of [{1}]",
+		"datetime":   "{0} does not match the {1} format",
+	},
+	"id": {
+		"required":   "{0} wajib diisi",
+		"type":       "{0} harus bertipe {1}",
+		"min-string": "panjang minimal {0} adalah {1} karakter",
+		"max-string": "panjang maksimal {0} adalah {1} karakter",
+		"min-items":  "{0} harus berisi minimal {1} item",
+		"max-items":  "{0} harus berisi maksimal {1} item",
+		"min-number": "{0} harus {1} atau lebih besar",
+		"max-number": "{0} harus {1} atau kurang",
+		"oneof":      "{0} harus berupa salah satu dari [{1}]",
+		"datetime":   "{0} tidak sesuai dengan format {1}",
+	},
+}
+
+// SchemaMessage translates the message of a failed openapi schema rule, english is used when translator is nil
+func SchemaMessage(translator ut.Translator, key string, field string, param string) string {
+	if translator != nil {
+		message, err := translator.T("schema-"+key, field, param)
+		if err == nil {
+			return message
+		}
+	}
+	return strings.NewReplacer("{0}", field, "{1}", param).Replace(schemaMessages[DefaultLocale][key])
+}
+
+func registerTranslation(validate *validator.Validate, translator ut.Translator, tag string, text string) error {
+	return validate.RegisterTranslation(tag, translator, func(translator ut.Translator) error {
+		return translator.Add(tag, text, true)
+	}, func(translator ut.Translator, fieldError validator.FieldError) string {
+		message, err := translator.T(tag, fieldError.Field(), fieldError.Param())
+		if err != nil {
+			return fieldError.Error()
+		}
+		return message
+	})
+}
+
+// FindTranslator picks the supported locale with the highest quality in an Accept-Language header, it falls back to english
+func FindTranslator(universalTranslator *ut.UniversalTranslator, acceptLanguage string) ut.Translator {
+	type language struct {
+		locale  string
+		quality float64
+	}
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		locale, parameters, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale == "" || locale == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(parameters), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		languages = append(languages, language{locale: strings.ToLower(strings.ReplaceAll(locale, "-", "_")), quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	for _, language := range languages {
+		if language.quality <= 0 {
+			continue
+		}
+		base, _, _ := strings.Cut(language.locale, "_")
+		for _, locale := range []string{language.locale, base} {
+			translator, found := universalTranslator.GetTranslator(locale)
+			if found {
+				return translator
+			}
+		}
+	}
+	translator, _ := universalTranslator.GetTranslator(DefaultLocale)
+	return translator
+}
+
+func ContextWithTranslator(ctx context.Context, translator ut.Translator) context.Context {
+	return context.WithValue(ctx, translatorContextKey{}, translator)
+}
+
+// TranslatorFromContext returns the translator chosen for the request, it is nil outside of http requests
+func TranslatorFromContext(ctx context.Context) ut.Translator {
+	translator, _ := ctx.Value(translatorContextKey{}).(ut.Translator)
+	return translator
+}